@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"payment-receiver/infrastructure"
+	"payment-receiver/usecase"
+
+	"gopkg.in/yaml.v3"
+)
+
+// dispatcherConfig is the YAML file referenced by DISPATCHER_CONFIG.
+// When Destinations is set, events are routed by Routes instead of being sent
+// to the single OUTBOX_QUEUE backend.
+type dispatcherConfig struct {
+	Destinations        []destinationConfig    `yaml:"destinations"`
+	Routes              []routeConfig          `yaml:"routes"`
+	DefaultDestinations []string               `yaml:"default_destinations"`
+	HTTPSubscribers     []httpSubscriberConfig `yaml:"http_subscribers"`
+}
+
+// destinationConfig names a queue backend (redis, kafka, nats or http) and
+// the settings that set it apart from other destinations of the same type.
+type destinationConfig struct {
+	Name          string `yaml:"name"`
+	Type          string `yaml:"type"`
+	Optional      bool   `yaml:"optional"`
+	queueSettings `yaml:",inline"`
+}
+
+// queueSettings are the backend options of one queue. Unset fields fall back
+// to the process-wide environment (see withEnvDefaults).
+type queueSettings struct {
+	// Brokers and Topic configure kafka.
+	Brokers []string `yaml:"brokers"`
+	Topic   string   `yaml:"topic"`
+	// URL is the NATS server.
+	URL string `yaml:"url"`
+	// Stream is the Redis stream key or the NATS JetStream stream.
+	Stream string `yaml:"stream"`
+	// Subject is the NATS subject prefix.
+	Subject string `yaml:"subject"`
+	// Subscribers selects http_subscribers by name; empty selects all.
+	Subscribers []string `yaml:"subscribers"`
+}
+
+// withEnvDefaults fills the fields backend uses from KAFKA_BROKERS,
+// KAFKA_TOPIC, NATS_URL, NATS_STREAM, NATS_SUBJECT_PREFIX and REDIS_QUEUE.
+func (s queueSettings) withEnvDefaults(backend string) queueSettings {
+	switch backend {
+	case "kafka":
+		if len(s.Brokers) == 0 {
+			s.Brokers = splitList(os.Getenv("KAFKA_BROKERS"))
+		}
+		if s.Topic == "" {
+			s.Topic = getenv("KAFKA_TOPIC", "payment-events")
+		}
+	case "nats":
+		if s.URL == "" {
+			s.URL = os.Getenv("NATS_URL")
+		}
+		if s.Stream == "" {
+			s.Stream = getenv("NATS_STREAM", "PAYMENTS")
+		}
+		if s.Subject == "" {
+			s.Subject = getenv("NATS_SUBJECT_PREFIX", "payments")
+		}
+	case "", "redis":
+		if s.Stream == "" {
+			s.Stream = getenv("REDIS_QUEUE", infrastructure.DefaultRedisStream)
+		}
+	}
+	return s
+}
+
+type routeConfig struct {
+	Match        routeMatchConfig `yaml:"match"`
+	Destinations []string         `yaml:"destinations"`
+}
+
+type routeMatchConfig struct {
+	EventTypes []string `yaml:"event_types"`
+	Statuses   []string `yaml:"statuses"`
+	Currencies []string `yaml:"currencies"`
+	MinAmount  *int64   `yaml:"min_amount"`
+	MaxAmount  *int64   `yaml:"max_amount"`
+}
+
+type httpSubscriberConfig struct {
+	Name             string        `yaml:"name"`
+	URL              string        `yaml:"url"`
+	SecretEnv        string        `yaml:"secret_env"`
+	MaxAttempts      int           `yaml:"max_attempts"`
+	InitialBackoff   time.Duration `yaml:"initial_backoff"`
+	MaxBackoff       time.Duration `yaml:"max_backoff"`
+	BreakerThreshold int           `yaml:"breaker_threshold"`
+	BreakerCooldown  time.Duration `yaml:"breaker_cooldown"`
+}
+
+// loadConfig reads the dispatcher config file. An empty path yields an empty config.
+func loadConfig(path string) (*dispatcherConfig, error) {
+	cfg := &dispatcherConfig{}
+	if path == "" {
+		return cfg, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dispatcher config: %w", err)
+	}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse dispatcher config: %w", err)
+	}
+	return cfg, nil
+}
+
+// httpSubscribers resolves subscriber secrets from the environment so they
+// never live in the config file. names selects subscribers; empty selects all.
+func (c *dispatcherConfig) httpSubscribers(names []string) ([]infrastructure.HTTPSubscriber, error) {
+	for _, name := range names {
+		if !slices.ContainsFunc(c.HTTPSubscribers, func(s httpSubscriberConfig) bool { return s.Name == name }) {
+			return nil, fmt.Errorf("unknown http subscriber %q", name)
+		}
+	}
+
+	subs := make([]infrastructure.HTTPSubscriber, 0, len(c.HTTPSubscribers))
+	for _, s := range c.HTTPSubscribers {
+		if len(names) > 0 && !slices.Contains(names, s.Name) {
+			continue
+		}
+		subs = append(subs, infrastructure.HTTPSubscriber{
+			Name:             s.Name,
+			URL:              s.URL,
+			Secret:           os.Getenv(s.SecretEnv),
+			MaxAttempts:      s.MaxAttempts,
+			InitialBackoff:   s.InitialBackoff,
+			MaxBackoff:       s.MaxBackoff,
+			BreakerThreshold: s.BreakerThreshold,
+			BreakerCooldown:  s.BreakerCooldown,
+		})
+	}
+	return subs, nil
+}
+
+func (c *dispatcherConfig) routes() []usecase.Route {
+	routes := make([]usecase.Route, 0, len(c.Routes))
+	for _, r := range c.Routes {
+		routes = append(routes, usecase.Route{
+			Match: usecase.RouteMatch{
+				EventTypes: r.Match.EventTypes,
+				Statuses:   r.Match.Statuses,
+				Currencies: r.Match.Currencies,
+				MinAmount:  r.Match.MinAmount,
+				MaxAmount:  r.Match.MaxAmount,
+			},
+			Destinations: r.Destinations,
+		})
+	}
+	return routes
+}
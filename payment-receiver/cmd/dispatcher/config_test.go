@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"payment-receiver/codec"
+	"payment-receiver/domain"
+	pb "payment-receiver/gen/proto"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, yaml string) *dispatcherConfig {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "dispatcher.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(yaml), 0o600))
+	cfg, err := loadConfig(path)
+	require.NoError(t, err)
+	return cfg
+}
+
+func TestQueueSettings_PerDestinationOverridesEnv(t *testing.T) {
+	t.Setenv("KAFKA_BROKERS", "k1:9092")
+	t.Setenv("KAFKA_TOPIC", "env-topic")
+	cfg := writeConfig(t, `
+destinations:
+  - name: payments
+    type: kafka
+    topic: payments
+  - name: audit
+    type: kafka
+    topic: payments-audit
+    brokers: [k2:9092]
+  - name: legacy
+    type: kafka
+`)
+
+	require.Len(t, cfg.Destinations, 3)
+	var got []queueSettings
+	for _, d := range cfg.Destinations {
+		got = append(got, d.queueSettings.withEnvDefaults(d.Type))
+	}
+	assert.Equal(t, []queueSettings{
+		{Brokers: []string{"k1:9092"}, Topic: "payments"},
+		{Brokers: []string{"k2:9092"}, Topic: "payments-audit"},
+		{Brokers: []string{"k1:9092"}, Topic: "env-topic"},
+	}, got)
+}
+
+func TestNewQueue_TwoRedisDestinationsUseTheirOwnStreams(t *testing.T) {
+	mr := miniredis.RunT(t)
+	t.Setenv("REDIS_ADDR", mr.Addr())
+	cfg := writeConfig(t, `
+destinations:
+  - name: payments
+    type: redis
+    stream: payments
+  - name: audit
+    type: redis
+    stream: payments-audit
+`)
+
+	payment := &pb.PaymentEvent{Id: "evt_001", Status: "paid", Currency: "USD", Amount: 1200}
+	event, err := domain.NewOutboxEvent("evt_001", "payment_event", time.Now(), payment, codec.Protobuf)
+	require.NoError(t, err)
+
+	for _, d := range cfg.Destinations {
+		queue, closeQueue, err := newQueue(d.Type, d.queueSettings, cfg, nil)
+		require.NoError(t, err)
+		t.Cleanup(closeQueue)
+		require.NoError(t, queue.Enqueue(context.Background(), event))
+	}
+
+	for _, stream := range []string{"payments", "payments-audit"} {
+		entries, err := mr.Stream(stream)
+		require.NoError(t, err, stream)
+		assert.Len(t, entries, 1, stream)
+	}
+}
+
+func TestHTTPSubscribers_SelectsByName(t *testing.T) {
+	cfg := writeConfig(t, `
+http_subscribers:
+  - name: ledger
+    url: https://ledger.example/hooks
+  - name: crm
+    url: https://crm.example/hooks
+`)
+
+	subs, err := cfg.httpSubscribers([]string{"crm"})
+	require.NoError(t, err)
+	require.Len(t, subs, 1)
+	assert.Equal(t, "https://crm.example/hooks", subs[0].URL)
+
+	all, err := cfg.httpSubscribers(nil)
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	_, err = cfg.httpSubscribers([]string{"billing"})
+	assert.ErrorContains(t, err, "billing")
+}
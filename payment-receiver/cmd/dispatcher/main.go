@@ -2,10 +2,18 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"payment-receiver/eventtype"
 	"payment-receiver/infrastructure"
+	"payment-receiver/migrations"
+	"payment-receiver/repository"
 	"payment-receiver/usecase"
 )
 
@@ -20,18 +28,54 @@ func main() {
 	}
 	defer db.Close()
 
-	// 2. Repository 初期化
-	repo := infrastructure.NewPostgresOutbox(db)
+	// "migrate up|down [N]|status|version" manages the schema and exits
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := migrations.Command(ctx, db, os.Args[2:], os.Stdout); err != nil {
+			log.Fatalf("migrate: %v", err)
+		}
+		return
+	}
 
-	// 3. Redis キュー初期化
-	queue := infrastructure.NewRedisQueue(
-		os.Getenv("REDIS_ADDR"),
-		os.Getenv("REDIS_PASSWORD"),
-		"payment-events",
-	)
+	// Refuse to run on an older schema; MIGRATE_ON_START=true applies pending migrations first
+	if err := migrations.EnsureCurrent(ctx, db, os.Getenv("MIGRATE_ON_START") == "true"); err != nil {
+		log.Fatalf("database schema: %v", err)
+	}
+
+	// 2. Repository 初期化 (POSTGRES_DRIVER=pgx serves the outbox from a pgx pool)
+	var repo repository.OutboxRepository = infrastructure.NewPostgresOutbox(db)
+	pool, err := infrastructure.NewPgxPoolFromEnv(ctx, dsn)
+	if err != nil {
+		log.Fatalf("failed to connect to Postgres: %v", err)
+	}
+	if pool != nil {
+		defer pool.Close()
+		repo = infrastructure.NewPgxOutbox(pool)
+	}
 
-	// 4. Dispatcher 構築
-	dispatcher := usecase.NewOutboxDispatcher(repo, queue)
+	cfg, err := loadConfig(os.Getenv("DISPATCHER_CONFIG"))
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+
+	// 3. キュー初期化 (OUTBOX_QUEUE: redis | kafka | nats | http, or routed by config)
+	var queue usecase.OutboxQueue
+	var closeQueue func()
+	if len(cfg.Destinations) > 0 {
+		queue, closeQueue, err = newRouter(cfg, db)
+	} else {
+		queue, closeQueue, err = newQueue(os.Getenv("OUTBOX_QUEUE"), queueSettings{}, cfg, db)
+	}
+	if err != nil {
+		log.Fatalf("failed to initialize queue: %v", err)
+	}
+	defer closeQueue()
+
+	// 4. Dispatcher 構築 (OUTBOX_MAX_ATTEMPTS failed deliveries mark an event failed)
+	maxAttempts, err := strconv.Atoi(getenv("OUTBOX_MAX_ATTEMPTS", "10"))
+	if err != nil {
+		log.Fatalf("invalid OUTBOX_MAX_ATTEMPTS: %v", err)
+	}
+	dispatcher := usecase.NewOutboxDispatcher(repo, queue, infrastructure.NewPostgresDispatcherControl(db), maxAttempts)
 
 	// 5. 実行
 	log.Println("Running Outbox Dispatcher...")
@@ -41,3 +85,161 @@ func main() {
 
 	log.Println("Dispatcher finished.")
 }
+
+// newQueue builds the OutboxQueue for the configured backend, defaulting to
+// Redis. Unset settings are read from the environment.
+func newQueue(
+	backend string,
+	settings queueSettings,
+	cfg *dispatcherConfig,
+	db *sql.DB,
+) (usecase.OutboxQueue, func(), error) {
+	source := os.Getenv("EVENT_SOURCE")
+	types := eventtype.Default()
+	settings = settings.withEnvDefaults(backend)
+
+	switch backend {
+	case "kafka":
+		queue, err := infrastructure.NewKafkaQueue(
+			settings.Brokers,
+			settings.Topic,
+			source,
+			types,
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+		return queue, queue.Close, nil
+	case "nats":
+		queue, err := infrastructure.NewNATSQueue(
+			settings.URL,
+			settings.Stream,
+			settings.Subject,
+			source,
+			types,
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+		return queue, queue.Close, nil
+	case "http":
+		subscribers, err := cfg.httpSubscribers(settings.Subscribers)
+		if err != nil {
+			return nil, nil, err
+		}
+		queue, err := infrastructure.NewHTTPQueue(
+			subscribers,
+			infrastructure.NewPostgresDeliveryAttempts(db),
+			source,
+			types,
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+		return queue, func() {}, nil
+	case "", "redis":
+		redisCfg, err := redisConfigFromEnv(settings.Stream, source)
+		if err != nil {
+			return nil, nil, err
+		}
+		queue, err := infrastructure.NewRedisQueue(redisCfg, types)
+		if err != nil {
+			return nil, nil, err
+		}
+		return queue, queue.Close, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown OUTBOX_QUEUE %q", backend)
+	}
+}
+
+// newRouter builds an OutboxRouter over the destinations declared in cfg.
+func newRouter(cfg *dispatcherConfig, db *sql.DB) (usecase.OutboxQueue, func(), error) {
+	var closers []func()
+	closeAll := func() {
+		for _, c := range closers {
+			c()
+		}
+	}
+
+	destinations := make([]usecase.Destination, 0, len(cfg.Destinations))
+	for _, d := range cfg.Destinations {
+		queue, closeQueue, err := newQueue(d.Type, d.queueSettings, cfg, db)
+		if err != nil {
+			closeAll()
+			return nil, nil, fmt.Errorf("destination %s: %w", d.Name, err)
+		}
+		closers = append(closers, closeQueue)
+		destinations = append(destinations, usecase.Destination{
+			Name:     d.Name,
+			Queue:    queue,
+			Optional: d.Optional,
+		})
+	}
+
+	router, err := usecase.NewOutboxRouter(
+		destinations,
+		cfg.routes(),
+		cfg.DefaultDestinations,
+		infrastructure.NewPostgresDestinationDeliveries(db),
+	)
+	if err != nil {
+		closeAll()
+		return nil, nil, err
+	}
+	return router, closeAll, nil
+}
+
+// getenv returns the environment variable key, or fallback when it is unset.
+func getenv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// redisConfigFromEnv reads the Redis client options shared with the webhook
+// (see infrastructure.RedisClientConfigFromEnv) and the options of stream.
+func redisConfigFromEnv(stream, source string) (infrastructure.RedisQueueConfig, error) {
+	client, err := infrastructure.RedisClientConfigFromEnv()
+	if err != nil {
+		return infrastructure.RedisQueueConfig{}, err
+	}
+	cfg := infrastructure.RedisQueueConfig{
+		RedisClientConfig: client,
+		Stream:            stream,
+		Source:            source,
+	}
+
+	if v := os.Getenv("REDIS_STREAM_MAXLEN"); v != "" {
+		if cfg.MaxLen, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return cfg, fmt.Errorf("invalid REDIS_STREAM_MAXLEN: %w", err)
+		}
+	}
+	if v := os.Getenv("REDIS_STREAM_MAX_AGE"); v != "" {
+		if cfg.MaxAge, err = time.ParseDuration(v); err != nil {
+			return cfg, fmt.Errorf("invalid REDIS_STREAM_MAX_AGE: %w", err)
+		}
+	}
+	if v := os.Getenv("REDIS_DEDUP_TTL"); v != "" {
+		if cfg.DedupTTL, err = time.ParseDuration(v); err != nil {
+			return cfg, fmt.Errorf("invalid REDIS_DEDUP_TTL: %w", err)
+		}
+	}
+	if v := os.Getenv("REDIS_STREAM_ID_FROM_EVENT_TIME"); v != "" {
+		if cfg.IDFromEventTime, err = strconv.ParseBool(v); err != nil {
+			return cfg, fmt.Errorf("invalid REDIS_STREAM_ID_FROM_EVENT_TIME: %w", err)
+		}
+	}
+	return cfg, nil
+}
+
+// splitList splits a comma-separated list, dropping empty entries.
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
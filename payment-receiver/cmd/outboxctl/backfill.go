@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"payment-receiver/infrastructure"
+	"payment-receiver/usecase"
+)
+
+func runBackfillPayloads(db *sql.DB, args []string) error {
+	fs := flag.NewFlagSet("backfill-payloads", flag.ContinueOnError)
+	batch := fs.Int("batch", usecase.DefaultBackfillBatchSize, "rows read and rewritten per batch")
+	pause := fs.Duration("pause", 0, "sleep between batches to limit write load (e.g. 200ms)")
+	dryRun := fs.Bool("dry-run", false, "count convertible rows without writing")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	// Interrupting is safe: converted rows are committed per batch and a rerun
+	// only sees rows that still hold JSON.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	backfill := usecase.NewPayloadBackfill(infrastructure.NewPostgresOutbox(db), *batch, *pause)
+	report, err := backfill.Run(ctx, *dryRun)
+	for _, f := range report.Failed {
+		fmt.Printf("%s\t%v\n", f.ID, f.Err)
+	}
+
+	mode := "converted"
+	if *dryRun {
+		mode = "would convert"
+	}
+	fmt.Printf("scanned %d legacy payloads: %s=%d failed=%d\n", report.Scanned, mode, report.Converted, len(report.Failed))
+	if err != nil {
+		return err
+	}
+	if len(report.Failed) > 0 {
+		return fmt.Errorf("%d payloads could not be converted", len(report.Failed))
+	}
+	return nil
+}
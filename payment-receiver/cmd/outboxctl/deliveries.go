@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"payment-receiver/infrastructure"
+
+	"github.com/google/uuid"
+)
+
+func runDeliveries(db *sql.DB, args []string) error {
+	fs := flag.NewFlagSet("deliveries", flag.ContinueOnError)
+	eventID := fs.String("event", "", "outbox event ID to show attempts for")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	repo := infrastructure.NewPostgresDeliveryAttempts(db)
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	defer w.Flush()
+
+	if *eventID == "" {
+		statuses, err := repo.SubscriberStatuses(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, "SUBSCRIBER\tSUCCEEDED\tFAILED\tLAST ATTEMPT\tLAST STATUS\tLAST ERROR")
+		for _, s := range statuses {
+			fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%d\t%s\n",
+				s.Subscriber, s.Succeeded, s.Failed,
+				s.LastAttemptAt.Format(time.RFC3339), s.LastStatusCode, s.LastError)
+		}
+		return nil
+	}
+
+	id, err := uuid.Parse(*eventID)
+	if err != nil {
+		return fmt.Errorf("invalid event ID: %w", err)
+	}
+	attempts, err := repo.ListByEvent(ctx, id)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(w, "SUBSCRIBER\tATTEMPT\tAT\tSTATUS\tOK\tDURATION\tERROR")
+	for _, a := range attempts {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%d\t%t\t%s\t%s\n",
+			a.Subscriber, a.Attempt, a.AttemptedAt.Format(time.RFC3339),
+			a.StatusCode, a.Succeeded, a.Duration, a.Error)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+// Package main is the entrypoint of outboxctl, the operator CLI for the outbox.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+
+	"payment-receiver/infrastructure"
+	"payment-receiver/migrations"
+)
+
+const usage = `usage: outboxctl <command> [flags]
+
+commands:
+  deliveries               show HTTP delivery status per subscriber
+  deliveries -event <id>   show every delivery attempt for one outbox event
+  webhooks                 list raw incoming webhook requests (-since, -status, -event, -limit)
+  webhooks -id <id>        show one webhook request with its headers and raw body
+  webhooks -prune <age>    delete webhook requests older than age (e.g. 2160h)
+  replay                   re-ingest stored webhook requests through the current parser
+                           (-status 400, -since, -id, -limit, -dry-run; signatures are not re-checked)
+  backfill-payloads        re-encode payment payloads left as JSON by the bytea conversion
+                           into protobuf (-batch, -pause, -dry-run)
+  migrate up               apply pending schema migrations
+  migrate down [N]         revert the last N migrations (default 1)
+  migrate status           list embedded migrations and whether each is applied
+  migrate version          show the applied and latest embedded schema versions
+
+environment:
+  POSTGRES_DSN             Postgres connection string
+`
+
+// command runs a subcommand against the database with the remaining arguments.
+type command func(db *sql.DB, args []string) error
+
+var commands = map[string]command{
+	"deliveries":        runDeliveries,
+	"webhooks":          runWebhooks,
+	"replay":            runReplay,
+	"backfill-payloads": runBackfillPayloads,
+	"migrate": func(db *sql.DB, args []string) error {
+		return migrations.Command(context.Background(), db, args, os.Stdout)
+	},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	db, err := infrastructure.NewPostgres(os.Getenv("POSTGRES_DSN"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to connect to Postgres: %v\n", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	if err := cmd(db, os.Args[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"payment-receiver/domain"
+	"payment-receiver/handler"
+	"payment-receiver/infrastructure"
+	"payment-receiver/repository"
+	"payment-receiver/usecase"
+
+	"github.com/google/uuid"
+)
+
+func runReplay(db *sql.DB, args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	ids := fs.String("id", "", "comma-separated delivery IDs to replay (overrides the filters below)")
+	status := fs.Int("status", 400, "replay deliveries originally answered with this HTTP status (0 for any)")
+	since := fs.Duration("since", 0, "only deliveries received within this period (0 for all)")
+	limit := fs.Int("limit", 100, "maximum number of deliveries to replay")
+	dryRun := fs.Bool("dry-run", false, "report what would be created or deduplicated without writing")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	deliveriesRepo := infrastructure.NewPostgresWebhookDeliveries(db)
+
+	var deliveries []*domain.WebhookDelivery
+	if *ids != "" {
+		for _, raw := range strings.Split(*ids, ",") {
+			id, err := uuid.Parse(strings.TrimSpace(raw))
+			if err != nil {
+				return fmt.Errorf("invalid delivery ID %q: %w", raw, err)
+			}
+			d, err := deliveriesRepo.FindByID(ctx, id)
+			if err != nil {
+				return fmt.Errorf("delivery %s: %w", id, err)
+			}
+			deliveries = append(deliveries, d)
+		}
+	} else {
+		filter := repository.WebhookDeliveryFilter{StatusCode: *status, Limit: *limit}
+		if *since > 0 {
+			filter.Since = time.Now().Add(-*since)
+		}
+		var err error
+		if deliveries, err = deliveriesRepo.List(ctx, filter); err != nil {
+			return err
+		}
+		// Replay oldest first so later state wins, as it did originally.
+		for i, j := 0, len(deliveries)-1; i < j; i, j = i+1, j-1 {
+			deliveries[i], deliveries[j] = deliveries[j], deliveries[i]
+		}
+	}
+
+	outboxRepo := infrastructure.NewPostgresOutbox(db)
+	recorder := usecase.NewPaymentRecorder(
+		usecase.NewOutboxEnqueuer(outboxRepo),
+		infrastructure.NewPostgresPayments(db),
+		infrastructure.NewPostgresTxManager(db),
+	)
+	replayer := usecase.NewWebhookReplayer(parseWebhook, recorder, outboxRepo)
+	results := replayer.Replay(ctx, deliveries, *dryRun)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "DELIVERY\tRECEIVED\tWAS\tOUTCOME\tAGGREGATE\tDETAIL")
+	counts := map[usecase.ReplayOutcome]int{}
+	for _, r := range results {
+		counts[r.Outcome]++
+		detail := ""
+		switch {
+		case r.Err != nil:
+			detail = r.Err.Error()
+		case r.OutboxEventID != nil:
+			detail = "outbox event " + r.OutboxEventID.String()
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\n",
+			r.Delivery.ID, r.Delivery.ReceivedAt.Format(time.RFC3339), r.Delivery.StatusCode,
+			r.Outcome, r.AggregateID, detail)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	mode := "replayed"
+	if *dryRun {
+		mode = "dry run over"
+	}
+	fmt.Printf("\n%s %d deliveries:", mode, len(results))
+	for _, o := range []usecase.ReplayOutcome{
+		usecase.ReplayCreated, usecase.ReplayDuplicate,
+		usecase.ReplayWouldCreate, usecase.ReplayWouldDuplicate,
+		usecase.ReplayInvalid, usecase.ReplayFailed,
+	} {
+		if counts[o] > 0 {
+			fmt.Printf(" %s=%d", o, counts[o])
+		}
+	}
+	fmt.Println()
+
+	if counts[usecase.ReplayFailed] > 0 {
+		return fmt.Errorf("%d deliveries failed", counts[usecase.ReplayFailed])
+	}
+	return nil
+}
+
+// parseWebhook adapts the live endpoint's parser to usecase.WebhookParser.
+// Unknown fields are tolerated because per-provider strictness is not applied
+// to replays.
+func parseWebhook(body []byte) (*domain.Payment, *domain.OutboxEvent, error) {
+	sub, err := handler.ParseWebhookRequest(body, handler.DecodeOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	return sub.Payment, sub.Event, nil
+}
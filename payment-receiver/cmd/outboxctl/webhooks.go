@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"payment-receiver/domain"
+	"payment-receiver/infrastructure"
+	"payment-receiver/repository"
+	"payment-receiver/usecase"
+
+	"github.com/google/uuid"
+)
+
+func runWebhooks(db *sql.DB, args []string) error {
+	fs := flag.NewFlagSet("webhooks", flag.ContinueOnError)
+	id := fs.String("id", "", "show one delivery with its headers and raw body")
+	eventID := fs.String("event", "", "only deliveries that produced this outbox event")
+	since := fs.Duration("since", 24*time.Hour, "only deliveries received within this period (0 for all)")
+	status := fs.Int("status", 0, "only deliveries answered with this HTTP status")
+	limit := fs.Int("limit", 50, "maximum number of deliveries to list")
+	prune := fs.Duration("prune", 0, "delete deliveries older than this retention period instead of listing")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	repo := infrastructure.NewPostgresWebhookDeliveries(db)
+
+	if *prune > 0 {
+		n, err := usecase.NewWebhookDeliveryPruner(repo, *prune).Prune(ctx, time.Now())
+		if err != nil {
+			return err
+		}
+		fmt.Printf("deleted %d deliveries received before %s\n", n, time.Now().Add(-*prune).Format(time.RFC3339))
+		return nil
+	}
+
+	if *id != "" {
+		deliveryID, err := uuid.Parse(*id)
+		if err != nil {
+			return fmt.Errorf("invalid delivery ID: %w", err)
+		}
+		d, err := repo.FindByID(ctx, deliveryID)
+		if err != nil {
+			return err
+		}
+		printWebhookDelivery(d)
+		return nil
+	}
+
+	filter := repository.WebhookDeliveryFilter{StatusCode: *status, Limit: *limit}
+	if *since > 0 {
+		filter.Since = time.Now().Add(-*since)
+	}
+	if *eventID != "" {
+		parsed, err := uuid.Parse(*eventID)
+		if err != nil {
+			return fmt.Errorf("invalid event ID: %w", err)
+		}
+		filter.OutboxEventID = &parsed
+	}
+
+	deliveries, err := repo.List(ctx, filter)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	defer w.Flush()
+	fmt.Fprintln(w, "ID\tRECEIVED\tSOURCE IP\tSTATUS\tSIGNATURE\tBYTES\tOUTBOX EVENT")
+	for _, d := range deliveries {
+		outboxEvent := "-"
+		if d.OutboxEventID != nil {
+			outboxEvent = d.OutboxEventID.String()
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%d\t%s\n",
+			d.ID, d.ReceivedAt.Format(time.RFC3339), d.SourceIP, d.StatusCode,
+			d.SignatureStatus, len(d.Body), outboxEvent)
+	}
+	return nil
+}
+
+func printWebhookDelivery(d *domain.WebhookDelivery) {
+	fmt.Printf("ID:           %s\n", d.ID)
+	fmt.Printf("Received:     %s\n", d.ReceivedAt.Format(time.RFC3339Nano))
+	fmt.Printf("Request:      %s %s\n", d.Method, d.Path)
+	fmt.Printf("Source IP:    %s\n", d.SourceIP)
+	fmt.Printf("Status:       %d\n", d.StatusCode)
+	fmt.Printf("Signature:    %s\n", d.SignatureStatus)
+	if d.OutboxEventID != nil {
+		fmt.Printf("Outbox event: %s\n", d.OutboxEventID)
+	}
+
+	fmt.Println("\nHeaders:")
+	names := make([]string, 0, len(d.Headers))
+	for name := range d.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("  %s: %s\n", name, strings.Join(d.Headers[name], ", "))
+	}
+
+	fmt.Println("\nBody:")
+	fmt.Println(string(d.Body))
+}
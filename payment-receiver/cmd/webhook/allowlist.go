@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/netip"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"payment-receiver/handler"
+
+	"gopkg.in/yaml.v3"
+)
+
+// allowlistConfig is the YAML file referenced by WEBHOOK_IP_ALLOWLIST:
+//
+//	providers:
+//	  acme:
+//	    - 192.0.2.0/24
+//	    - 2001:db8::/32
+type allowlistConfig struct {
+	Providers map[string][]string `yaml:"providers"`
+}
+
+// loadAllowlist reads and validates the allowlist file.
+func loadAllowlist(path string) (map[string][]netip.Prefix, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read IP allowlist: %w", err)
+	}
+	var cfg allowlistConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse IP allowlist: %w", err)
+	}
+
+	ranges := make(map[string][]netip.Prefix, len(cfg.Providers))
+	for provider, entries := range cfg.Providers {
+		prefixes, err := handler.ParseIPRanges(entries)
+		if err != nil {
+			return nil, fmt.Errorf("provider %q: %w", provider, err)
+		}
+		ranges[provider] = prefixes
+	}
+	return ranges, nil
+}
+
+// reloadAllowlistOnSIGHUP reloads the allowlist file whenever the process
+// receives SIGHUP. A file that fails to load keeps the previous ranges.
+func reloadAllowlistOnSIGHUP(path string, allowlist *handler.IPAllowlist) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGHUP)
+	go func() {
+		for range sig {
+			ranges, err := loadAllowlist(path)
+			if err != nil {
+				log.Printf("IP allowlist not reloaded: %v", err)
+				continue
+			}
+			allowlist.Update(ranges)
+			log.Printf("IP allowlist reloaded: %d providers", len(ranges))
+		}
+	}()
+}
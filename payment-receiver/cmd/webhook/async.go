@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"payment-receiver/infrastructure"
+	"payment-receiver/usecase"
+)
+
+// newAsyncRecorder opens the WAL in dir and starts the background writer,
+// which first replays whatever a previous run left in the WAL.
+// WEBHOOK_ASYNC_BATCH_SIZE and WEBHOOK_ASYNC_WAL_MAX_BYTES tune it.
+func newAsyncRecorder(dir string, recorder usecase.PaymentBatchRecorder) (*usecase.AsyncPaymentRecorder, func(), error) {
+	batchSize := usecase.DefaultAsyncBatchSize
+	if v := os.Getenv("WEBHOOK_ASYNC_BATCH_SIZE"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return nil, nil, fmt.Errorf("invalid WEBHOOK_ASYNC_BATCH_SIZE %q", v)
+		}
+		batchSize = n
+	}
+	var maxBytes int64
+	if v := os.Getenv("WEBHOOK_ASYNC_WAL_MAX_BYTES"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n <= 0 {
+			return nil, nil, fmt.Errorf("invalid WEBHOOK_ASYNC_WAL_MAX_BYTES %q", v)
+		}
+		maxBytes = n
+	}
+
+	wal, err := infrastructure.OpenFileWAL(dir, maxBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	if n := wal.Pending(); n > 0 {
+		log.Printf("replaying %d events from WAL", n)
+	}
+
+	async := usecase.NewAsyncPaymentRecorder(wal, recorder, batchSize)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		async.Run(ctx, time.Second)
+	}()
+
+	return async, func() {
+		cancel()
+		<-done
+		if err := wal.Close(); err != nil {
+			log.Printf("failed to close WAL: %v", err)
+		}
+	}, nil
+}
@@ -2,11 +2,19 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"payment-receiver/handler"
 	"payment-receiver/infrastructure"
+	"payment-receiver/migrations"
 	"payment-receiver/usecase"
 
 	"github.com/gin-gonic/gin"
@@ -30,21 +38,220 @@ func main() {
 		}
 	}()
 
+	// "migrate up|down [N]|status|version" manages the schema and exits
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := migrations.Command(context.Background(), db, os.Args[2:], os.Stdout); err != nil {
+			log.Fatalf("migrate: %v", err)
+		}
+		return
+	}
+
+	// Refuse to run on an older schema; MIGRATE_ON_START=true applies pending migrations first
+	if err := migrations.EnsureCurrent(context.Background(), db, os.Getenv("MIGRATE_ON_START") == "true"); err != nil {
+		log.Fatalf("database schema: %v", err)
+	}
+
 	// Inject into usecase
 	outboxRepo := infrastructure.NewPostgresOutbox(db)
-	enqueuer := usecase.NewOutboxEnqueuer(outboxRepo)
+	txManager := infrastructure.NewPostgresTxManager(db)
+	recorder := usecase.NewPaymentRecorder(
+		usecase.NewOutboxEnqueuer(outboxRepo),
+		infrastructure.NewPostgresPayments(db),
+		txManager,
+	)
+
+	// POSTGRES_DRIVER=pgx records payments through a pgx pool; the read and
+	// admin APIs stay on database/sql
+	pool, err := infrastructure.NewPgxPoolFromEnv(context.Background(), dsn)
+	if err != nil {
+		log.Fatalf("failed to connect to DB: %v", err)
+	}
+	if pool != nil {
+		defer pool.Close()
+		recorder = usecase.NewPaymentRecorder(
+			usecase.NewOutboxEnqueuer(infrastructure.NewPgxOutbox(pool)),
+			infrastructure.NewPgxPayments(pool),
+			infrastructure.NewPgxTxManager(pool),
+		)
+	}
+
+	// Raw delivery audit log, pruned after WEBHOOK_DELIVERY_RETENTION (kept forever when unset)
+	deliveries := infrastructure.NewPostgresWebhookDeliveries(db)
+	if v := os.Getenv("WEBHOOK_DELIVERY_RETENTION"); v != "" {
+		retention, err := time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("invalid WEBHOOK_DELIVERY_RETENTION: %v", err)
+		}
+		go usecase.NewWebhookDeliveryPruner(deliveries, retention).Run(context.Background(), time.Hour)
+	}
+	redacted := slices.Concat(handler.DefaultRedactedHeaders, splitList(os.Getenv("WEBHOOK_AUDIT_REDACT_HEADERS")))
+
+	// With WEBHOOK_ASYNC_WAL_DIR set, webhooks are acknowledged with 202 once
+	// they are fsync'd to a local WAL, and written to Postgres in the background
+	webhookHandler := handler.WebhookHandler(recorder)
+	if dir := os.Getenv("WEBHOOK_ASYNC_WAL_DIR"); dir != "" {
+		asyncRecorder, closeWAL, err := newAsyncRecorder(dir, recorder)
+		if err != nil {
+			log.Fatalf("failed to initialize async mode: %v", err)
+		}
+		defer closeWAL()
+		webhookHandler = handler.AsyncWebhookHandler(asyncRecorder)
+	}
+
+	// Rate limits are checked before anything touches Postgres
+	limiter, closeLimiter, err := newRateLimiter()
+	if err != nil {
+		log.Fatalf("failed to initialize rate limiter: %v", err)
+	}
+	defer closeLimiter()
 
 	// Set up Gin router
 	router := gin.Default()
-	router.POST("/webhook", handler.WebhookHandler(enqueuer))
+	// X-Forwarded-For is only honored from TRUSTED_PROXIES (IPs or CIDRs)
+	if err := router.SetTrustedProxies(splitList(os.Getenv("TRUSTED_PROXIES"))); err != nil {
+		log.Fatalf("invalid TRUSTED_PROXIES: %v", err)
+	}
+
+	// Providers are identified by their mTLS client certificate when
+	// WEBHOOK_TLS_CLIENT_PROVIDERS maps it, otherwise by WEBHOOK_PROVIDER_HEADER
+	var webhook []gin.HandlerFunc
+	certProviders, err := parseCertProviders(os.Getenv("WEBHOOK_TLS_CLIENT_PROVIDERS"))
+	if err != nil {
+		log.Fatalf("invalid WEBHOOK_TLS_CLIENT_PROVIDERS: %v", err)
+	}
+	if len(certProviders) > 0 {
+		webhook = append(webhook, handler.ClientCertProvider(certProviders, providerHeader()))
+	}
+
+	// Provider source ranges from WEBHOOK_IP_ALLOWLIST, reloaded on SIGHUP
+	var allowlist *handler.IPAllowlist
+	if path := os.Getenv("WEBHOOK_IP_ALLOWLIST"); path != "" {
+		ranges, err := loadAllowlist(path)
+		if err != nil {
+			log.Fatalf("invalid WEBHOOK_IP_ALLOWLIST: %v", err)
+		}
+		allowlist = handler.NewIPAllowlist(ranges)
+		reloadAllowlistOnSIGHUP(path, allowlist)
+	}
+
+	// Only providers known to the allowlist or the certificate mapping get a
+	// provider bucket; anything else is limited by client IP
+	rules, err := rateLimitRules(knownProviders(certProviders, allowlist))
+	if err != nil {
+		log.Fatalf("invalid rate limit configuration: %v", err)
+	}
+	webhook = append(webhook, handler.RateLimit(limiter, rules...))
+	if allowlist != nil {
+		webhook = append(webhook, handler.SourceIPAllowlist(allowlist, handler.ByProvider(providerHeader())))
+	}
+
+	// Body limit (WEBHOOK_MAX_BODY_BYTES) and providers whose unknown fields are rejected
+	maxBody := int64(handler.DefaultMaxBodyBytes)
+	if v := os.Getenv("WEBHOOK_MAX_BODY_BYTES"); v != "" {
+		maxBody, err = strconv.ParseInt(v, 10, 64)
+		if err != nil || maxBody <= 0 {
+			log.Fatalf("invalid WEBHOOK_MAX_BODY_BYTES %q", v)
+		}
+	}
+	// Providers listed in WEBHOOK_SIGNING_SECRETS must sign their bodies
+	signingSecrets, err := parseNamedKeys(os.Getenv("WEBHOOK_SIGNING_SECRETS"))
+	if err != nil {
+		log.Fatalf("invalid WEBHOOK_SIGNING_SECRETS: %v", err)
+	}
+	webhook = append(webhook,
+		handler.MaxBodySize(maxBody),
+		handler.StrictFields(handler.ByProvider(providerHeader()), splitList(os.Getenv("WEBHOOK_STRICT_PROVIDERS"))),
+		handler.WebhookDeliveryAudit(deliveries, redacted),
+		handler.WebhookSignature(signingSecrets, handler.ByProvider(providerHeader()), 0),
+		webhookHandler,
+	)
+	router.POST("/webhook", webhook...)
+
+	// Read API, only served when API keys are configured
+	if apiKeys := splitList(os.Getenv("API_KEYS")); len(apiKeys) > 0 {
+		querier := usecase.NewPaymentQueryService(infrastructure.NewPostgresPayments(db), outboxRepo)
+		api := router.Group("/", handler.APIKeyAuth(apiKeys))
+		api.GET("/payments", handler.ListPaymentsHandler(querier))
+		api.GET("/payments/:id", handler.GetPaymentHandler(querier))
+		api.GET("/outbox/:id", handler.GetOutboxEventHandler(querier))
+	} else {
+		log.Println("API_KEYS is not set; query endpoints are disabled")
+	}
+
+	// Admin API, only served when named admin keys are configured
+	adminKeys, err := parseNamedKeys(os.Getenv("ADMIN_API_KEYS"))
+	if err != nil {
+		log.Fatalf("invalid ADMIN_API_KEYS: %v", err)
+	}
+	if len(adminKeys) > 0 {
+		admin := usecase.NewOutboxAdminService(
+			outboxRepo,
+			infrastructure.NewPostgresDispatcherControl(db),
+			infrastructure.NewPostgresAuditLog(db),
+			txManager,
+		)
+		adminAPI := router.Group("/admin", handler.NamedAPIKeyAuth(adminKeys))
+		adminAPI.GET("/outbox", handler.ListOutboxEventsHandler(admin))
+		adminAPI.POST("/outbox/:id/retry", handler.RetryOutboxEventHandler(admin))
+		adminAPI.POST("/outbox/requeue", handler.RequeueOutboxEventsHandler(admin))
+		adminAPI.GET("/dispatcher", handler.DispatcherStatusHandler(admin))
+		adminAPI.POST("/dispatcher/pause", handler.PauseDispatcherHandler(admin))
+		adminAPI.POST("/dispatcher/resume", handler.ResumeDispatcherHandler(admin))
+		if allowlist != nil {
+			adminAPI.GET("/webhook/rejections", handler.IPAllowlistRejectionsHandler(allowlist))
+		}
+	}
 
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
 
-	log.Printf("Starting webhook server on port %s...", port)
-	if err := router.Run(":" + port); err != nil {
+	// Native TLS (and mTLS) when WEBHOOK_TLS_CERT_FILE is set, plain HTTP otherwise
+	reloader, err := tlsFromEnv()
+	if err != nil {
+		log.Fatalf("invalid TLS configuration: %v", err)
+	}
+	server := &http.Server{
+		Addr:              ":" + port,
+		Handler:           router,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	if reloader != nil {
+		server.TLSConfig = reloader.TLSConfig()
+		go reloader.Watch(context.Background(), 30*time.Second)
+		log.Printf("Starting webhook server with TLS on port %s...", port)
+		err = server.ListenAndServeTLS("", "")
+	} else {
+		log.Printf("Starting webhook server on port %s...", port)
+		err = server.ListenAndServe()
+	}
+	if err != nil {
 		log.Fatalf("failed to start server: %v", err)
 	}
 }
+
+// splitList splits a comma-separated list, dropping empty entries.
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// parseNamedKeys parses "name:key" pairs separated by commas.
+func parseNamedKeys(s string) (map[string]string, error) {
+	keys := map[string]string{}
+	for _, pair := range splitList(s) {
+		name, key, ok := strings.Cut(pair, ":")
+		if !ok || name == "" || key == "" {
+			return nil, fmt.Errorf("entry %q is not name:key", pair)
+		}
+		keys[name] = key
+	}
+	return keys, nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"payment-receiver/handler"
+	"payment-receiver/infrastructure"
+	"payment-receiver/usecase"
+)
+
+// newRateLimiter builds the limiter selected by WEBHOOK_RATE_LIMIT_BACKEND:
+// "memory" (default, per replica) or "redis" (shared by all replicas).
+func newRateLimiter() (usecase.RateLimiter, func(), error) {
+	switch backend := os.Getenv("WEBHOOK_RATE_LIMIT_BACKEND"); backend {
+	case "", "memory":
+		return infrastructure.NewMemoryRateLimiter(), func() {}, nil
+	case "redis":
+		cfg, err := infrastructure.RedisClientConfigFromEnv()
+		if err != nil {
+			return nil, nil, err
+		}
+		limiter, err := infrastructure.NewRedisRateLimiter(cfg, "ratelimit:webhook:")
+		if err != nil {
+			return nil, nil, err
+		}
+		return limiter, limiter.Close, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown WEBHOOK_RATE_LIMIT_BACKEND %q", backend)
+	}
+}
+
+// rateLimitRules reads the per-IP and per-provider limits. A rule whose
+// *_RPS variable is unset is disabled. The IP limit is checked first; the
+// provider limit keys unknown providers by IP (see handler.ByKnownProvider).
+func rateLimitRules(known func(provider string) bool) ([]handler.RateLimitRule, error) {
+	ipLimit, err := rateLimitFromEnv("WEBHOOK_RATE_LIMIT_IP")
+	if err != nil {
+		return nil, err
+	}
+	providerLimit, err := rateLimitFromEnv("WEBHOOK_RATE_LIMIT_PROVIDER")
+	if err != nil {
+		return nil, err
+	}
+
+	return []handler.RateLimitRule{
+		{Name: "ip", Limit: ipLimit, Key: handler.ByClientIP},
+		{Name: "provider", Limit: providerLimit, Key: handler.ByKnownProvider(providerHeader(), known)},
+	}, nil
+}
+
+// knownProviders reports whether a provider is mapped from a client
+// certificate or listed in the allowlist, which may be nil.
+func knownProviders(certProviders map[string]string, allowlist *handler.IPAllowlist) func(string) bool {
+	mapped := make(map[string]struct{}, len(certProviders))
+	for _, provider := range certProviders {
+		mapped[provider] = struct{}{}
+	}
+	return func(provider string) bool {
+		if _, ok := mapped[provider]; ok {
+			return true
+		}
+		return allowlist != nil && allowlist.Known(provider)
+	}
+}
+
+// providerHeader names the request header that identifies the sending
+// provider (WEBHOOK_PROVIDER_HEADER, default X-Provider-Id).
+func providerHeader() string {
+	if h := os.Getenv("WEBHOOK_PROVIDER_HEADER"); h != "" {
+		return h
+	}
+	return "X-Provider-Id"
+}
+
+// rateLimitFromEnv reads <prefix>_RPS and <prefix>_BURST. The burst defaults
+// to one second's worth of requests.
+func rateLimitFromEnv(prefix string) (usecase.RateLimit, error) {
+	var limit usecase.RateLimit
+	v := os.Getenv(prefix + "_RPS")
+	if v == "" {
+		return limit, nil
+	}
+
+	var err error
+	if limit.Rate, err = strconv.ParseFloat(v, 64); err != nil || limit.Rate <= 0 {
+		return limit, fmt.Errorf("invalid %s_RPS %q", prefix, v)
+	}
+	limit.Burst = max(1, int(limit.Rate))
+	if v := os.Getenv(prefix + "_BURST"); v != "" {
+		if limit.Burst, err = strconv.Atoi(v); err != nil || limit.Burst < 1 {
+			return limit, fmt.Errorf("invalid %s_BURST %q", prefix, v)
+		}
+	}
+	return limit, nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"strings"
+
+	"payment-receiver/infrastructure"
+)
+
+// tlsFromEnv builds the TLS reloader from WEBHOOK_TLS_CERT_FILE and
+// WEBHOOK_TLS_KEY_FILE. It returns nil when TLS is not configured. With
+// WEBHOOK_TLS_CLIENT_CA_FILE set, client certificates are verified against
+// that bundle; WEBHOOK_TLS_CLIENT_AUTH chooses whether they are required
+// ("require", the default) or only verified when presented ("optional").
+func tlsFromEnv() (*infrastructure.TLSReloader, error) {
+	certFile := os.Getenv("WEBHOOK_TLS_CERT_FILE")
+	keyFile := os.Getenv("WEBHOOK_TLS_KEY_FILE")
+	if certFile == "" && keyFile == "" {
+		return nil, nil
+	}
+
+	clientAuth := tls.RequireAndVerifyClientCert
+	switch v := os.Getenv("WEBHOOK_TLS_CLIENT_AUTH"); v {
+	case "", "require":
+	case "optional":
+		clientAuth = tls.VerifyClientCertIfGiven
+	default:
+		return nil, fmt.Errorf("unknown WEBHOOK_TLS_CLIENT_AUTH %q", v)
+	}
+
+	return infrastructure.NewTLSReloader(certFile, keyFile, os.Getenv("WEBHOOK_TLS_CLIENT_CA_FILE"), clientAuth)
+}
+
+// parseCertProviders parses "provider=subject" pairs separated by commas
+// into a map from certificate subject (common name, DNS name or URI) to
+// provider. A provider may be listed with several subjects.
+func parseCertProviders(s string) (map[string]string, error) {
+	subjects := map[string]string{}
+	for _, pair := range splitList(s) {
+		provider, subject, ok := strings.Cut(pair, "=")
+		provider, subject = strings.TrimSpace(provider), strings.TrimSpace(subject)
+		if !ok || provider == "" || subject == "" {
+			return nil, fmt.Errorf("entry %q is not provider=subject", pair)
+		}
+		if other, dup := subjects[subject]; dup && other != provider {
+			return nil, fmt.Errorf("subject %q is mapped to both %q and %q", subject, other, provider)
+		}
+		subjects[subject] = provider
+	}
+	return subjects, nil
+}
@@ -0,0 +1,126 @@
+package codec
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	pb "payment-receiver/gen/proto"
+
+	"github.com/linkedin/goavro/v2"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// paymentEventSchema is the Avro schema of payment.PaymentEvent.
+const paymentEventSchema = `{
+	"type": "record",
+	"name": "PaymentEvent",
+	"namespace": "payment",
+	"fields": [
+		{"name": "id", "type": "string"},
+		{"name": "amount", "type": "int"},
+		{"name": "currency", "type": "string"},
+		{"name": "method", "type": "string"},
+		{"name": "status", "type": "string"},
+		{"name": "occurred_at", "type": {"type": "long", "logicalType": "timestamp-micros"}}
+	]
+}`
+
+// Avro encodes payment events in the Avro binary format.
+var Avro Codec = newAvroCodec()
+
+type avroCodec struct {
+	payment *goavro.Codec
+}
+
+func newAvroCodec() avroCodec {
+	c, err := goavro.NewCodec(paymentEventSchema)
+	if err != nil {
+		panic(fmt.Sprintf("invalid payment event avro schema: %v", err))
+	}
+	return avroCodec{payment: c}
+}
+
+func (avroCodec) Name() string        { return NameAvro }
+func (avroCodec) ContentType() string { return "application/avro" }
+
+func (c avroCodec) Marshal(m proto.Message) ([]byte, error) {
+	event, ok := m.(*pb.PaymentEvent)
+	if !ok {
+		return nil, fmt.Errorf("avro codec does not support %T", m)
+	}
+	if event.OccurredAt == nil {
+		return nil, errors.New("avro codec requires occurred_at")
+	}
+
+	return c.payment.BinaryFromNative(nil, map[string]interface{}{
+		"id":          event.Id,
+		"amount":      event.Amount,
+		"currency":    event.Currency,
+		"method":      event.Method,
+		"status":      event.Status,
+		"occurred_at": event.OccurredAt.AsTime(),
+	})
+}
+
+func (c avroCodec) Unmarshal(data []byte, m proto.Message) error {
+	event, ok := m.(*pb.PaymentEvent)
+	if !ok {
+		return fmt.Errorf("avro codec does not support %T", m)
+	}
+
+	native, _, err := c.payment.NativeFromBinary(data)
+	if err != nil {
+		return err
+	}
+	record, ok := native.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("unexpected avro value %T", native)
+	}
+
+	id, err := avroField[string](record, "id")
+	if err != nil {
+		return err
+	}
+	amount, err := avroField[int32](record, "amount")
+	if err != nil {
+		return err
+	}
+	currency, err := avroField[string](record, "currency")
+	if err != nil {
+		return err
+	}
+	method, err := avroField[string](record, "method")
+	if err != nil {
+		return err
+	}
+	status, err := avroField[string](record, "status")
+	if err != nil {
+		return err
+	}
+	occurredAt, err := avroField[time.Time](record, "occurred_at")
+	if err != nil {
+		return err
+	}
+	occurredAt = occurredAt.UTC()
+
+	event.Reset()
+	event.Id = id
+	event.Amount = amount
+	event.Currency = currency
+	event.Method = method
+	event.Status = status
+	event.OccurredAt = timestamppb.New(occurredAt)
+	event.OccurredAtRfc3339 = occurredAt.Format(time.RFC3339Nano)
+	return nil
+}
+
+// avroField returns the named field of a decoded record as a T.
+func avroField[T any](record map[string]interface{}, name string) (T, error) {
+	v, ok := record[name].(T)
+	if !ok {
+		return v, fmt.Errorf("unexpected avro value %T for field %q", record[name], name)
+	}
+	return v, nil
+}
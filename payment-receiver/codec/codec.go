@@ -0,0 +1,46 @@
+// Package codec provides the serialization formats used for outbox payloads.
+package codec
+
+import (
+	"errors"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Codec names stored in outbox_events.codec.
+const (
+	NameProtobuf = "protobuf"
+	NameJSON     = "json"
+	NameAvro     = "avro"
+)
+
+// ErrUnknownCodec is returned when a codec name is not registered.
+var ErrUnknownCodec = errors.New("unknown codec")
+
+// Codec encodes and decodes outbox payloads.
+type Codec interface {
+	Name() string
+	ContentType() string
+	Marshal(m proto.Message) ([]byte, error)
+	Unmarshal(data []byte, m proto.Message) error
+}
+
+var codecs = map[string]Codec{
+	NameProtobuf: Protobuf,
+	NameJSON:     JSON,
+	NameAvro:     Avro,
+}
+
+// Lookup returns the codec registered under name.
+// An empty name resolves to Protobuf, the format of rows written before codecs existed.
+func Lookup(name string) (Codec, error) {
+	if name == "" {
+		return Protobuf, nil
+	}
+	c, ok := codecs[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownCodec, name)
+	}
+	return c, nil
+}
@@ -0,0 +1,76 @@
+package codec_test
+
+import (
+	"testing"
+	"time"
+
+	"payment-receiver/codec"
+	pb "payment-receiver/gen/proto"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func newPaymentEvent() *pb.PaymentEvent {
+	occurredAt := time.Date(2024, 4, 1, 12, 0, 0, 123_456_000, time.UTC)
+	return &pb.PaymentEvent{
+		Id:                "evt_001",
+		Amount:            1200,
+		Currency:          "USD",
+		Method:            "card",
+		Status:            "paid",
+		OccurredAtRfc3339: occurredAt.Format(time.RFC3339Nano),
+		OccurredAt:        timestamppb.New(occurredAt),
+	}
+}
+
+func TestCodecs_RoundTrip(t *testing.T) {
+	for _, name := range []string{codec.NameProtobuf, codec.NameJSON, codec.NameAvro} {
+		t.Run(name, func(t *testing.T) {
+			c, err := codec.Lookup(name)
+			require.NoError(t, err)
+			assert.Equal(t, name, c.Name())
+
+			want := newPaymentEvent()
+			data, err := c.Marshal(want)
+			require.NoError(t, err)
+
+			var got pb.PaymentEvent
+			require.NoError(t, c.Unmarshal(data, &got))
+			assert.True(t, proto.Equal(want, &got), "got %v, want %v", &got, want)
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	t.Run("empty name defaults to protobuf", func(t *testing.T) {
+		c, err := codec.Lookup("")
+		require.NoError(t, err)
+		assert.Equal(t, codec.Protobuf, c)
+	})
+
+	t.Run("unknown name returns error", func(t *testing.T) {
+		c, err := codec.Lookup("xml")
+		assert.ErrorIs(t, err, codec.ErrUnknownCodec)
+		assert.Nil(t, c)
+	})
+}
+
+func TestAvro_RejectsUnsupportedMessage(t *testing.T) {
+	_, err := codec.Avro.Marshal(wrapperspb.String("x"))
+	assert.Error(t, err)
+
+	err = codec.Avro.Unmarshal(nil, &wrapperspb.StringValue{})
+	assert.Error(t, err)
+}
+
+func TestAvro_RejectsMissingOccurredAt(t *testing.T) {
+	event := newPaymentEvent()
+	event.OccurredAt = nil
+
+	_, err := codec.Avro.Marshal(event)
+	assert.Error(t, err)
+}
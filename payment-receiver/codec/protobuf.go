@@ -0,0 +1,38 @@
+package codec
+
+import (
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Protobuf encodes payloads in the protobuf binary wire format.
+var Protobuf Codec = protobufCodec{}
+
+// JSON encodes payloads with the canonical protobuf JSON mapping.
+var JSON Codec = jsonCodec{}
+
+type protobufCodec struct{}
+
+func (protobufCodec) Name() string        { return NameProtobuf }
+func (protobufCodec) ContentType() string { return "application/protobuf" }
+
+func (protobufCodec) Marshal(m proto.Message) ([]byte, error) {
+	return proto.Marshal(m)
+}
+
+func (protobufCodec) Unmarshal(data []byte, m proto.Message) error {
+	return proto.Unmarshal(data, m)
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string        { return NameJSON }
+func (jsonCodec) ContentType() string { return "application/json" }
+
+func (jsonCodec) Marshal(m proto.Message) ([]byte, error) {
+	return protojson.Marshal(m)
+}
+
+func (jsonCodec) Unmarshal(data []byte, m proto.Message) error {
+	return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, m)
+}
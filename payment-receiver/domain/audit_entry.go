@@ -0,0 +1,30 @@
+// Package domain handles core business entities and logic.
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditEntry records an operator action taken through the admin API.
+type AuditEntry struct {
+	ID        uuid.UUID
+	Actor     string
+	Action    string
+	Target    string
+	Details   map[string]any
+	CreatedAt time.Time
+}
+
+// NewAuditEntry returns an entry for action on target performed by actor.
+func NewAuditEntry(actor, action, target string, details map[string]any) *AuditEntry {
+	return &AuditEntry{
+		ID:        uuid.New(),
+		Actor:     actor,
+		Action:    action,
+		Target:    target,
+		Details:   details,
+		CreatedAt: time.Now().UTC(),
+	}
+}
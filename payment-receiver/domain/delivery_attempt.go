@@ -0,0 +1,31 @@
+// Package domain handles core business entities and logic.
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DeliveryAttempt records one attempt to deliver an outbox event to an HTTP subscriber.
+type DeliveryAttempt struct {
+	ID            uuid.UUID
+	OutboxEventID uuid.UUID
+	Subscriber    string
+	Attempt       int
+	StatusCode    int
+	Error         string
+	Succeeded     bool
+	AttemptedAt   time.Time
+	Duration      time.Duration
+}
+
+// SubscriberStatus summarizes delivery attempts made to one subscriber.
+type SubscriberStatus struct {
+	Subscriber     string
+	Succeeded      int
+	Failed         int
+	LastAttemptAt  time.Time
+	LastStatusCode int
+	LastError      string
+}
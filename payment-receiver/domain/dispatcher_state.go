@@ -0,0 +1,28 @@
+// Package domain handles core business entities and logic.
+package domain
+
+import "time"
+
+// DispatcherState is the operator-controlled switch shared by all dispatchers.
+type DispatcherState struct {
+	Paused    bool
+	Reason    string
+	UpdatedBy string
+	UpdatedAt time.Time
+}
+
+// OutboxStats summarizes the outbox backlog.
+type OutboxStats struct {
+	Pending         int64
+	Failed          int64
+	OldestPendingAt *time.Time
+	LastSentAt      *time.Time
+}
+
+// Lag returns how long the oldest pending event has been waiting at now.
+func (s *OutboxStats) Lag(now time.Time) time.Duration {
+	if s.OldestPendingAt == nil {
+		return 0
+	}
+	return now.Sub(*s.OldestPendingAt)
+}
@@ -0,0 +1,117 @@
+// Package domain handles core business entities and logic.
+package domain
+
+import (
+	"errors"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	pr "payment-receiver/gen/proto"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ErrInvalidOccurredAt is returned when an occurred_at value cannot be parsed.
+var ErrInvalidOccurredAt = errors.New("invalid occurred_at format")
+
+// exponentEpoch matches epoch seconds in exponent form such as 1.7e9, which
+// JSON encoders emit for large floats. The two-digit exponent bounds the
+// size of the decimal expansion.
+var exponentEpoch = regexp.MustCompile(`^-?\d+(\.\d+)?[eE][+-]?\d{1,2}$`)
+
+// ParseOccurredAt parses an occurred_at value received at the HTTP edge.
+// It accepts RFC3339 timestamps (with or without fractional seconds) and
+// Unix epoch seconds (optionally fractional or in exponent form), and
+// always returns UTC.
+func ParseOccurredAt(input string) (time.Time, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return time.Time{}, ErrInvalidOccurredAt
+	}
+
+	if exponentEpoch.MatchString(input) {
+		r, ok := new(big.Rat).SetString(input)
+		if !ok {
+			return time.Time{}, ErrInvalidOccurredAt
+		}
+		input = r.FloatString(9)
+	}
+	if isEpoch(input) {
+		return parseEpoch(input)
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, input)
+	if err != nil {
+		return time.Time{}, ErrInvalidOccurredAt
+	}
+	return t.UTC(), nil
+}
+
+// OccurredAtFromProto reads occurred_at from a proto.PaymentEvent.
+// The Timestamp field wins; events written before it existed fall back to
+// the legacy RFC3339 string field.
+func OccurredAtFromProto(event *pr.PaymentEvent) (time.Time, error) {
+	if event.OccurredAt != nil {
+		if err := event.OccurredAt.CheckValid(); err != nil {
+			return time.Time{}, ErrInvalidOccurredAt
+		}
+		return event.OccurredAt.AsTime().UTC(), nil
+	}
+	return ParseOccurredAt(event.OccurredAtRfc3339)
+}
+
+// SetProtoOccurredAt writes t into both occurred_at fields in UTC so that
+// old and new readers agree on the value.
+func SetProtoOccurredAt(event *pr.PaymentEvent, t time.Time) {
+	t = t.UTC()
+	event.OccurredAt = timestamppb.New(t)
+	event.OccurredAtRfc3339 = t.Format(time.RFC3339Nano)
+}
+
+func isEpoch(input string) bool {
+	digits := 0
+	for i, r := range input {
+		switch {
+		case r >= '0' && r <= '9':
+			digits++
+		case r == '.' && i > 0:
+		case r == '-' && i == 0:
+		default:
+			return false
+		}
+	}
+	return digits > 0 && strings.Count(input, ".") <= 1
+}
+
+func parseEpoch(input string) (time.Time, error) {
+	secPart, fracPart, _ := strings.Cut(input, ".")
+
+	sec, err := strconv.ParseInt(secPart, 10, 64)
+	if err != nil {
+		return time.Time{}, ErrInvalidOccurredAt
+	}
+
+	var nsec int64
+	if fracPart != "" {
+		if len(fracPart) > 9 {
+			fracPart = fracPart[:9]
+		}
+		fracPart += strings.Repeat("0", 9-len(fracPart))
+		nsec, err = strconv.ParseInt(fracPart, 10, 64)
+		if err != nil {
+			return time.Time{}, ErrInvalidOccurredAt
+		}
+		if sec < 0 || strings.HasPrefix(secPart, "-") {
+			nsec = -nsec
+		}
+	}
+
+	t := time.Unix(sec, nsec).UTC()
+	if err := timestamppb.New(t).CheckValid(); err != nil {
+		return time.Time{}, ErrInvalidOccurredAt
+	}
+	return t, nil
+}
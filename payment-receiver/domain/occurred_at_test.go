@@ -0,0 +1,60 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"payment-receiver/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseOccurredAt(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  time.Time
+	}{
+		{"RFC3339", "2024-04-01T12:00:00Z", time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)},
+		{
+			"RFC3339 with fractional seconds",
+			"2024-04-01T12:00:00.123456Z",
+			time.Date(2024, 4, 1, 12, 0, 0, 123_456_000, time.UTC),
+		},
+		{
+			"RFC3339 with offset is normalized to UTC",
+			"2024-04-01T21:00:00+09:00",
+			time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC),
+		},
+		{"epoch seconds", "1711972800", time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)},
+		{
+			"fractional epoch seconds",
+			"1711972800.25",
+			time.Date(2024, 4, 1, 12, 0, 0, 250_000_000, time.UTC),
+		},
+		{"exponent epoch seconds", "1.7e9", time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC)},
+		{
+			"exponent epoch seconds with fraction",
+			"1.71197280025E+9",
+			time.Date(2024, 4, 1, 12, 0, 0, 250_000_000, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := domain.ParseOccurredAt(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, time.UTC, got.Location())
+		})
+	}
+}
+
+func TestParseOccurredAt_Invalid(t *testing.T) {
+	for _, input := range []string{"", "not-a-date", "2024-04-01", "1.2.3", "99999999999999999999", "1e999", "1.7e99", "e9"} {
+		t.Run(input, func(t *testing.T) {
+			_, err := domain.ParseOccurredAt(input)
+			assert.ErrorIs(t, err, domain.ErrInvalidOccurredAt)
+		})
+	}
+}
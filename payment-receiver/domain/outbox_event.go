@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"time"
 
+	"payment-receiver/codec"
 	pr "payment-receiver/gen/proto"
 
 	"github.com/google/uuid"
@@ -29,25 +30,33 @@ type OutboxEvent struct {
 	AggregateID string
 	EventType   string
 	Payload     json.RawMessage
+	Codec       string
 	Status      OutboxStatus
 	CreatedAt   time.Time
 	SentAt      *time.Time
 	EventAt     time.Time
+	// Attempts counts failed deliveries since the event was last queued.
+	Attempts int
 }
 
 // NewOutboxEvent constructs a new OutboxEvent with validation.
+// The payload is serialized with c, whose name is recorded on the event.
 func NewOutboxEvent(
 	aggregateID, eventType string,
 	eventAt time.Time,
-	payload interface{},
+	payload proto.Message,
+	c codec.Codec,
 ) (*OutboxEvent, error) {
 	if aggregateID == "" || eventType == "" {
 		return nil, errors.New("aggregateID and eventType are required")
 	}
+	if payload == nil || c == nil {
+		return nil, errors.New("payload and codec are required")
+	}
 
-	data, err := json.Marshal(payload)
+	data, err := c.Marshal(payload)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to marshal payload with %s codec: %w", c.Name(), err)
 	}
 
 	return &OutboxEvent{
@@ -55,6 +64,7 @@ func NewOutboxEvent(
 		AggregateID: aggregateID,
 		EventType:   eventType,
 		Payload:     data,
+		Codec:       c.Name(),
 		Status:      StatusPending,
 		EventAt:     eventAt,
 		CreatedAt:   time.Now(),
@@ -71,9 +81,14 @@ func NewOutboxEventFromProtoPayment(event *pr.PaymentEvent) (*OutboxEvent, error
 		return nil, err
 	}
 
-	t, _ := parseISO8601Strict(event.OccurredAt)
+	t, err := OccurredAtFromProto(event)
+	if err != nil {
+		return nil, fmt.Errorf("invalid occurred_at format: %w", err)
+	}
+	normalized := proto.Clone(event).(*pr.PaymentEvent)
+	SetProtoOccurredAt(normalized, t)
 
-	payload, err := proto.Marshal(event)
+	payload, err := codec.Protobuf.Marshal(normalized)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal protobuf: %w", err)
 	}
@@ -83,6 +98,7 @@ func NewOutboxEventFromProtoPayment(event *pr.PaymentEvent) (*OutboxEvent, error
 		AggregateID: event.Id,
 		EventType:   "payment_event",
 		Payload:     payload,
+		Codec:       codec.NameProtobuf,
 		Status:      StatusPending,
 		EventAt:     t,
 		CreatedAt:   time.Now(),
@@ -108,12 +124,8 @@ func validateProtoPaymentEvent(event *pr.PaymentEvent) error {
 	default:
 		return errors.New("invalid status")
 	}
-	if _, err := parseISO8601Strict(event.OccurredAt); err != nil {
+	if _, err := OccurredAtFromProto(event); err != nil {
 		return fmt.Errorf("invalid occurred_at format: %w", err)
 	}
 	return nil
 }
-
-func parseISO8601Strict(input string) (time.Time, error) {
-	return time.Parse(time.RFC3339, input)
-}
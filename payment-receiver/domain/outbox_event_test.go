@@ -4,10 +4,13 @@ import (
 	"testing"
 	"time"
 
+	"payment-receiver/codec"
 	"payment-receiver/domain"
 	pr "payment-receiver/gen/proto"
 
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func TestNewOutboxEventFromProtoPayment(t *testing.T) {
@@ -17,7 +20,7 @@ func TestNewOutboxEventFromProtoPayment(t *testing.T) {
 		Currency:   "USD",
 		Method:     "card",
 		Status:     "paid",
-		OccurredAt: time.Now().Format(time.RFC3339),
+		OccurredAt: timestamppb.Now(),
 	}
 
 	t.Run("valid proto payment event", func(t *testing.T) {
@@ -25,6 +28,7 @@ func TestNewOutboxEventFromProtoPayment(t *testing.T) {
 		assert.NoError(t, err)
 		assert.NotNil(t, ev)
 		assert.Equal(t, "evt_001", ev.AggregateID)
+		assert.Equal(t, codec.NameProtobuf, ev.Codec)
 	})
 
 	t.Run("nil proto event returns error", func(t *testing.T) {
@@ -75,21 +79,67 @@ func TestNewOutboxEventFromProtoPayment(t *testing.T) {
 
 	t.Run("invalid occurred_at format returns error", func(t *testing.T) {
 		event := cloneEvent(validEvent)
-		event.OccurredAt = "not-a-date"
+		event.OccurredAt = nil
+		event.OccurredAtRfc3339 = "not-a-date"
 		ev, err := domain.NewOutboxEventFromProtoPayment(event)
 		assert.ErrorContains(t, err, "invalid occurred_at format")
 		assert.Nil(t, ev)
 	})
+
+	t.Run("legacy RFC3339 string is accepted and normalized", func(t *testing.T) {
+		event := cloneEvent(validEvent)
+		event.OccurredAt = nil
+		event.OccurredAtRfc3339 = "2024-04-01T21:00:00.5+09:00"
+		ev, err := domain.NewOutboxEventFromProtoPayment(event)
+		assert.NoError(t, err)
+
+		want := time.Date(2024, 4, 1, 12, 0, 0, 500_000_000, time.UTC)
+		assert.Equal(t, want, ev.EventAt)
+
+		var stored pr.PaymentEvent
+		assert.NoError(t, proto.Unmarshal(ev.Payload, &stored))
+		assert.Equal(t, want, stored.OccurredAt.AsTime())
+		assert.Equal(t, "2024-04-01T12:00:00.5Z", stored.OccurredAtRfc3339)
+	})
+
+	t.Run("timestamp takes precedence over legacy string", func(t *testing.T) {
+		event := cloneEvent(validEvent)
+		event.OccurredAtRfc3339 = "2000-01-01T00:00:00Z"
+		ev, err := domain.NewOutboxEventFromProtoPayment(event)
+		assert.NoError(t, err)
+		assert.Equal(t, validEvent.OccurredAt.AsTime(), ev.EventAt)
+	})
 }
 
 // cloneEvent creates a deep copy of a proto.PaymentEvent.
 func cloneEvent(e *pr.PaymentEvent) *pr.PaymentEvent {
 	return &pr.PaymentEvent{
-		Id:         e.Id,
-		Amount:     e.Amount,
-		Currency:   e.Currency,
-		Method:     e.Method,
-		Status:     e.Status,
-		OccurredAt: e.OccurredAt,
+		Id:                e.Id,
+		Amount:            e.Amount,
+		Currency:          e.Currency,
+		Method:            e.Method,
+		Status:            e.Status,
+		OccurredAt:        e.OccurredAt,
+		OccurredAtRfc3339: e.OccurredAtRfc3339,
 	}
 }
+
+func TestNewOutboxEvent(t *testing.T) {
+	payment := &pr.PaymentEvent{Id: "evt_001", Amount: 1000, Currency: "USD"}
+
+	t.Run("payload is marshaled with the given codec", func(t *testing.T) {
+		ev, err := domain.NewOutboxEvent("evt_001", "payment_event", time.Now(), payment, codec.JSON)
+		assert.NoError(t, err)
+		assert.Equal(t, codec.NameJSON, ev.Codec)
+
+		var got pr.PaymentEvent
+		assert.NoError(t, codec.JSON.Unmarshal(ev.Payload, &got))
+		assert.Equal(t, "evt_001", got.Id)
+	})
+
+	t.Run("missing codec returns error", func(t *testing.T) {
+		ev, err := domain.NewOutboxEvent("evt_001", "payment_event", time.Now(), payment, nil)
+		assert.Error(t, err)
+		assert.Nil(t, ev)
+	})
+}
@@ -0,0 +1,28 @@
+// Package domain handles core business entities and logic.
+package domain
+
+import "time"
+
+// Payment is the current state of a payment as last reported by the provider.
+type Payment struct {
+	ID         string
+	Amount     int
+	Currency   string
+	Method     string
+	Status     string
+	OccurredAt time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// NewPaymentFromEvent returns the payment state described by a PaymentEvent.
+func NewPaymentFromEvent(event *PaymentEvent) *Payment {
+	return &Payment{
+		ID:         event.ID,
+		Amount:     event.Amount,
+		Currency:   event.Currency,
+		Method:     event.Method,
+		Status:     event.Status,
+		OccurredAt: event.OccurredAt.UTC(),
+	}
+}
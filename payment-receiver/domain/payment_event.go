@@ -51,9 +51,9 @@ func NewPaymentEvent(
 		return nil, errors.New("invalid status")
 	}
 
-	ts, err := time.Parse(time.RFC3339, occurredAt)
+	ts, err := ParseOccurredAt(occurredAt)
 	if err != nil {
-		return nil, errors.New("invalid occurred_at format")
+		return nil, err
 	}
 
 	return &PaymentEvent{
@@ -0,0 +1,31 @@
+// Package domain handles core business entities and logic.
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Signature verification outcomes recorded for a webhook delivery.
+const (
+	SignatureNotConfigured = "not_configured"
+	SignatureValid         = "valid"
+	SignatureInvalid       = "invalid"
+	SignatureMissing       = "missing"
+)
+
+// WebhookDelivery is the raw record of one incoming webhook request, kept so
+// that what a provider actually sent can be shown later.
+type WebhookDelivery struct {
+	ID              uuid.UUID
+	ReceivedAt      time.Time
+	Method          string
+	Path            string
+	SourceIP        string
+	Headers         map[string][]string
+	Body            []byte
+	SignatureStatus string
+	StatusCode      int
+	OutboxEventID   *uuid.UUID
+}
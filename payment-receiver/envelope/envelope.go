@@ -0,0 +1,147 @@
+// Package envelope wraps outbox events in CloudEvents attributes for transport.
+package envelope
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"payment-receiver/codec"
+	"payment-receiver/domain"
+)
+
+// SpecVersion is the CloudEvents specification version this package writes.
+const SpecVersion = "1.0"
+
+// DefaultSource is the CloudEvents source used when none is configured.
+const DefaultSource = "/payment-receiver"
+
+// Stream field names. Attributes carry the "ce_" prefix used by the CloudEvents
+// binary content mode; the payload stays in "data" so existing consumers keep working.
+const (
+	FieldSpecVersion     = "ce_specversion"
+	FieldID              = "ce_id"
+	FieldType            = "ce_type"
+	FieldSource          = "ce_source"
+	FieldTime            = "ce_time"
+	FieldDataContentType = "ce_datacontenttype"
+	FieldDataSchema      = "ce_dataschema"
+	FieldData            = "data"
+)
+
+// ErrInvalidEnvelope is returned when decoded fields do not form a valid envelope.
+var ErrInvalidEnvelope = errors.New("invalid event envelope")
+
+// Envelope is an outbox event described by CloudEvents attributes.
+type Envelope struct {
+	SpecVersion     string
+	ID              string
+	Type            string
+	Source          string
+	Time            time.Time
+	DataContentType string
+	DataSchema      string
+	Data            []byte
+}
+
+// FromOutboxEvent builds an envelope for an outbox event. The payload is carried
+// as stored and described by the content type of the codec it was written with.
+func FromOutboxEvent(event *domain.OutboxEvent, source string) (*Envelope, error) {
+	c, err := codec.Lookup(event.Codec)
+	if err != nil {
+		return nil, err
+	}
+	if source == "" {
+		source = DefaultSource
+	}
+
+	return &Envelope{
+		SpecVersion:     SpecVersion,
+		ID:              event.ID.String(),
+		Type:            event.EventType,
+		Source:          source,
+		Time:            event.EventAt.UTC(),
+		DataContentType: c.ContentType(),
+		Data:            []byte(event.Payload),
+	}, nil
+}
+
+// Attributes returns the CloudEvents attributes keyed by their prefixed field names.
+// Optional attributes are omitted when empty.
+func (e *Envelope) Attributes() map[string]string {
+	attrs := map[string]string{
+		FieldSpecVersion:     e.SpecVersion,
+		FieldID:              e.ID,
+		FieldType:            e.Type,
+		FieldSource:          e.Source,
+		FieldDataContentType: e.DataContentType,
+	}
+	if !e.Time.IsZero() {
+		attrs[FieldTime] = e.Time.UTC().Format(time.RFC3339Nano)
+	}
+	if e.DataSchema != "" {
+		attrs[FieldDataSchema] = e.DataSchema
+	}
+	return attrs
+}
+
+// Encode returns the envelope as Redis stream fields.
+func (e *Envelope) Encode() map[string]interface{} {
+	values := map[string]interface{}{FieldData: e.Data}
+	for k, v := range e.Attributes() {
+		values[k] = v
+	}
+	return values
+}
+
+// Decode parses Redis stream fields back into an envelope.
+func Decode(values map[string]interface{}) (*Envelope, error) {
+	e := &Envelope{
+		SpecVersion:     stringField(values, FieldSpecVersion),
+		ID:              stringField(values, FieldID),
+		Type:            stringField(values, FieldType),
+		Source:          stringField(values, FieldSource),
+		DataContentType: stringField(values, FieldDataContentType),
+		DataSchema:      stringField(values, FieldDataSchema),
+		Data:            bytesField(values, FieldData),
+	}
+
+	if e.SpecVersion != SpecVersion {
+		return nil, fmt.Errorf("%w: unsupported specversion %q", ErrInvalidEnvelope, e.SpecVersion)
+	}
+	if e.ID == "" || e.Type == "" || e.Source == "" {
+		return nil, fmt.Errorf("%w: id, type and source are required", ErrInvalidEnvelope)
+	}
+
+	if raw := stringField(values, FieldTime); raw != "" {
+		t, err := time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid time: %v", ErrInvalidEnvelope, err)
+		}
+		e.Time = t.UTC()
+	}
+
+	return e, nil
+}
+
+func stringField(values map[string]interface{}, key string) string {
+	switch v := values[key].(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	default:
+		return ""
+	}
+}
+
+func bytesField(values map[string]interface{}, key string) []byte {
+	switch v := values[key].(type) {
+	case []byte:
+		return v
+	case string:
+		return []byte(v)
+	default:
+		return nil
+	}
+}
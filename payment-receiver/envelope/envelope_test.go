@@ -0,0 +1,135 @@
+package envelope_test
+
+import (
+	"testing"
+	"time"
+
+	"payment-receiver/codec"
+	"payment-receiver/domain"
+	"payment-receiver/envelope"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newOutboxEvent() *domain.OutboxEvent {
+	return &domain.OutboxEvent{
+		ID:          uuid.New(),
+		AggregateID: "evt_001",
+		EventType:   "payment_event",
+		Payload:     []byte{0x0a, 0x07, 'e', 'v', 't', '_', '0', '0', '1'},
+		Codec:       codec.NameProtobuf,
+		Status:      domain.StatusPending,
+		EventAt:     time.Date(2024, 4, 1, 21, 0, 0, 500, time.FixedZone("JST", 9*60*60)),
+		CreatedAt:   time.Now(),
+	}
+}
+
+func mustFromOutboxEvent(t *testing.T, ev *domain.OutboxEvent, source string) *envelope.Envelope {
+	t.Helper()
+	env, err := envelope.FromOutboxEvent(ev, source)
+	require.NoError(t, err)
+	return env
+}
+
+func TestFromOutboxEvent(t *testing.T) {
+	ev := newOutboxEvent()
+
+	env := mustFromOutboxEvent(t, ev, "")
+
+	assert.Equal(t, envelope.SpecVersion, env.SpecVersion)
+	assert.Equal(t, ev.ID.String(), env.ID)
+	assert.Equal(t, "payment_event", env.Type)
+	assert.Equal(t, envelope.DefaultSource, env.Source)
+	assert.Equal(t, ev.EventAt.UTC(), env.Time)
+	assert.Equal(t, "application/protobuf", env.DataContentType)
+	assert.Empty(t, env.DataSchema)
+	assert.Equal(t, []byte(ev.Payload), env.Data)
+}
+
+func TestFromOutboxEvent_ContentTypeFollowsCodec(t *testing.T) {
+	ev := newOutboxEvent()
+	ev.Codec = codec.NameJSON
+	ev.Payload = []byte(`{"id":"evt_001"}`)
+
+	env := mustFromOutboxEvent(t, ev, "")
+
+	assert.Equal(t, "application/json", env.DataContentType)
+	assert.Equal(t, []byte(ev.Payload), env.Data)
+}
+
+func TestFromOutboxEvent_UnknownCodec(t *testing.T) {
+	ev := newOutboxEvent()
+	ev.Codec = "xml"
+
+	env, err := envelope.FromOutboxEvent(ev, "")
+	assert.ErrorIs(t, err, codec.ErrUnknownCodec)
+	assert.Nil(t, env)
+}
+
+func TestEncodeDecode_RoundTrip(t *testing.T) {
+	env := mustFromOutboxEvent(t, newOutboxEvent(), "/payment-receiver/test")
+	env.DataSchema = "type.googleapis.com/payment.PaymentEvent"
+
+	decoded, err := envelope.Decode(env.Encode())
+	require.NoError(t, err)
+	assert.Equal(t, env, decoded)
+}
+
+func TestEncodeDecode_RoundTripThroughStringValues(t *testing.T) {
+	env := mustFromOutboxEvent(t, newOutboxEvent(), "/payment-receiver/test")
+
+	// Redis returns every stream field as a string.
+	values := map[string]interface{}{}
+	for k, v := range env.Encode() {
+		switch v := v.(type) {
+		case []byte:
+			values[k] = string(v)
+		default:
+			values[k] = v
+		}
+	}
+
+	decoded, err := envelope.Decode(values)
+	require.NoError(t, err)
+	assert.Equal(t, env, decoded)
+}
+
+func TestEncode_OmitsEmptyDataSchema(t *testing.T) {
+	ev := newOutboxEvent()
+
+	values := mustFromOutboxEvent(t, ev, "").Encode()
+
+	assert.NotContains(t, values, envelope.FieldDataSchema)
+	assert.Equal(t, []byte(ev.Payload), values[envelope.FieldData])
+}
+
+func TestDecode_Invalid(t *testing.T) {
+	valid := mustFromOutboxEvent(t, newOutboxEvent(), "").Encode()
+
+	tests := []struct {
+		name   string
+		mutate func(map[string]interface{})
+	}{
+		{"unsupported specversion", func(v map[string]interface{}) { v[envelope.FieldSpecVersion] = "0.3" }},
+		{"missing id", func(v map[string]interface{}) { delete(v, envelope.FieldID) }},
+		{"missing type", func(v map[string]interface{}) { delete(v, envelope.FieldType) }},
+		{"missing source", func(v map[string]interface{}) { delete(v, envelope.FieldSource) }},
+		{"invalid time", func(v map[string]interface{}) { v[envelope.FieldTime] = "yesterday" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values := map[string]interface{}{}
+			for k, v := range valid {
+				values[k] = v
+			}
+			tt.mutate(values)
+
+			env, err := envelope.Decode(values)
+			assert.ErrorIs(t, err, envelope.ErrInvalidEnvelope)
+			assert.Nil(t, env)
+		})
+	}
+}
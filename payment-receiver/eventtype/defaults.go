@@ -0,0 +1,21 @@
+package eventtype
+
+import (
+	pb "payment-receiver/gen/proto"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// PaymentEvent is the event type of payment webhooks stored by the receiver.
+const PaymentEvent = "payment_event"
+
+// Default returns a registry with the event types produced by this service.
+func Default() *Registry {
+	r := NewRegistry()
+	_ = r.Register(Type{
+		Name:       PaymentEvent,
+		DataSchema: "type.googleapis.com/payment.PaymentEvent",
+		Validate:   ProtoValidator(func() proto.Message { return &pb.PaymentEvent{} }),
+	})
+	return r
+}
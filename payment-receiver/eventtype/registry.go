@@ -0,0 +1,81 @@
+// Package eventtype keeps a registry of outbox event types and their schema hooks.
+package eventtype
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"payment-receiver/codec"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrInvalidPayload is returned when a payload fails its event type's validation.
+var ErrInvalidPayload = errors.New("payload does not match event type schema")
+
+// ValidateFunc checks that data, encoded with c, is a valid payload for an event type.
+type ValidateFunc func(data []byte, c codec.Codec) error
+
+// Type describes an event type published from the outbox.
+type Type struct {
+	Name       string
+	DataSchema string
+	Validate   ValidateFunc
+}
+
+// Registry maps event type names to their descriptions.
+type Registry struct {
+	mu    sync.RWMutex
+	types map[string]Type
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{types: make(map[string]Type)}
+}
+
+// Register adds t to the registry, replacing any type with the same name.
+func (r *Registry) Register(t Type) error {
+	if t.Name == "" {
+		return errors.New("event type name is required")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.types[t.Name] = t
+	return nil
+}
+
+// Lookup returns the type registered under name.
+func (r *Registry) Lookup(name string) (Type, bool) {
+	if r == nil {
+		return Type{}, false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.types[name]
+	return t, ok
+}
+
+// Validate runs the validation hook of eventType, if one is registered.
+// Unknown types and types without a hook are accepted.
+func (r *Registry) Validate(eventType string, data []byte, c codec.Codec) error {
+	t, ok := r.Lookup(eventType)
+	if !ok || t.Validate == nil {
+		return nil
+	}
+	if err := t.Validate(data, c); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidPayload, eventType, err)
+	}
+	return nil
+}
+
+// ProtoValidator returns a ValidateFunc that checks data decodes into the message
+// returned by newMessage.
+func ProtoValidator(newMessage func() proto.Message) ValidateFunc {
+	return func(data []byte, c codec.Codec) error {
+		return c.Unmarshal(data, newMessage())
+	}
+}
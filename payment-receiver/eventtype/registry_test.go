@@ -0,0 +1,57 @@
+package eventtype_test
+
+import (
+	"testing"
+
+	"payment-receiver/codec"
+	"payment-receiver/eventtype"
+	pb "payment-receiver/gen/proto"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_Validate(t *testing.T) {
+	r := eventtype.Default()
+
+	valid, err := codec.Protobuf.Marshal(&pb.PaymentEvent{Id: "evt_001"})
+	require.NoError(t, err)
+
+	t.Run("valid payload passes", func(t *testing.T) {
+		assert.NoError(t, r.Validate(eventtype.PaymentEvent, valid, codec.Protobuf))
+	})
+
+	t.Run("malformed payload fails", func(t *testing.T) {
+		err := r.Validate(eventtype.PaymentEvent, []byte("{not protobuf"), codec.Protobuf)
+		assert.ErrorIs(t, err, eventtype.ErrInvalidPayload)
+	})
+
+	t.Run("payload is validated with its own codec", func(t *testing.T) {
+		data, err := codec.JSON.Marshal(&pb.PaymentEvent{Id: "evt_001"})
+		require.NoError(t, err)
+		assert.NoError(t, r.Validate(eventtype.PaymentEvent, data, codec.JSON))
+	})
+
+	t.Run("unknown type is accepted", func(t *testing.T) {
+		assert.NoError(t, r.Validate("other_event", []byte("anything"), codec.Protobuf))
+	})
+
+	t.Run("nil registry accepts everything", func(t *testing.T) {
+		var nilRegistry *eventtype.Registry
+		assert.NoError(t, nilRegistry.Validate(eventtype.PaymentEvent, []byte("x"), codec.Protobuf))
+	})
+}
+
+func TestRegistry_Register(t *testing.T) {
+	r := eventtype.NewRegistry()
+
+	assert.Error(t, r.Register(eventtype.Type{}))
+	require.NoError(t, r.Register(eventtype.Type{Name: "refund_event", DataSchema: "urn:refund"}))
+
+	got, ok := r.Lookup("refund_event")
+	assert.True(t, ok)
+	assert.Equal(t, "urn:refund", got.DataSchema)
+
+	_, ok = r.Lookup("missing")
+	assert.False(t, ok)
+}
@@ -0,0 +1,241 @@
+// Package handler provides HTTP handler functions.
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"payment-receiver/domain"
+	"payment-receiver/repository"
+	"payment-receiver/usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// OutboxEventListResponse is the body of GET /admin/outbox.
+type OutboxEventListResponse struct {
+	Events     []OutboxEventResponse `json:"events"`
+	NextCursor string                `json:"next_cursor,omitempty"`
+}
+
+// RequeueRequest is the body of POST /admin/outbox/requeue.
+type RequeueRequest struct {
+	Status string `json:"status"`
+	From   string `json:"from"   binding:"required"`
+	To     string `json:"to"     binding:"required"`
+}
+
+// PauseRequest is the body of POST /admin/dispatcher/pause.
+type PauseRequest struct {
+	Reason string `json:"reason"`
+}
+
+// DispatcherStatusResponse is the body of GET /admin/dispatcher.
+type DispatcherStatusResponse struct {
+	Paused          bool       `json:"paused"`
+	Reason          string     `json:"reason,omitempty"`
+	UpdatedBy       string     `json:"updated_by,omitempty"`
+	UpdatedAt       *time.Time `json:"updated_at,omitempty"`
+	Pending         int64      `json:"pending"`
+	Failed          int64      `json:"failed"`
+	OldestPendingAt *time.Time `json:"oldest_pending_at,omitempty"`
+	LastSentAt      *time.Time `json:"last_sent_at,omitempty"`
+	LagSeconds      float64    `json:"lag_seconds"`
+}
+
+// ListOutboxEventsHandler serves
+// GET /admin/outbox?status=&event_type=&aggregate_id=&from=&to=&limit=&cursor=.
+func ListOutboxEventsHandler(admin usecase.OutboxAdmin) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter := repository.OutboxFilter{
+			Status:      domain.OutboxStatus(c.Query("status")),
+			EventType:   c.Query("event_type"),
+			AggregateID: c.Query("aggregate_id"),
+		}
+
+		var err error
+		if v := c.Query("from"); v != "" {
+			if filter.From, err = domain.ParseOccurredAt(v); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from"})
+				return
+			}
+		}
+		if v := c.Query("to"); v != "" {
+			if filter.To, err = domain.ParseOccurredAt(v); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to"})
+				return
+			}
+		}
+		if v := c.Query("limit"); v != "" {
+			if filter.Limit, err = strconv.Atoi(v); err != nil || filter.Limit < 1 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+				return
+			}
+		}
+		if v := c.Query("cursor"); v != "" {
+			if filter.After, err = decodeOutboxCursor(v); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cursor"})
+				return
+			}
+		}
+
+		page, err := admin.ListEvents(c.Request.Context(), filter)
+		if err != nil {
+			writeQueryError(c, "outbox events", err)
+			return
+		}
+
+		resp := OutboxEventListResponse{Events: make([]OutboxEventResponse, 0, len(page.Events))}
+		for _, ev := range page.Events {
+			resp.Events = append(resp.Events, newOutboxEventResponse(ev))
+		}
+		if page.Next != nil {
+			resp.NextCursor = encodeOutboxCursor(page.Next)
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// RetryOutboxEventHandler serves POST /admin/outbox/:id/retry.
+func RetryOutboxEventHandler(admin usecase.OutboxAdmin) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+			return
+		}
+
+		if err := admin.RetryEvent(c.Request.Context(), Actor(c), id); err != nil {
+			switch {
+			case errors.Is(err, repository.ErrNotFound):
+				c.JSON(http.StatusNotFound, gin.H{"error": "outbox event not found"})
+			case errors.Is(err, usecase.ErrEventNotRetryable):
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			default:
+				log.Printf("failed to retry outbox event %s: %v", id, err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retry event"})
+			}
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "requeued", "id": id})
+	}
+}
+
+// RequeueOutboxEventsHandler serves POST /admin/outbox/requeue. status
+// defaults to failed; from is inclusive and to is exclusive on created_at.
+func RequeueOutboxEventsHandler(admin usecase.OutboxAdmin) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req RequeueRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+			return
+		}
+		from, err := domain.ParseOccurredAt(req.From)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from"})
+			return
+		}
+		to, err := domain.ParseOccurredAt(req.To)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to"})
+			return
+		}
+		status := domain.OutboxStatus(req.Status)
+		if status == "" {
+			status = domain.StatusFailed
+		}
+
+		n, err := admin.RequeueRange(c.Request.Context(), Actor(c), status, from, to)
+		if err != nil {
+			if errors.Is(err, usecase.ErrInvalidRequeue) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			log.Printf("failed to requeue outbox events: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to requeue events"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "requeued", "count": n})
+	}
+}
+
+// PauseDispatcherHandler serves POST /admin/dispatcher/pause.
+func PauseDispatcherHandler(admin usecase.OutboxAdmin) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req PauseRequest
+		if c.Request.ContentLength != 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+				return
+			}
+		}
+		setPaused(c, admin, true, req.Reason)
+	}
+}
+
+// ResumeDispatcherHandler serves POST /admin/dispatcher/resume.
+func ResumeDispatcherHandler(admin usecase.OutboxAdmin) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		setPaused(c, admin, false, "")
+	}
+}
+
+func setPaused(c *gin.Context, admin usecase.OutboxAdmin, paused bool, reason string) {
+	if err := admin.SetPaused(c.Request.Context(), Actor(c), paused, reason); err != nil {
+		log.Printf("failed to update dispatcher state: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update dispatcher state"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"paused": paused})
+}
+
+// DispatcherStatusHandler serves GET /admin/dispatcher.
+func DispatcherStatusHandler(admin usecase.OutboxAdmin) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		status, err := admin.Status(c.Request.Context())
+		if err != nil {
+			writeQueryError(c, "dispatcher status", err)
+			return
+		}
+
+		resp := DispatcherStatusResponse{
+			Paused:          status.State.Paused,
+			Reason:          status.State.Reason,
+			UpdatedBy:       status.State.UpdatedBy,
+			Pending:         status.Stats.Pending,
+			Failed:          status.Stats.Failed,
+			OldestPendingAt: status.Stats.OldestPendingAt,
+			LastSentAt:      status.Stats.LastSentAt,
+			LagSeconds:      status.Lag.Seconds(),
+		}
+		if !status.State.UpdatedAt.IsZero() {
+			resp.UpdatedAt = &status.State.UpdatedAt
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// outboxCursor is the JSON form of an OutboxCursor.
+type outboxCursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        uuid.UUID `json:"id"`
+}
+
+func encodeOutboxCursor(cur *repository.OutboxCursor) string {
+	return encodeCursor(outboxCursor{CreatedAt: cur.CreatedAt, ID: cur.ID})
+}
+
+func decodeOutboxCursor(s string) (*repository.OutboxCursor, error) {
+	var cur outboxCursor
+	if err := decodeCursor(s, &cur); err != nil {
+		return nil, err
+	}
+	if cur.ID == uuid.Nil || cur.CreatedAt.IsZero() {
+		return nil, errors.New("incomplete cursor")
+	}
+	return &repository.OutboxCursor{CreatedAt: cur.CreatedAt, ID: cur.ID}, nil
+}
@@ -0,0 +1,195 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"payment-receiver/domain"
+	"payment-receiver/handler"
+	"payment-receiver/repository"
+	"payment-receiver/usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeOutboxAdmin struct {
+	actor    string
+	filter   repository.OutboxFilter
+	page     *usecase.OutboxEventPage
+	retryErr error
+	from     domain.OutboxStatus
+	start    time.Time
+	end      time.Time
+	paused   *bool
+	reason   string
+	status   *usecase.DispatcherStatus
+}
+
+func (f *fakeOutboxAdmin) ListEvents(_ context.Context, filter repository.OutboxFilter) (*usecase.OutboxEventPage, error) {
+	f.filter = filter
+	return f.page, nil
+}
+
+func (f *fakeOutboxAdmin) RetryEvent(_ context.Context, actor string, _ uuid.UUID) error {
+	f.actor = actor
+	return f.retryErr
+}
+
+func (f *fakeOutboxAdmin) RequeueRange(
+	_ context.Context,
+	actor string,
+	from domain.OutboxStatus,
+	start, end time.Time,
+) (int64, error) {
+	f.actor, f.from, f.start, f.end = actor, from, start, end
+	return 4, nil
+}
+
+func (f *fakeOutboxAdmin) SetPaused(_ context.Context, actor string, paused bool, reason string) error {
+	f.actor, f.paused, f.reason = actor, &paused, reason
+	return nil
+}
+
+func (f *fakeOutboxAdmin) Status(_ context.Context) (*usecase.DispatcherStatus, error) {
+	return f.status, nil
+}
+
+const adminKey = "admin-secret"
+
+func newAdminRouter(admin usecase.OutboxAdmin) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	api := router.Group("/admin", handler.NamedAPIKeyAuth(map[string]string{"alice": adminKey}))
+	api.GET("/outbox", handler.ListOutboxEventsHandler(admin))
+	api.POST("/outbox/:id/retry", handler.RetryOutboxEventHandler(admin))
+	api.POST("/outbox/requeue", handler.RequeueOutboxEventsHandler(admin))
+	api.GET("/dispatcher", handler.DispatcherStatusHandler(admin))
+	api.POST("/dispatcher/pause", handler.PauseDispatcherHandler(admin))
+	api.POST("/dispatcher/resume", handler.ResumeDispatcherHandler(admin))
+	return router
+}
+
+func serveAdmin(router *gin.Engine, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+adminKey)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAdmin_RequiresKey(t *testing.T) {
+	router := newAdminRouter(&fakeOutboxAdmin{})
+	req := httptest.NewRequest(http.MethodPost, "/admin/dispatcher/resume", nil)
+	req.Header.Set(handler.APIKeyHeader, "wrong")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestListOutboxEventsHandler(t *testing.T) {
+	last := &domain.OutboxEvent{ID: uuid.New(), Status: domain.StatusFailed, CreatedAt: time.Now().UTC()}
+	admin := &fakeOutboxAdmin{page: &usecase.OutboxEventPage{
+		Events: []*domain.OutboxEvent{last},
+		Next:   &repository.OutboxCursor{CreatedAt: last.CreatedAt, ID: last.ID},
+	}}
+	router := newAdminRouter(admin)
+
+	w := serveAdmin(router, http.MethodGet, "/admin/outbox?status=failed&event_type=payment_event&limit=1", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, domain.StatusFailed, admin.filter.Status)
+	assert.Equal(t, "payment_event", admin.filter.EventType)
+
+	var resp handler.OutboxEventListResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Events, 1)
+	require.NotEmpty(t, resp.NextCursor)
+
+	w = serveAdmin(router, http.MethodGet, "/admin/outbox?cursor="+resp.NextCursor, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NotNil(t, admin.filter.After)
+	assert.Equal(t, last.ID, admin.filter.After.ID)
+}
+
+func TestRetryOutboxEventHandler(t *testing.T) {
+	cases := map[string]struct {
+		err  error
+		want int
+	}{
+		"requeued":      {want: http.StatusOK},
+		"not found":     {err: repository.ErrNotFound, want: http.StatusNotFound},
+		"not retryable": {err: fmt.Errorf("%w: event is sent", usecase.ErrEventNotRetryable), want: http.StatusConflict},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			admin := &fakeOutboxAdmin{retryErr: tc.err}
+			w := serveAdmin(newAdminRouter(admin), http.MethodPost, "/admin/outbox/"+uuid.NewString()+"/retry", "")
+			assert.Equal(t, tc.want, w.Code)
+			assert.Equal(t, "alice", admin.actor)
+		})
+	}
+}
+
+func TestRequeueOutboxEventsHandler(t *testing.T) {
+	admin := &fakeOutboxAdmin{}
+	router := newAdminRouter(admin)
+
+	w := serveAdmin(router, http.MethodPost, "/admin/outbox/requeue",
+		`{"from":"2024-04-01T00:00:00Z","to":"2024-04-02T00:00:00Z"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status":"requeued","count":4}`, w.Body.String())
+	assert.Equal(t, "alice", admin.actor)
+	assert.Equal(t, domain.StatusFailed, admin.from, "status defaults to failed")
+	assert.Equal(t, time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), admin.start)
+	assert.Equal(t, time.Date(2024, 4, 2, 0, 0, 0, 0, time.UTC), admin.end)
+
+	w = serveAdmin(router, http.MethodPost, "/admin/outbox/requeue", `{"from":"bad","to":"2024-04-02T00:00:00Z"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestPauseAndResumeDispatcherHandlers(t *testing.T) {
+	admin := &fakeOutboxAdmin{}
+	router := newAdminRouter(admin)
+
+	w := serveAdmin(router, http.MethodPost, "/admin/dispatcher/pause", `{"reason":"broker maintenance"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NotNil(t, admin.paused)
+	assert.True(t, *admin.paused)
+	assert.Equal(t, "broker maintenance", admin.reason)
+	assert.Equal(t, "alice", admin.actor)
+
+	w = serveAdmin(router, http.MethodPost, "/admin/dispatcher/resume", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.False(t, *admin.paused)
+}
+
+func TestDispatcherStatusHandler(t *testing.T) {
+	oldest := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+	admin := &fakeOutboxAdmin{status: &usecase.DispatcherStatus{
+		State: &domain.DispatcherState{Paused: true, UpdatedBy: "alice", Reason: "maintenance"},
+		Stats: &domain.OutboxStats{Pending: 12, Failed: 2, OldestPendingAt: &oldest},
+		Lag:   90 * time.Second,
+	}}
+
+	w := serveAdmin(newAdminRouter(admin), http.MethodGet, "/admin/dispatcher", "")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp handler.DispatcherStatusResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.True(t, resp.Paused)
+	assert.Equal(t, "alice", resp.UpdatedBy)
+	assert.Equal(t, int64(12), resp.Pending)
+	assert.Equal(t, int64(2), resp.Failed)
+	assert.Equal(t, 90.0, resp.LagSeconds)
+}
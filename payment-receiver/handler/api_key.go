@@ -0,0 +1,83 @@
+// Package handler provides HTTP handler functions.
+package handler
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHeader is the header carrying the API key. A bearer token in the
+// Authorization header is accepted as well.
+const APIKeyHeader = "X-API-Key"
+
+// actorKey is the gin context key holding the name of the authenticated key.
+const actorKey = "actor"
+
+// APIKeyAuth returns a middleware that rejects requests without one of keys.
+// With no keys configured every request is rejected.
+func APIKeyAuth(keys []string) gin.HandlerFunc {
+	named := make(map[string]string, len(keys))
+	for _, k := range keys {
+		named[k] = "api-key"
+	}
+	return apiKeyAuth(named)
+}
+
+// NamedAPIKeyAuth is like APIKeyAuth but keys maps each key's owner to the
+// key; the owner of the matching key is available to handlers via Actor.
+func NamedAPIKeyAuth(keys map[string]string) gin.HandlerFunc {
+	byKey := make(map[string]string, len(keys))
+	for name, k := range keys {
+		byKey[k] = name
+	}
+	return apiKeyAuth(byKey)
+}
+
+// Actor returns the owner of the API key that authenticated the request.
+func Actor(c *gin.Context) string {
+	return c.GetString(actorKey)
+}
+
+type hashedKey struct {
+	name string
+	hash [32]byte
+}
+
+func apiKeyAuth(byKey map[string]string) gin.HandlerFunc {
+	keys := make([]hashedKey, 0, len(byKey))
+	for k, name := range byKey {
+		if k != "" {
+			keys = append(keys, hashedKey{name: name, hash: sha256.Sum256([]byte(k))})
+		}
+	}
+
+	return func(c *gin.Context) {
+		key := c.GetHeader(APIKeyHeader)
+		if key == "" {
+			if token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok {
+				key = token
+			}
+		}
+
+		if key != "" {
+			got := sha256.Sum256([]byte(key))
+			match := ""
+			for _, want := range keys {
+				if subtle.ConstantTimeCompare(got[:], want.hash[:]) == 1 {
+					match = want.name
+				}
+			}
+			if match != "" {
+				c.Set(actorKey, match)
+				c.Next()
+				return
+			}
+		}
+
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+	}
+}
@@ -0,0 +1,82 @@
+// Package handler provides HTTP handler functions.
+package handler
+
+import (
+	"crypto/x509"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// certProviderKey is the gin context key holding the provider identified by
+// a verified client certificate.
+const certProviderKey = "cert_provider"
+
+// ClientCertProvider returns a middleware that maps the verified client
+// certificate of an mTLS connection to a provider. subjects maps a
+// certificate's common name, DNS name or URI to the provider it identifies.
+// A verified certificate that maps to no provider, or a provider header that
+// names a different provider than the certificate, is rejected with 403, as
+// is a request without a verified certificate whose header names a provider
+// that has one. Other requests without a certificate pass through unchanged.
+func ClientCertProvider(subjects map[string]string, providerHeader string) gin.HandlerFunc {
+	certified := make(map[string]bool, len(subjects))
+	for _, provider := range subjects {
+		certified[provider] = true
+	}
+
+	return func(c *gin.Context) {
+		tlsState := c.Request.TLS
+		if tlsState == nil || len(tlsState.VerifiedChains) == 0 || len(tlsState.VerifiedChains[0]) == 0 {
+			if certified[c.GetHeader(providerHeader)] {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "provider requires a client certificate"})
+				return
+			}
+			c.Next()
+			return
+		}
+
+		provider, ok := providerForCert(tlsState.VerifiedChains[0][0], subjects)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "client certificate is not mapped to a provider"})
+			return
+		}
+		if claimed := c.GetHeader(providerHeader); claimed != "" && claimed != provider {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "provider does not match client certificate"})
+			return
+		}
+
+		c.Set(certProviderKey, provider)
+		c.Next()
+	}
+}
+
+// CertProvider returns the provider identified by the request's client
+// certificate, or "" when ClientCertProvider did not identify one.
+func CertProvider(c *gin.Context) string {
+	return c.GetString(certProviderKey)
+}
+
+// ByProvider keys requests by provider: the client certificate identity when
+// there is one, otherwise the value of header.
+func ByProvider(header string) func(c *gin.Context) string {
+	return func(c *gin.Context) string {
+		if p := CertProvider(c); p != "" {
+			return p
+		}
+		return c.GetHeader(header)
+	}
+}
+
+func providerForCert(cert *x509.Certificate, subjects map[string]string) (string, bool) {
+	names := append([]string{cert.Subject.CommonName}, cert.DNSNames...)
+	for _, u := range cert.URIs {
+		names = append(names, u.String())
+	}
+	for _, name := range names {
+		if provider, ok := subjects[name]; ok && name != "" {
+			return provider, true
+		}
+	}
+	return "", false
+}
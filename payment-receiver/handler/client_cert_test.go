@@ -0,0 +1,76 @@
+package handler_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"payment-receiver/handler"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newClientCertRouter(subjects map[string]string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/webhook",
+		handler.ClientCertProvider(subjects, "X-Provider-Id"),
+		func(c *gin.Context) {
+			c.String(http.StatusCreated, handler.ByProvider("X-Provider-Id")(c))
+		},
+	)
+	return router
+}
+
+func postWithClientCert(router *gin.Engine, cert *x509.Certificate, provider string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/webhook", nil)
+	if cert != nil {
+		req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	}
+	if provider != "" {
+		req.Header.Set("X-Provider-Id", provider)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestClientCertProvider(t *testing.T) {
+	spiffe, _ := url.Parse("spiffe://globex.example/webhooks")
+	router := newClientCertRouter(map[string]string{
+		"acme-webhooks":                    "acme",
+		"hooks.initech.example":            "initech",
+		"spiffe://globex.example/webhooks": "globex",
+	})
+
+	tests := []struct {
+		name     string
+		cert     *x509.Certificate
+		header   string
+		wantCode int
+		wantBody string
+	}{
+		{"common name", &x509.Certificate{Subject: pkix.Name{CommonName: "acme-webhooks"}}, "", http.StatusCreated, "acme"},
+		{"dns name", &x509.Certificate{DNSNames: []string{"hooks.initech.example"}}, "", http.StatusCreated, "initech"},
+		{"uri", &x509.Certificate{URIs: []*url.URL{spiffe}}, "", http.StatusCreated, "globex"},
+		{"matching header", &x509.Certificate{Subject: pkix.Name{CommonName: "acme-webhooks"}}, "acme", http.StatusCreated, "acme"},
+		{"header names another provider", &x509.Certificate{Subject: pkix.Name{CommonName: "acme-webhooks"}}, "globex", http.StatusForbidden, ""},
+		{"unmapped certificate", &x509.Certificate{Subject: pkix.Name{CommonName: "mallory"}}, "", http.StatusForbidden, ""},
+		{"no certificate for a certificate provider", nil, "acme", http.StatusForbidden, ""},
+		{"no certificate for another provider", nil, "umbrella", http.StatusCreated, "umbrella"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := postWithClientCert(router, tt.cert, tt.header)
+			assert.Equal(t, tt.wantCode, w.Code)
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+// Package handler provides HTTP handler functions.
+package handler
+
+import (
+	"encoding/base64"
+	"encoding/json"
+)
+
+// encodeCursor returns the opaque, base64url-encoded JSON form of v.
+func encodeCursor(v any) string {
+	b, _ := json.Marshal(v)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeCursor parses a cursor produced by encodeCursor into v.
+func decodeCursor(s string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
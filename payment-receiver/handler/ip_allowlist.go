@@ -0,0 +1,149 @@
+// Package handler provides HTTP handler functions.
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Reasons counted by IPAllowlist.Rejections. They are kept apart from
+// signature outcomes so a misconfigured allowlist is not mistaken for forged
+// requests.
+const (
+	RejectIPNotAllowed    = "ip_not_allowed"
+	RejectUnknownProvider = "unknown_provider"
+	RejectUnparseableIP   = "invalid_client_ip"
+)
+
+// IPAllowlist holds the source ranges each provider may send webhooks from.
+// The ranges can be replaced at runtime with Update.
+type IPAllowlist struct {
+	ranges atomic.Pointer[map[string][]netip.Prefix]
+
+	mu         sync.Mutex
+	rejections map[string]int64
+}
+
+// NewIPAllowlist creates an allowlist from provider name to source ranges.
+func NewIPAllowlist(ranges map[string][]netip.Prefix) *IPAllowlist {
+	a := &IPAllowlist{rejections: map[string]int64{}}
+	a.Update(ranges)
+	return a
+}
+
+// Update atomically replaces every provider's ranges.
+func (a *IPAllowlist) Update(ranges map[string][]netip.Prefix) {
+	a.ranges.Store(&ranges)
+}
+
+// Allowed reports whether provider is configured and ip lies in one of its
+// ranges. The second result is false when the provider is not configured.
+func (a *IPAllowlist) Allowed(provider string, ip netip.Addr) (allowed, known bool) {
+	prefixes, ok := (*a.ranges.Load())[provider]
+	if !ok {
+		return false, false
+	}
+	ip = ip.Unmap()
+	for _, p := range prefixes {
+		if p.Contains(ip) {
+			return true, true
+		}
+	}
+	return false, true
+}
+
+// Known reports whether provider has configured ranges.
+func (a *IPAllowlist) Known(provider string) bool {
+	_, ok := (*a.ranges.Load())[provider]
+	return ok
+}
+
+// Rejections returns the number of rejected requests. RejectIPNotAllowed is
+// keyed by "<reason>:<provider>" for providers in the allowlist; the other
+// reasons are keyed by reason alone, so client-chosen provider names cannot
+// add keys.
+func (a *IPAllowlist) Rejections() map[string]int64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	out := make(map[string]int64, len(a.rejections))
+	for k, v := range a.rejections {
+		out[k] = v
+	}
+	return out
+}
+
+func (a *IPAllowlist) reject(reason, provider string) {
+	key := reason
+	if reason == RejectIPNotAllowed && a.Known(provider) {
+		key += ":" + provider
+	}
+	a.mu.Lock()
+	a.rejections[key]++
+	a.mu.Unlock()
+}
+
+// ParseIPRanges parses CIDR ranges and single addresses, which are treated as
+// /32 or /128 ranges.
+func ParseIPRanges(entries []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if strings.Contains(entry, "/") {
+			p, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CIDR %q: %w", entry, err)
+			}
+			prefixes = append(prefixes, p.Masked())
+			continue
+		}
+		ip, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid IP %q: %w", entry, err)
+		}
+		ip = ip.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(ip, ip.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// SourceIPAllowlist returns a middleware that rejects with 403 any request
+// whose client IP is outside the ranges of the provider named by providerKey.
+// Requests from providers that are not in the allowlist are rejected too.
+// The client IP honors X-Forwarded-For only from the engine's trusted proxies.
+func SourceIPAllowlist(allowlist *IPAllowlist, providerKey func(c *gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provider := providerKey(c)
+		clientIP := c.ClientIP()
+
+		reason := ""
+		if ip, err := netip.ParseAddr(clientIP); err != nil {
+			reason = RejectUnparseableIP
+		} else if allowed, known := allowlist.Allowed(provider, ip); !known {
+			reason = RejectUnknownProvider
+		} else if !allowed {
+			reason = RejectIPNotAllowed
+		}
+
+		if reason != "" {
+			allowlist.reject(reason, provider)
+			log.Printf("webhook rejected by IP allowlist: reason=%s provider=%q ip=%s", reason, provider, clientIP)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "source not allowed"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// IPAllowlistRejectionsHandler serves the allowlist rejection counters.
+func IPAllowlistRejectionsHandler(allowlist *IPAllowlist) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"rejections": allowlist.Rejections()})
+	}
+}
@@ -0,0 +1,148 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"payment-receiver/handler"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAllowlist(t *testing.T, ranges map[string][]string) *handler.IPAllowlist {
+	t.Helper()
+	parsed := map[string][]netip.Prefix{}
+	for provider, entries := range ranges {
+		prefixes, err := handler.ParseIPRanges(entries)
+		require.NoError(t, err)
+		parsed[provider] = prefixes
+	}
+	return handler.NewIPAllowlist(parsed)
+}
+
+func newAllowlistRouter(t *testing.T, allowlist *handler.IPAllowlist, trusted []string) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	require.NoError(t, router.SetTrustedProxies(trusted))
+	router.POST("/webhook", handler.SourceIPAllowlist(allowlist, handler.ByHeader("X-Provider-Id")), func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+	return router
+}
+
+func TestParseIPRanges(t *testing.T) {
+	prefixes, err := handler.ParseIPRanges([]string{"192.0.2.0/24", " 198.51.100.7 ", "2001:db8::/32", "::ffff:203.0.113.9", "10.1.2.3/8"})
+	require.NoError(t, err)
+	assert.Equal(t, []netip.Prefix{
+		netip.MustParsePrefix("192.0.2.0/24"),
+		netip.MustParsePrefix("198.51.100.7/32"),
+		netip.MustParsePrefix("2001:db8::/32"),
+		netip.MustParsePrefix("203.0.113.9/32"),
+		netip.MustParsePrefix("10.0.0.0/8"),
+	}, prefixes)
+
+	for _, bad := range []string{"192.0.2.0/33", "not-an-ip", ""} {
+		_, err := handler.ParseIPRanges([]string{bad})
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestSourceIPAllowlist(t *testing.T) {
+	allowlist := newAllowlist(t, map[string][]string{
+		"acme":   {"192.0.2.0/24", "2001:db8::/32"},
+		"globex": {"198.51.100.7"},
+	})
+	router := newAllowlistRouter(t, allowlist, nil)
+
+	tests := []struct {
+		name       string
+		provider   string
+		remoteAddr string
+		want       int
+	}{
+		{"in range", "acme", "192.0.2.10:443", http.StatusCreated},
+		{"ipv6 in range", "acme", "[2001:db8::1]:443", http.StatusCreated},
+		{"single address", "globex", "198.51.100.7:443", http.StatusCreated},
+		{"other provider's range", "globex", "192.0.2.10:443", http.StatusForbidden},
+		{"outside range", "acme", "203.0.113.1:443", http.StatusForbidden},
+		{"unknown provider", "initech", "192.0.2.10:443", http.StatusForbidden},
+		{"missing provider", "", "192.0.2.10:443", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := postWebhook(router, tt.remoteAddr, map[string]string{"X-Provider-Id": tt.provider})
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+
+	assert.Equal(t, map[string]int64{
+		"ip_not_allowed:globex": 1,
+		"ip_not_allowed:acme":   1,
+		"unknown_provider":      2,
+	}, allowlist.Rejections())
+}
+
+func TestSourceIPAllowlist_UnknownProvidersShareOneCounter(t *testing.T) {
+	allowlist := newAllowlist(t, map[string][]string{"acme": {"192.0.2.0/24"}})
+	router := newAllowlistRouter(t, allowlist, nil)
+
+	for _, provider := range []string{"random-1", "random-2", "random-3"} {
+		w := postWebhook(router, "192.0.2.10:443", map[string]string{"X-Provider-Id": provider})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	}
+	w := postWebhook(router, "not-an-ip", map[string]string{"X-Provider-Id": "acme"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	assert.Equal(t, map[string]int64{
+		"unknown_provider":  3,
+		"invalid_client_ip": 1,
+	}, allowlist.Rejections())
+}
+
+func TestSourceIPAllowlist_ForwardedForOnlyFromTrustedProxies(t *testing.T) {
+	allowlist := newAllowlist(t, map[string][]string{"acme": {"192.0.2.0/24"}})
+	headers := map[string]string{"X-Provider-Id": "acme", "X-Forwarded-For": "192.0.2.10"}
+
+	trusted := newAllowlistRouter(t, allowlist, []string{"10.0.0.0/8"})
+	assert.Equal(t, http.StatusCreated, postWebhook(trusted, "10.1.2.3:443", headers).Code)
+	assert.Equal(t, http.StatusForbidden, postWebhook(trusted, "203.0.113.1:443", headers).Code,
+		"X-Forwarded-For from an untrusted peer is ignored")
+
+	untrusted := newAllowlistRouter(t, allowlist, nil)
+	assert.Equal(t, http.StatusForbidden, postWebhook(untrusted, "10.1.2.3:443", headers).Code)
+}
+
+func TestIPAllowlist_Update(t *testing.T) {
+	allowlist := newAllowlist(t, map[string][]string{"acme": {"192.0.2.0/24"}})
+	router := newAllowlistRouter(t, allowlist, nil)
+	headers := map[string]string{"X-Provider-Id": "acme"}
+
+	require.Equal(t, http.StatusForbidden, postWebhook(router, "198.51.100.7:443", headers).Code)
+
+	allowlist.Update(map[string][]netip.Prefix{"acme": {netip.MustParsePrefix("198.51.100.0/24")}})
+	assert.Equal(t, http.StatusCreated, postWebhook(router, "198.51.100.7:443", headers).Code)
+	assert.Equal(t, http.StatusForbidden, postWebhook(router, "192.0.2.10:443", headers).Code)
+}
+
+func TestIPAllowlistRejectionsHandler(t *testing.T) {
+	allowlist := newAllowlist(t, map[string][]string{"acme": {"192.0.2.0/24"}})
+	postWebhook(newAllowlistRouter(t, allowlist, nil), "203.0.113.1:443", map[string]string{"X-Provider-Id": "acme"})
+
+	router := gin.New()
+	router.GET("/admin/webhook/rejections", handler.IPAllowlistRejectionsHandler(allowlist))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/webhook/rejections", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var body struct {
+		Rejections map[string]int64 `json:"rejections"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, map[string]int64{"ip_not_allowed:acme": 1}, body.Rejections)
+}
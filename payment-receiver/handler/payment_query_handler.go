@@ -0,0 +1,207 @@
+// Package handler provides HTTP handler functions.
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"payment-receiver/codec"
+	"payment-receiver/domain"
+	"payment-receiver/repository"
+	"payment-receiver/usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// PaymentResponse is the JSON view of a payment's current state.
+type PaymentResponse struct {
+	ID         string    `json:"id"`
+	Amount     int       `json:"amount"`
+	Currency   string    `json:"currency"`
+	Method     string    `json:"method"`
+	Status     string    `json:"status"`
+	OccurredAt time.Time `json:"occurred_at"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// OutboxEventResponse is the JSON view of an outbox event and its dispatch status.
+type OutboxEventResponse struct {
+	ID          uuid.UUID  `json:"id"`
+	AggregateID string     `json:"aggregate_id"`
+	EventType   string     `json:"event_type"`
+	Codec       string     `json:"codec"`
+	Status      string     `json:"status"`
+	EventAt     time.Time  `json:"event_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	SentAt      *time.Time `json:"sent_at,omitempty"`
+	Attempts    int        `json:"attempts"`
+}
+
+// PaymentDetailsResponse is the body of GET /payments/:id.
+type PaymentDetailsResponse struct {
+	PaymentResponse
+	Events []OutboxEventResponse `json:"events"`
+}
+
+// PaymentListResponse is the body of GET /payments.
+type PaymentListResponse struct {
+	Payments   []PaymentResponse `json:"payments"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
+
+// GetPaymentHandler serves GET /payments/:id.
+func GetPaymentHandler(querier usecase.PaymentQuerier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		details, err := querier.GetPayment(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			writeQueryError(c, "payment", err)
+			return
+		}
+
+		resp := PaymentDetailsResponse{
+			PaymentResponse: newPaymentResponse(details.Payment),
+			Events:          make([]OutboxEventResponse, 0, len(details.Events)),
+		}
+		for _, ev := range details.Events {
+			resp.Events = append(resp.Events, newOutboxEventResponse(ev))
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// ListPaymentsHandler serves GET /payments?status=&currency=&from=&to=&limit=&cursor=.
+// from and to accept the same formats as occurred_at; from is inclusive and
+// to is exclusive.
+func ListPaymentsHandler(querier usecase.PaymentQuerier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter := repository.PaymentFilter{
+			Status:   c.Query("status"),
+			Currency: c.Query("currency"),
+		}
+
+		var err error
+		if v := c.Query("from"); v != "" {
+			if filter.From, err = domain.ParseOccurredAt(v); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from"})
+				return
+			}
+		}
+		if v := c.Query("to"); v != "" {
+			if filter.To, err = domain.ParseOccurredAt(v); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to"})
+				return
+			}
+		}
+		if v := c.Query("limit"); v != "" {
+			if filter.Limit, err = strconv.Atoi(v); err != nil || filter.Limit < 1 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+				return
+			}
+		}
+		if v := c.Query("cursor"); v != "" {
+			if filter.After, err = decodePaymentCursor(v); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cursor"})
+				return
+			}
+		}
+
+		page, err := querier.ListPayments(c.Request.Context(), filter)
+		if err != nil {
+			writeQueryError(c, "payments", err)
+			return
+		}
+
+		resp := PaymentListResponse{Payments: make([]PaymentResponse, 0, len(page.Payments))}
+		for _, p := range page.Payments {
+			resp.Payments = append(resp.Payments, newPaymentResponse(p))
+		}
+		if page.Next != nil {
+			resp.NextCursor = encodePaymentCursor(page.Next)
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// GetOutboxEventHandler serves GET /outbox/:id.
+func GetOutboxEventHandler(querier usecase.PaymentQuerier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+			return
+		}
+
+		event, err := querier.GetOutboxEvent(c.Request.Context(), id)
+		if err != nil {
+			writeQueryError(c, "outbox event", err)
+			return
+		}
+		c.JSON(http.StatusOK, newOutboxEventResponse(event))
+	}
+}
+
+func writeQueryError(c *gin.Context, what string, err error) {
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": what + " not found"})
+		return
+	}
+	log.Printf("failed to query %s: %v", what, err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to query " + what})
+}
+
+func newPaymentResponse(p *domain.Payment) PaymentResponse {
+	return PaymentResponse{
+		ID:         p.ID,
+		Amount:     p.Amount,
+		Currency:   p.Currency,
+		Method:     p.Method,
+		Status:     p.Status,
+		OccurredAt: p.OccurredAt,
+		CreatedAt:  p.CreatedAt,
+		UpdatedAt:  p.UpdatedAt,
+	}
+}
+
+func newOutboxEventResponse(ev *domain.OutboxEvent) OutboxEventResponse {
+	codecName := ev.Codec
+	if codecName == "" {
+		codecName = codec.NameProtobuf
+	}
+	return OutboxEventResponse{
+		ID:          ev.ID,
+		AggregateID: ev.AggregateID,
+		EventType:   ev.EventType,
+		Codec:       codecName,
+		Status:      string(ev.Status),
+		EventAt:     ev.EventAt,
+		CreatedAt:   ev.CreatedAt,
+		SentAt:      ev.SentAt,
+		Attempts:    ev.Attempts,
+	}
+}
+
+// paymentCursor is the JSON form of a PaymentCursor.
+type paymentCursor struct {
+	OccurredAt time.Time `json:"t"`
+	ID         string    `json:"id"`
+}
+
+func encodePaymentCursor(cur *repository.PaymentCursor) string {
+	return encodeCursor(paymentCursor{OccurredAt: cur.OccurredAt, ID: cur.ID})
+}
+
+func decodePaymentCursor(s string) (*repository.PaymentCursor, error) {
+	var cur paymentCursor
+	if err := decodeCursor(s, &cur); err != nil {
+		return nil, err
+	}
+	if cur.ID == "" || cur.OccurredAt.IsZero() {
+		return nil, errors.New("incomplete cursor")
+	}
+	return &repository.PaymentCursor{OccurredAt: cur.OccurredAt, ID: cur.ID}, nil
+}
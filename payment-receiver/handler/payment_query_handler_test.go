@@ -0,0 +1,184 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"payment-receiver/domain"
+	"payment-receiver/handler"
+	"payment-receiver/repository"
+	"payment-receiver/usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePaymentQuerier struct {
+	details *usecase.PaymentDetails
+	page    *usecase.PaymentPage
+	event   *domain.OutboxEvent
+	err     error
+	filter  repository.PaymentFilter
+}
+
+func (f *fakePaymentQuerier) GetPayment(ctx context.Context, id string) (*usecase.PaymentDetails, error) {
+	return f.details, f.err
+}
+
+func (f *fakePaymentQuerier) ListPayments(
+	ctx context.Context,
+	filter repository.PaymentFilter,
+) (*usecase.PaymentPage, error) {
+	f.filter = filter
+	return f.page, f.err
+}
+
+func (f *fakePaymentQuerier) GetOutboxEvent(ctx context.Context, id uuid.UUID) (*domain.OutboxEvent, error) {
+	return f.event, f.err
+}
+
+const testAPIKey = "secret-key"
+
+func newQueryRouter(q usecase.PaymentQuerier) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	api := router.Group("/", handler.APIKeyAuth([]string{testAPIKey}))
+	api.GET("/payments", handler.ListPaymentsHandler(q))
+	api.GET("/payments/:id", handler.GetPaymentHandler(q))
+	api.GET("/outbox/:id", handler.GetOutboxEventHandler(q))
+	return router
+}
+
+func serveQuery(router *gin.Engine, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set(handler.APIKeyHeader, testAPIKey)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	router := newQueryRouter(&fakePaymentQuerier{err: repository.ErrNotFound})
+
+	cases := map[string]struct {
+		header, value string
+		want          int
+	}{
+		"missing":      {want: http.StatusUnauthorized},
+		"wrong key":    {header: handler.APIKeyHeader, value: "nope", want: http.StatusUnauthorized},
+		"header key":   {header: handler.APIKeyHeader, value: testAPIKey, want: http.StatusNotFound},
+		"bearer token": {header: "Authorization", value: "Bearer " + testAPIKey, want: http.StatusNotFound},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/payments/evt_001", nil)
+			if tc.header != "" {
+				req.Header.Set(tc.header, tc.value)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.want, w.Code)
+		})
+	}
+}
+
+func TestGetPaymentHandler(t *testing.T) {
+	occurredAt := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+	eventID := uuid.New()
+	router := newQueryRouter(&fakePaymentQuerier{details: &usecase.PaymentDetails{
+		Payment: &domain.Payment{ID: "evt_001", Amount: 1200, Currency: "USD", Method: "card", Status: "paid", OccurredAt: occurredAt},
+		Events:  []*domain.OutboxEvent{{ID: eventID, AggregateID: "evt_001", EventType: "payment_event", Status: domain.StatusPending}},
+	}})
+
+	w := serveQuery(router, "/payments/evt_001")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp handler.PaymentDetailsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "evt_001", resp.ID)
+	assert.Equal(t, "paid", resp.Status)
+	assert.True(t, occurredAt.Equal(resp.OccurredAt))
+	require.Len(t, resp.Events, 1)
+	assert.Equal(t, eventID, resp.Events[0].ID)
+	assert.Equal(t, "protobuf", resp.Events[0].Codec)
+}
+
+func TestGetPaymentHandler_Errors(t *testing.T) {
+	w := serveQuery(newQueryRouter(&fakePaymentQuerier{err: repository.ErrNotFound}), "/payments/missing")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = serveQuery(newQueryRouter(&fakePaymentQuerier{err: errors.New("db down")}), "/payments/evt_001")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestListPaymentsHandler_FiltersAndCursor(t *testing.T) {
+	last := &domain.Payment{ID: "evt_002", OccurredAt: time.Date(2024, 4, 2, 0, 0, 0, 0, time.UTC)}
+	q := &fakePaymentQuerier{page: &usecase.PaymentPage{
+		Payments: []*domain.Payment{last},
+		Next:     &repository.PaymentCursor{OccurredAt: last.OccurredAt, ID: last.ID},
+	}}
+	router := newQueryRouter(q)
+
+	w := serveQuery(router, "/payments?status=paid&currency=USD&from=2024-04-01T00:00:00Z&to=1712102400&limit=1")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "paid", q.filter.Status)
+	assert.Equal(t, "USD", q.filter.Currency)
+	assert.Equal(t, time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), q.filter.From)
+	assert.Equal(t, time.Date(2024, 4, 3, 0, 0, 0, 0, time.UTC), q.filter.To)
+	assert.Equal(t, 1, q.filter.Limit)
+	assert.Nil(t, q.filter.After)
+
+	var resp handler.PaymentListResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Payments, 1)
+	require.NotEmpty(t, resp.NextCursor)
+
+	w = serveQuery(router, "/payments?cursor="+resp.NextCursor)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NotNil(t, q.filter.After)
+	assert.Equal(t, "evt_002", q.filter.After.ID)
+	assert.True(t, last.OccurredAt.Equal(q.filter.After.OccurredAt))
+}
+
+func TestListPaymentsHandler_InvalidQuery(t *testing.T) {
+	router := newQueryRouter(&fakePaymentQuerier{page: &usecase.PaymentPage{}})
+	for _, path := range []string{
+		"/payments?from=yesterday",
+		"/payments?to=nope",
+		"/payments?limit=0",
+		"/payments?cursor=!!!",
+		"/payments?cursor=e30", // {}
+	} {
+		w := serveQuery(router, path)
+		assert.Equal(t, http.StatusBadRequest, w.Code, path)
+	}
+}
+
+func TestGetOutboxEventHandler(t *testing.T) {
+	sentAt := time.Date(2024, 4, 1, 12, 0, 5, 0, time.UTC)
+	id := uuid.New()
+	router := newQueryRouter(&fakePaymentQuerier{event: &domain.OutboxEvent{
+		ID: id, AggregateID: "evt_001", EventType: "payment_event", Codec: "json",
+		Status: domain.StatusSent, SentAt: &sentAt,
+	}})
+
+	w := serveQuery(router, "/outbox/"+id.String())
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp handler.OutboxEventResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "sent", resp.Status)
+	assert.Equal(t, "json", resp.Codec)
+	require.NotNil(t, resp.SentAt)
+	assert.True(t, sentAt.Equal(*resp.SentAt))
+
+	w = serveQuery(router, "/outbox/not-a-uuid")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
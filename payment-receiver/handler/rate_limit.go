@@ -0,0 +1,90 @@
+// Package handler provides HTTP handler functions.
+package handler
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"payment-receiver/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitRule limits requests that share the key returned by Key. Requests
+// for which Key returns "" are not counted against the rule.
+type RateLimitRule struct {
+	Name  string
+	Limit usecase.RateLimit
+	Key   func(c *gin.Context) string
+}
+
+// ByClientIP keys requests by client IP. X-Forwarded-For is only honored
+// when the connection comes from one of the engine's trusted proxies.
+func ByClientIP(c *gin.Context) string {
+	return c.ClientIP()
+}
+
+// ByHeader keys requests by the value of header, typically the provider
+// identifier or API key.
+func ByHeader(header string) func(c *gin.Context) string {
+	return func(c *gin.Context) string {
+		return c.GetHeader(header)
+	}
+}
+
+// ByKnownProvider keys requests like ByProvider, but only for providers
+// identified by a client certificate or accepted by known. Any other request
+// is keyed by client IP, so rotating the provider header does not yield
+// fresh buckets.
+func ByKnownProvider(header string, known func(provider string) bool) func(c *gin.Context) string {
+	return func(c *gin.Context) string {
+		if p := CertProvider(c); p != "" {
+			return p
+		}
+		if p := c.GetHeader(header); p != "" && known != nil && known(p) {
+			return p
+		}
+		return "ip:" + c.ClientIP()
+	}
+}
+
+// RateLimit returns a middleware that takes a token from each rule's bucket,
+// in order, and answers 429 with Retry-After once any of them is empty. Limiter errors
+// are logged and the request is let through.
+func RateLimit(limiter usecase.RateLimiter, rules ...RateLimitRule) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, rule := range rules {
+			if !rule.Limit.Enabled() {
+				continue
+			}
+			key := rule.Key(c)
+			if key == "" {
+				continue
+			}
+
+			allowed, retryAfter, err := limiter.Allow(c.Request.Context(), rule.Name+":"+key, rule.Limit)
+			if err != nil {
+				log.Printf("rate limiter unavailable, allowing request: %v", err)
+				continue
+			}
+			if !allowed {
+				c.Header("Retry-After", strconv.Itoa(retryAfterSeconds(retryAfter)))
+				c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// retryAfterSeconds rounds up to whole seconds, as Retry-After requires.
+func retryAfterSeconds(d time.Duration) int {
+	s := int(math.Ceil(d.Seconds()))
+	if s < 1 {
+		return 1
+	}
+	return s
+}
@@ -0,0 +1,178 @@
+package handler_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"payment-receiver/handler"
+	"payment-receiver/usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingLimiter allows the first limit.Burst calls per key and then
+// rejects with a one and a half second retry.
+type countingLimiter struct {
+	calls map[string]int
+	err   error
+}
+
+func (l *countingLimiter) Allow(_ context.Context, key string, limit usecase.RateLimit) (bool, time.Duration, error) {
+	if l.err != nil {
+		return false, 0, l.err
+	}
+	if l.calls == nil {
+		l.calls = map[string]int{}
+	}
+	l.calls[key]++
+	if l.calls[key] > limit.Burst {
+		return false, 1500 * time.Millisecond, nil
+	}
+	return true, 0, nil
+}
+
+func newRateLimitedRouter(t *testing.T, limiter usecase.RateLimiter, trusted []string, rules ...handler.RateLimitRule) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	require.NoError(t, router.SetTrustedProxies(trusted))
+	router.POST("/webhook", handler.RateLimit(limiter, rules...), func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+	return router
+}
+
+func postWebhook(router *gin.Engine, remoteAddr string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/webhook", nil)
+	req.RemoteAddr = remoteAddr
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimit_RejectsWithRetryAfter(t *testing.T) {
+	limiter := &countingLimiter{}
+	router := newRateLimitedRouter(t, limiter, nil, handler.RateLimitRule{
+		Name:  "ip",
+		Limit: usecase.RateLimit{Rate: 1, Burst: 2},
+		Key:   handler.ByClientIP,
+	})
+
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, http.StatusCreated, postWebhook(router, "203.0.113.7:1000", nil).Code)
+	}
+	w := postWebhook(router, "203.0.113.7:1000", nil)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+	assert.JSONEq(t, `{"error":"rate limit exceeded"}`, w.Body.String())
+
+	// Another client has its own bucket.
+	assert.Equal(t, http.StatusCreated, postWebhook(router, "198.51.100.9:1000", nil).Code)
+}
+
+func TestRateLimit_PerProviderHeader(t *testing.T) {
+	limiter := &countingLimiter{}
+	router := newRateLimitedRouter(t, limiter, nil, handler.RateLimitRule{
+		Name:  "provider",
+		Limit: usecase.RateLimit{Rate: 1, Burst: 1},
+		Key:   handler.ByHeader("X-Provider-Id"),
+	})
+
+	acme := map[string]string{"X-Provider-Id": "acme"}
+	assert.Equal(t, http.StatusCreated, postWebhook(router, "203.0.113.7:1000", acme).Code)
+	assert.Equal(t, http.StatusTooManyRequests, postWebhook(router, "203.0.113.8:1000", acme).Code)
+	assert.Equal(t, http.StatusCreated, postWebhook(router, "203.0.113.7:1000", map[string]string{"X-Provider-Id": "globex"}).Code)
+
+	// Requests without the header are not counted against the rule.
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusCreated, postWebhook(router, "203.0.113.7:1000", nil).Code)
+	}
+	assert.Equal(t, map[string]int{"provider:acme": 2, "provider:globex": 1}, limiter.calls)
+}
+
+func TestRateLimit_ForwardedForOnlyFromTrustedProxies(t *testing.T) {
+	rule := handler.RateLimitRule{
+		Name:  "ip",
+		Limit: usecase.RateLimit{Rate: 1, Burst: 5},
+		Key:   handler.ByClientIP,
+	}
+	xff := map[string]string{"X-Forwarded-For": "192.0.2.44"}
+
+	limiter := &countingLimiter{}
+	router := newRateLimitedRouter(t, limiter, []string{"10.0.0.0/8"}, rule)
+	postWebhook(router, "10.1.2.3:1000", xff)
+	postWebhook(router, "203.0.113.7:1000", xff)
+	assert.Equal(t, map[string]int{"ip:192.0.2.44": 1, "ip:203.0.113.7": 1}, limiter.calls)
+
+	limiter = &countingLimiter{}
+	router = newRateLimitedRouter(t, limiter, nil, rule)
+	postWebhook(router, "10.1.2.3:1000", xff)
+	assert.Equal(t, map[string]int{"ip:10.1.2.3": 1}, limiter.calls)
+}
+
+func TestRateLimit_FailsOpenOnLimiterError(t *testing.T) {
+	router := newRateLimitedRouter(t, &countingLimiter{err: errors.New("redis down")}, nil, handler.RateLimitRule{
+		Name:  "ip",
+		Limit: usecase.RateLimit{Rate: 1, Burst: 1},
+		Key:   handler.ByClientIP,
+	})
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusCreated, postWebhook(router, "203.0.113.7:1000", nil).Code)
+	}
+}
+
+func TestRateLimit_DisabledRuleIsSkipped(t *testing.T) {
+	limiter := &countingLimiter{}
+	router := newRateLimitedRouter(t, limiter, nil, handler.RateLimitRule{
+		Name: "ip",
+		Key:  handler.ByClientIP,
+	})
+
+	assert.Equal(t, http.StatusCreated, postWebhook(router, "203.0.113.7:1000", nil).Code)
+	assert.Empty(t, limiter.calls)
+}
+
+func TestRateLimit_IPBeforeKnownProvider(t *testing.T) {
+	known := func(provider string) bool { return provider == "acme" }
+	limiter := &countingLimiter{}
+	router := newRateLimitedRouter(t, limiter, nil,
+		handler.RateLimitRule{Name: "ip", Limit: usecase.RateLimit{Rate: 1, Burst: 1}, Key: handler.ByClientIP},
+		handler.RateLimitRule{Name: "provider", Limit: usecase.RateLimit{Rate: 1, Burst: 5}, Key: handler.ByKnownProvider("X-Provider-Id", known)},
+	)
+
+	acme := map[string]string{"X-Provider-Id": "acme"}
+	assert.Equal(t, http.StatusCreated, postWebhook(router, "203.0.113.7:1000", acme).Code)
+	assert.Equal(t, http.StatusTooManyRequests, postWebhook(router, "203.0.113.7:1000", acme).Code)
+
+	// The request rejected by the IP limit did not spend a provider token.
+	assert.Equal(t, map[string]int{"ip:203.0.113.7": 2, "provider:acme": 1}, limiter.calls)
+}
+
+func TestRateLimit_UnknownProvidersFallBackToClientIP(t *testing.T) {
+	known := func(provider string) bool { return provider == "acme" }
+	limiter := &countingLimiter{}
+	router := newRateLimitedRouter(t, limiter, nil, handler.RateLimitRule{
+		Name:  "provider",
+		Limit: usecase.RateLimit{Rate: 1, Burst: 1},
+		Key:   handler.ByKnownProvider("X-Provider-Id", known),
+	})
+
+	// Rotating an unknown provider header does not yield fresh buckets.
+	assert.Equal(t, http.StatusCreated, postWebhook(router, "203.0.113.7:1000", map[string]string{"X-Provider-Id": "x1"}).Code)
+	assert.Equal(t, http.StatusTooManyRequests, postWebhook(router, "203.0.113.7:1000", map[string]string{"X-Provider-Id": "x2"}).Code)
+	assert.Equal(t, http.StatusTooManyRequests, postWebhook(router, "203.0.113.7:1000", nil).Code)
+
+	// A known provider has its own bucket.
+	assert.Equal(t, http.StatusCreated, postWebhook(router, "203.0.113.7:1000", map[string]string{"X-Provider-Id": "acme"}).Code)
+	assert.Equal(t, map[string]int{"provider:ip:203.0.113.7": 3, "provider:acme": 1}, limiter.calls)
+}
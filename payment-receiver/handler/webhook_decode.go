@@ -0,0 +1,243 @@
+// Package handler provides HTTP handler functions.
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Error codes returned in the "code" field of rejected webhook requests.
+const (
+	CodeBodyTooLarge  = "body_too_large"
+	CodeMalformedJSON = "malformed_json"
+	CodeDuplicateKey  = "duplicate_key"
+	CodeUnknownField  = "unknown_field"
+	CodeInvalidType   = "invalid_type"
+	CodeMissingField  = "missing_field"
+	CodeInvalidValue  = "invalid_value"
+)
+
+// DefaultMaxBodyBytes is the webhook body limit used when none is configured.
+const DefaultMaxBodyBytes = 1 << 20
+
+// strictFieldsKey is the gin context key through which StrictFields asks
+// WebhookHandler to reject unknown fields.
+const strictFieldsKey = "strict_fields"
+
+// DecodeOptions controls how strictly a webhook body is decoded. Duplicated
+// keys, trailing data and type mismatches are always rejected.
+type DecodeOptions struct {
+	DisallowUnknownFields bool
+}
+
+// PayloadError describes why a webhook body was rejected. It matches
+// ErrInvalidPayload with errors.Is.
+type PayloadError struct {
+	Code  string
+	Field string
+	Err   error
+}
+
+func (e *PayloadError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrInvalidPayload, e.Err)
+}
+
+func (e *PayloadError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is ErrInvalidPayload.
+func (e *PayloadError) Is(target error) bool {
+	return target == ErrInvalidPayload
+}
+
+// MaxBodySize returns a middleware that limits request bodies to limit bytes.
+// Reading past the limit fails with *http.MaxBytesError, which
+// WebhookHandler answers with 413.
+func MaxBodySize(limit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Body != nil {
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		}
+		c.Next()
+	}
+}
+
+// StrictFields returns a middleware that makes WebhookHandler reject unknown
+// fields for the providers listed. "*" applies to every provider.
+func StrictFields(providerKey func(c *gin.Context) string, providers []string) gin.HandlerFunc {
+	strict := make(map[string]struct{}, len(providers))
+	for _, p := range providers {
+		strict[p] = struct{}{}
+	}
+	_, all := strict["*"]
+
+	return func(c *gin.Context) {
+		if _, ok := strict[providerKey(c)]; ok || all {
+			c.Set(strictFieldsKey, true)
+		}
+		c.Next()
+	}
+}
+
+// decodeWebhookRequest strictly decodes and validates a webhook body.
+func decodeWebhookRequest(body []byte, opts DecodeOptions) (*WebhookRequest, error) {
+	if err := checkJSON(body); err != nil {
+		return nil, err
+	}
+
+	var req WebhookRequest
+	dec := json.NewDecoder(bytes.NewReader(body))
+	if opts.DisallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(&req); err != nil {
+		return nil, decodeError(err)
+	}
+
+	if req.Amount > math.MaxInt32 || req.Amount < math.MinInt32 {
+		return nil, &PayloadError{Code: CodeInvalidType, Field: "amount", Err: errors.New("amount is out of range")}
+	}
+
+	if field := missingField(&req); field != "" {
+		return nil, &PayloadError{Code: CodeMissingField, Field: field, Err: fmt.Errorf("%s is required", field)}
+	}
+	return &req, nil
+}
+
+// decodeError classifies an encoding/json decoding error.
+func decodeError(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		field := typeErr.Field
+		if field == "" {
+			field = "value"
+		}
+		return &PayloadError{
+			Code:  CodeInvalidType,
+			Field: typeErr.Field,
+			Err:   fmt.Errorf("%s must be %s, got %s", field, typeName(typeErr.Type), typeErr.Value),
+		}
+	}
+	if name, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		field := strings.Trim(name, `"`)
+		return &PayloadError{Code: CodeUnknownField, Field: field, Err: fmt.Errorf("unknown field %q", field)}
+	}
+	return &PayloadError{Code: CodeMalformedJSON, Err: err}
+}
+
+// checkJSON rejects bodies that are not exactly one JSON object or that
+// repeat a key within an object, which encoding/json would silently resolve
+// to the last value.
+func checkJSON(body []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+
+	tok, err := dec.Token()
+	if err != nil {
+		return &PayloadError{Code: CodeMalformedJSON, Err: syntaxError(err)}
+	}
+	if tok != json.Delim('{') {
+		return &PayloadError{Code: CodeMalformedJSON, Err: errors.New("body must be a JSON object")}
+	}
+	if err := checkObject(dec, ""); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return &PayloadError{Code: CodeMalformedJSON, Err: errors.New("unexpected data after JSON object")}
+	}
+	return nil
+}
+
+// checkObject consumes the members of an object whose opening brace has been
+// read, recursing into nested values.
+func checkObject(dec *json.Decoder, path string) error {
+	seen := map[string]struct{}{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return &PayloadError{Code: CodeMalformedJSON, Err: syntaxError(err)}
+		}
+		key := tok.(string)
+		field := key
+		if path != "" {
+			field = path + "." + key
+		}
+		if _, dup := seen[key]; dup {
+			return &PayloadError{Code: CodeDuplicateKey, Field: field, Err: fmt.Errorf("duplicate key %q", field)}
+		}
+		seen[key] = struct{}{}
+
+		if err := checkValue(dec, field); err != nil {
+			return err
+		}
+	}
+	return closeDelim(dec)
+}
+
+func checkValue(dec *json.Decoder, path string) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return &PayloadError{Code: CodeMalformedJSON, Err: syntaxError(err)}
+	}
+	switch tok {
+	case json.Delim('{'):
+		return checkObject(dec, path)
+	case json.Delim('['):
+		for i := 0; dec.More(); i++ {
+			if err := checkValue(dec, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		return closeDelim(dec)
+	}
+	return nil
+}
+
+func closeDelim(dec *json.Decoder) error {
+	if _, err := dec.Token(); err != nil {
+		return &PayloadError{Code: CodeMalformedJSON, Err: syntaxError(err)}
+	}
+	return nil
+}
+
+// syntaxError reports a truncated body as io.ErrUnexpectedEOF rather than io.EOF.
+func syntaxError(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// missingField returns the JSON name of the first `binding:"required"` field
+// of req left at its zero value, or "".
+func missingField(req *WebhookRequest) string {
+	v := reflect.ValueOf(req).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		if f.Tag.Get("binding") == "required" && v.Field(i).IsZero() {
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			return name
+		}
+	}
+	return ""
+}
+
+func typeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return "an integer"
+	case reflect.String:
+		return "a string"
+	}
+	return t.String()
+}
@@ -0,0 +1,197 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"payment-receiver/handler"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const validWebhookBody = `{"id":"evt_001","amount":1200,"currency":"USD","method":"card","status":"paid","occurred_at":"2024-04-01T12:00:00Z"}`
+
+func TestParseWebhookRequest_Rejections(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		strict bool
+		code   string
+		field  string
+	}{
+		{"malformed", `{"id":`, false, handler.CodeMalformedJSON, ""},
+		{"not an object", `["evt_001"]`, false, handler.CodeMalformedJSON, ""},
+		{"trailing data", validWebhookBody + `{}`, false, handler.CodeMalformedJSON, ""},
+		{"duplicate key", `{"id":"evt_001","amount":1200,"amount":1,"currency":"USD","method":"card","status":"paid","occurred_at":1711972800}`, false, handler.CodeDuplicateKey, "amount"},
+		{"nested duplicate key", `{"id":"evt_001","meta":{"a":1,"a":2}}`, false, handler.CodeDuplicateKey, "meta.a"},
+		{"fractional amount", `{"id":"evt_001","amount":12.5,"currency":"USD","method":"card","status":"paid","occurred_at":1711972800}`, false, handler.CodeInvalidType, "amount"},
+		{"amount as string", `{"id":"evt_001","amount":"1200","currency":"USD","method":"card","status":"paid","occurred_at":1711972800}`, false, handler.CodeInvalidType, "amount"},
+		{"amount out of range", `{"id":"evt_001","amount":3000000000,"currency":"USD","method":"card","status":"paid","occurred_at":1711972800}`, false, handler.CodeInvalidType, "amount"},
+		{"missing field", `{"id":"evt_001","amount":1200}`, false, handler.CodeMissingField, "currency"},
+		{"unknown field when strict", `{"id":"evt_001","amount":1200,"currency":"USD","method":"card","status":"paid","occurred_at":1711972800,"fee":3}`, true, handler.CodeUnknownField, "fee"},
+		{"invalid status", `{"id":"evt_001","amount":1200,"currency":"USD","method":"card","status":"unknown","occurred_at":1711972800}`, false, handler.CodeInvalidValue, ""},
+		{"invalid occurred_at", `{"id":"evt_001","amount":1200,"currency":"USD","method":"card","status":"paid","occurred_at":"soon"}`, false, handler.CodeInvalidValue, "occurred_at"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := handler.ParseWebhookRequest([]byte(tt.body), handler.DecodeOptions{DisallowUnknownFields: tt.strict})
+			require.Error(t, err)
+			assert.ErrorIs(t, err, handler.ErrInvalidPayload)
+
+			var perr *handler.PayloadError
+			require.True(t, errors.As(err, &perr))
+			assert.Equal(t, tt.code, perr.Code)
+			assert.Equal(t, tt.field, perr.Field)
+		})
+	}
+}
+
+func TestParseWebhookRequest_UnknownFieldsAllowedByDefault(t *testing.T) {
+	body := strings.Replace(validWebhookBody, `"id"`, `"fee":3,"id"`, 1)
+	sub, err := handler.ParseWebhookRequest([]byte(body), handler.DecodeOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 1200, sub.Payment.Amount)
+}
+
+func newStrictWebhookRouter(maxBody int64, strict ...string) (*gin.Engine, *mockPaymentRecorder) {
+	gin.SetMode(gin.TestMode)
+	mock := &mockPaymentRecorder{}
+	router := gin.New()
+	router.POST("/webhook",
+		handler.MaxBodySize(maxBody),
+		handler.StrictFields(handler.ByHeader("X-Provider-Id"), strict),
+		handler.WebhookHandler(mock),
+	)
+	return router, mock
+}
+
+func sendWebhook(router *gin.Engine, provider, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Provider-Id", provider)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestWebhookHandler_BodyTooLarge(t *testing.T) {
+	router, mock := newStrictWebhookRouter(64)
+
+	w := sendWebhook(router, "acme", validWebhookBody)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.JSONEq(t, `{"error":"body exceeds 64 bytes","code":"body_too_large"}`, w.Body.String())
+	assert.False(t, mock.called)
+}
+
+func TestWebhookHandler_BodyTooLargeIsAudited(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	deliveries := &fakeDeliveryRecorder{}
+	router := gin.New()
+	router.POST("/webhook",
+		handler.MaxBodySize(64),
+		handler.WebhookDeliveryAudit(deliveries, nil),
+		handler.WebhookHandler(&mockPaymentRecorder{}),
+	)
+
+	w := sendWebhook(router, "acme", validWebhookBody)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	require.Len(t, deliveries.deliveries, 1)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, deliveries.deliveries[0].StatusCode)
+	assert.Len(t, deliveries.deliveries[0].Body, 64)
+}
+
+func TestWebhookHandler_StrictProviders(t *testing.T) {
+	body := strings.Replace(validWebhookBody, `"id"`, `"fee":3,"id"`, 1)
+	router, _ := newStrictWebhookRouter(handler.DefaultMaxBodyBytes, "acme")
+
+	w := sendWebhook(router, "acme", body)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var resp map[string]string
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, handler.CodeUnknownField, resp["code"])
+	assert.Equal(t, "fee", resp["field"])
+
+	assert.Equal(t, http.StatusCreated, sendWebhook(router, "globex", body).Code)
+
+	all, _ := newStrictWebhookRouter(handler.DefaultMaxBodyBytes, "*")
+	assert.Equal(t, http.StatusBadRequest, sendWebhook(all, "globex", body).Code)
+}
+
+func TestWebhookHandler_DuplicateKeyCode(t *testing.T) {
+	router, mock := newStrictWebhookRouter(handler.DefaultMaxBodyBytes)
+
+	w := sendWebhook(router, "acme", strings.Replace(validWebhookBody, `"amount":1200`, `"amount":1200,"amount":1`, 1))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"duplicate_key"`)
+	assert.False(t, mock.called)
+}
+
+func FuzzParseWebhookRequest(f *testing.F) {
+	f.Add([]byte(validWebhookBody), false)
+	f.Add([]byte(`{"id":"evt_001","amount":1200,"amount":1}`), false)
+	f.Add([]byte(`{"id":"evt_001","amount":12.5,"currency":"USD","method":"card","status":"paid","occurred_at":1711972800}`), false)
+	f.Add([]byte(`{"id":"evt_001","extra":{"a":[1,{"b":2}]}}`), true)
+	f.Add([]byte(`{"occurred_at":1e400}`), false)
+	f.Add([]byte(`[]`), false)
+	f.Add([]byte{}, false)
+
+	f.Fuzz(func(t *testing.T, body []byte, strict bool) {
+		sub, err := handler.ParseWebhookRequest(body, handler.DecodeOptions{DisallowUnknownFields: strict})
+		if err != nil {
+			var perr *handler.PayloadError
+			if !errors.As(err, &perr) || perr.Code == "" {
+				t.Fatalf("error is not a coded PayloadError: %v", err)
+			}
+			return
+		}
+
+		// Anything accepted must be a valid JSON object carrying every required field.
+		if !json.Valid(body) {
+			t.Fatalf("accepted invalid JSON %q", body)
+		}
+		if sub.Payment.ID == "" || sub.Payment.Amount <= 0 || sub.Event == nil {
+			t.Fatalf("accepted incomplete payment %+v", sub.Payment)
+		}
+		if int(sub.PaymentEvent.Amount) != sub.Payment.Amount {
+			t.Fatalf("amount %d truncated to %d", sub.Payment.Amount, sub.PaymentEvent.Amount)
+		}
+	})
+}
+
+func FuzzWebhookHandler(f *testing.F) {
+	f.Add(validWebhookBody)
+	f.Add(`{"id":"evt_001","amount":1200,"amount":1}`)
+	f.Add(strings.Repeat(" ", 300) + validWebhookBody)
+	f.Add(`{"id":`)
+
+	f.Fuzz(func(t *testing.T, body string) {
+		router, mock := newStrictWebhookRouter(256, "*")
+		w := sendWebhook(router, "acme", body)
+
+		switch w.Code {
+		case http.StatusCreated:
+			if !mock.called {
+				t.Fatal("201 without recording the payment")
+			}
+		case http.StatusBadRequest, http.StatusRequestEntityTooLarge:
+			if mock.called {
+				t.Fatalf("%d after recording the payment", w.Code)
+			}
+			var resp map[string]any
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp["code"] == "" {
+				t.Fatalf("error response without code: %s", w.Body.String())
+			}
+		default:
+			t.Fatalf("unexpected status %d", w.Code)
+		}
+	})
+}
@@ -0,0 +1,116 @@
+// Package handler provides HTTP handler functions.
+package handler
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"payment-receiver/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Gin context keys through which handlers report to WebhookDeliveryAudit.
+const (
+	signatureStatusKey = "signature_status"
+	outboxEventIDKey   = "outbox_event_id"
+)
+
+// auditTimeout bounds how long recording a delivery may delay the response.
+const auditTimeout = 5 * time.Second
+
+// DefaultRedactedHeaders are never stored in the delivery audit log.
+var DefaultRedactedHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	APIKeyHeader,
+}
+
+// WebhookDeliveryRecorder persists raw webhook deliveries.
+type WebhookDeliveryRecorder interface {
+	Record(ctx context.Context, delivery *domain.WebhookDelivery) error
+}
+
+// SetSignatureStatus reports the signature verification outcome of the
+// current request to WebhookDeliveryAudit.
+func SetSignatureStatus(c *gin.Context, status string) {
+	c.Set(signatureStatusKey, status)
+}
+
+// WebhookDeliveryAudit returns a middleware that records every request it
+// wraps: headers except redacted ones (DefaultRedactedHeaders when redacted
+// is nil), the raw body, source IP, signature outcome, response code and the
+// outbox event it produced. Failing to record is logged and does not change
+// the response.
+func WebhookDeliveryAudit(recorder WebhookDeliveryRecorder, redacted []string) gin.HandlerFunc {
+	if redacted == nil {
+		redacted = DefaultRedactedHeaders
+	}
+	skip := make(map[string]struct{}, len(redacted))
+	for _, h := range redacted {
+		skip[http.CanonicalHeaderKey(h)] = struct{}{}
+	}
+
+	return func(c *gin.Context) {
+		receivedAt := time.Now().UTC()
+
+		var body []byte
+		if c.Request.Body != nil {
+			var err error
+			body, err = io.ReadAll(c.Request.Body)
+			var rest io.Reader = bytes.NewReader(body)
+			if err != nil {
+				// Hand the read error (e.g. *http.MaxBytesError) on to the handler
+				rest = io.MultiReader(rest, errReader{err})
+			}
+			c.Request.Body = io.NopCloser(rest)
+		}
+
+		c.Next()
+
+		headers := make(map[string][]string, len(c.Request.Header))
+		for name, values := range c.Request.Header {
+			if _, ok := skip[http.CanonicalHeaderKey(name)]; ok {
+				continue
+			}
+			headers[name] = values
+		}
+
+		delivery := &domain.WebhookDelivery{
+			ID:              uuid.New(),
+			ReceivedAt:      receivedAt,
+			Method:          c.Request.Method,
+			Path:            c.Request.URL.Path,
+			SourceIP:        c.ClientIP(),
+			Headers:         headers,
+			Body:            body,
+			SignatureStatus: c.GetString(signatureStatusKey),
+			StatusCode:      c.Writer.Status(),
+		}
+		if delivery.SignatureStatus == "" {
+			delivery.SignatureStatus = domain.SignatureNotConfigured
+		}
+		if v, ok := c.Get(outboxEventIDKey); ok {
+			if id, ok := v.(uuid.UUID); ok {
+				delivery.OutboxEventID = &id
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), auditTimeout)
+		defer cancel()
+		if err := recorder.Record(ctx, delivery); err != nil {
+			log.Printf("failed to record webhook delivery: %v", err)
+		}
+	}
+}
+
+// errReader fails every read with err.
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) { return 0, r.err }
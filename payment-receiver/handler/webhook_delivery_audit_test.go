@@ -0,0 +1,130 @@
+package handler_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"payment-receiver/domain"
+	"payment-receiver/handler"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeDeliveryRecorder struct {
+	deliveries []*domain.WebhookDelivery
+	err        error
+}
+
+func (f *fakeDeliveryRecorder) Record(_ context.Context, d *domain.WebhookDelivery) error {
+	f.deliveries = append(f.deliveries, d)
+	return f.err
+}
+
+const auditedBody = `{"id":"evt_001","amount":1200,"currency":"USD","method":"card","status":"paid","occurred_at":"2024-04-01T12:00:00Z"}`
+
+func TestWebhookDeliveryAudit_RecordsAcceptedRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	deliveries := &fakeDeliveryRecorder{}
+	mock := &mockPaymentRecorder{}
+	router := gin.New()
+	router.POST("/webhook",
+		handler.WebhookDeliveryAudit(deliveries, nil),
+		handler.WebhookHandler(mock),
+	)
+
+	req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(auditedBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer provider-secret")
+	req.Header.Set("X-Provider-Signature", "t=1,v1=abc")
+	req.RemoteAddr = "203.0.113.7:51234"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.Len(t, deliveries.deliveries, 1)
+	d := deliveries.deliveries[0]
+	assert.Equal(t, auditedBody, string(d.Body), "raw body is stored verbatim")
+	assert.Equal(t, "203.0.113.7", d.SourceIP)
+	assert.Equal(t, http.StatusCreated, d.StatusCode)
+	assert.Equal(t, domain.SignatureNotConfigured, d.SignatureStatus)
+	assert.Equal(t, []string{"t=1,v1=abc"}, d.Headers["X-Provider-Signature"])
+	assert.NotContains(t, d.Headers, "Authorization")
+	require.NotNil(t, d.OutboxEventID)
+	assert.Equal(t, mock.event.ID, *d.OutboxEventID)
+}
+
+func TestWebhookDeliveryAudit_RecordsAsyncAcceptedRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	deliveries := &fakeDeliveryRecorder{}
+	mock := &mockPaymentRecorder{}
+	router := gin.New()
+	router.POST("/webhook",
+		handler.WebhookDeliveryAudit(deliveries, nil),
+		handler.AsyncWebhookHandler(mock),
+	)
+
+	req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(auditedBody))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusAccepted, w.Code)
+	require.Len(t, deliveries.deliveries, 1)
+	d := deliveries.deliveries[0]
+	assert.Equal(t, http.StatusAccepted, d.StatusCode)
+	require.NotNil(t, d.OutboxEventID)
+	assert.Equal(t, mock.event.ID, *d.OutboxEventID)
+}
+
+func TestWebhookDeliveryAudit_RecordsRejectedRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	deliveries := &fakeDeliveryRecorder{}
+	router := gin.New()
+	router.POST("/webhook",
+		handler.WebhookDeliveryAudit(deliveries, []string{"X-Extra-Secret"}),
+		func(c *gin.Context) {
+			handler.SetSignatureStatus(c, domain.SignatureInvalid)
+			c.Next()
+		},
+		handler.WebhookHandler(&mockPaymentRecorder{}),
+	)
+
+	req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(`{"id":`))
+	req.Header.Set("X-Extra-Secret", "hunter2")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Len(t, deliveries.deliveries, 1)
+	d := deliveries.deliveries[0]
+	assert.Equal(t, `{"id":`, string(d.Body))
+	assert.Equal(t, http.StatusBadRequest, d.StatusCode)
+	assert.Equal(t, domain.SignatureInvalid, d.SignatureStatus)
+	assert.NotContains(t, d.Headers, "X-Extra-Secret")
+	assert.Nil(t, d.OutboxEventID)
+}
+
+func TestWebhookDeliveryAudit_RecorderFailureDoesNotChangeResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/webhook",
+		handler.WebhookDeliveryAudit(&fakeDeliveryRecorder{err: errors.New("db down")}, nil),
+		func(c *gin.Context) {
+			body, _ := io.ReadAll(c.Request.Body)
+			c.String(http.StatusOK, string(body))
+		},
+	)
+
+	req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader("ping"))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "ping", w.Body.String())
+}
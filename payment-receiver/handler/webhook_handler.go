@@ -2,7 +2,10 @@
 package handler
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
 
@@ -15,43 +18,103 @@ import (
 
 // WebhookRequest represents the incoming webhook payload (DTO)
 type WebhookRequest struct {
-	ID         string `json:"id"          binding:"required"`
-	Amount     int    `json:"amount"      binding:"required"`
-	Currency   string `json:"currency"    binding:"required"`
-	Method     string `json:"method"      binding:"required"`
-	Status     string `json:"status"      binding:"required"`
-	OccurredAt string `json:"occurred_at" binding:"required"`
+	ID         string     `json:"id"          binding:"required"`
+	Amount     int        `json:"amount"      binding:"required"`
+	Currency   string     `json:"currency"    binding:"required"`
+	Method     string     `json:"method"      binding:"required"`
+	Status     string     `json:"status"      binding:"required"`
+	OccurredAt OccurredAt `json:"occurred_at" binding:"required"`
 }
 
-// WebhookHandler returns a gin.HandlerFunc with injected usecase.
-func WebhookHandler(enqueuer usecase.OutboxEventSaver) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		var req WebhookRequest
+// OccurredAt holds the raw occurred_at value, which providers send either as
+// an RFC3339 string or as a Unix epoch number.
+type OccurredAt string
 
-		if err := c.ShouldBindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
-			return
+// UnmarshalJSON accepts both JSON strings and JSON numbers.
+func (o *OccurredAt) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(data, []byte(`"`)) {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
 		}
+		*o = OccurredAt(s)
+		return nil
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	*o = OccurredAt(n.String())
+	return nil
+}
+
+// ErrInvalidPayload is returned by ParseWebhookRequest when the body is not a
+// well-formed webhook request.
+var ErrInvalidPayload = errors.New("invalid payload")
+
+// WebhookSubmission is what a valid webhook request asks the receiver to store.
+type WebhookSubmission struct {
+	Payment      *domain.Payment
+	Event        *domain.OutboxEvent
+	PaymentEvent *proto.PaymentEvent
+}
+
+// ParseWebhookRequest strictly decodes and validates a raw webhook body and
+// builds the payment state and outbox event it describes; failures are
+// *PayloadError values. WebhookHandler and the replay tool share it so that
+// stored requests are judged by the current rules.
+func ParseWebhookRequest(body []byte, opts DecodeOptions) (*WebhookSubmission, error) {
+	req, err := decodeWebhookRequest(body, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	occurredAt, err := domain.ParseOccurredAt(string(req.OccurredAt))
+	if err != nil {
+		return nil, &PayloadError{Code: CodeInvalidValue, Field: "occurred_at", Err: err}
+	}
 
-		// Construct a protobuf PaymentEvent
-		paymentEvent := &proto.PaymentEvent{
-			Id:         req.ID,
-			Amount:     int32(req.Amount),
+	// Construct a protobuf PaymentEvent
+	paymentEvent := &proto.PaymentEvent{
+		Id:       req.ID,
+		Amount:   int32(req.Amount),
+		Currency: req.Currency,
+		Method:   req.Method,
+		Status:   req.Status,
+	}
+	domain.SetProtoOccurredAt(paymentEvent, occurredAt)
+
+	// Convert to OutboxEvent (with protobuf payload)
+	outboxEvent, err := domain.NewOutboxEventFromProtoPayment(paymentEvent)
+	if err != nil {
+		return nil, &PayloadError{Code: CodeInvalidValue, Err: err}
+	}
+
+	return &WebhookSubmission{
+		Payment: &domain.Payment{
+			ID:         req.ID,
+			Amount:     req.Amount,
 			Currency:   req.Currency,
 			Method:     req.Method,
 			Status:     req.Status,
-			OccurredAt: req.OccurredAt,
-		}
+			OccurredAt: occurredAt,
+		},
+		Event:        outboxEvent,
+		PaymentEvent: paymentEvent,
+	}, nil
+}
 
-		// Convert to OutboxEvent (with protobuf payload)
-		outboxEvent, err := domain.NewOutboxEventFromProtoPayment(paymentEvent)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+// WebhookHandler returns a gin.HandlerFunc with injected usecase.
+func WebhookHandler(recorder usecase.PaymentEventRecorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sub, ok := bindWebhook(c)
+		if !ok {
 			return
 		}
 
-		// Enqueue to outbox
-		if err := enqueuer.EnqueueOutboxEvent(c.Request.Context(), outboxEvent); err != nil {
+		// Store payment state and enqueue to outbox in one transaction
+		if err := recorder.RecordPayment(c.Request.Context(), sub.Payment, sub.Event); err != nil {
 			if errors.Is(err, usecase.ErrDuplicateEvent) {
 				c.JSON(http.StatusOK, gin.H{
 					"status": "duplicate",
@@ -64,10 +127,71 @@ func WebhookHandler(enqueuer usecase.OutboxEventSaver) gin.HandlerFunc {
 			return
 		}
 
+		c.Set(outboxEventIDKey, sub.Event.ID)
+
 		// Return success response with original payload
 		c.JSON(http.StatusCreated, gin.H{
 			"status":  "received",
-			"payload": paymentEvent,
+			"payload": sub.PaymentEvent,
 		})
 	}
 }
+
+// AsyncWebhookHandler answers 202 as soon as recorder (an
+// usecase.AsyncPaymentRecorder) has durably queued the event. Duplicates are
+// accepted too and dropped when the event is recorded.
+func AsyncWebhookHandler(recorder usecase.PaymentEventRecorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sub, ok := bindWebhook(c)
+		if !ok {
+			return
+		}
+
+		if err := recorder.RecordPayment(c.Request.Context(), sub.Payment, sub.Event); err != nil {
+			log.Printf("failed to queue event: %v", err)
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "failed to queue event"})
+			return
+		}
+
+		c.Set(outboxEventIDKey, sub.Event.ID)
+
+		c.JSON(http.StatusAccepted, gin.H{
+			"status":   "accepted",
+			"event_id": sub.Event.ID,
+			"payload":  sub.PaymentEvent,
+		})
+	}
+}
+
+// bindWebhook reads and parses the request body. On failure it writes the
+// error response and returns false.
+func bindWebhook(c *gin.Context) (*WebhookSubmission, bool) {
+	body, err := c.GetRawData()
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error": fmt.Sprintf("body exceeds %d bytes", tooLarge.Limit),
+				"code":  CodeBodyTooLarge,
+			})
+			return nil, false
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload", "code": CodeMalformedJSON})
+		return nil, false
+	}
+
+	sub, err := ParseWebhookRequest(body, DecodeOptions{DisallowUnknownFields: c.GetBool(strictFieldsKey)})
+	if err != nil {
+		resp := gin.H{"error": err.Error(), "code": CodeInvalidValue}
+		var perr *PayloadError
+		if errors.As(err, &perr) {
+			resp["code"] = perr.Code
+			if perr.Field != "" {
+				resp["field"] = perr.Field
+			}
+		}
+		c.JSON(http.StatusBadRequest, resp)
+		return nil, false
+	}
+	return sub, true
+}
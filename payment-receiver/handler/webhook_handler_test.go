@@ -9,6 +9,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"payment-receiver/domain"
 	"payment-receiver/handler"
@@ -16,19 +17,23 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
-type mockOutboxEnqueuer struct {
-	called bool
-	event  *domain.OutboxEvent
-	err    error
+type mockPaymentRecorder struct {
+	called  bool
+	payment *domain.Payment
+	event   *domain.OutboxEvent
+	err     error
 }
 
-func (m *mockOutboxEnqueuer) EnqueueOutboxEvent(
+func (m *mockPaymentRecorder) RecordPayment(
 	ctx context.Context,
+	payment *domain.Payment,
 	event *domain.OutboxEvent,
 ) error {
 	m.called = true
+	m.payment = payment
 	m.event = event
 	return m.err
 }
@@ -36,7 +41,7 @@ func (m *mockOutboxEnqueuer) EnqueueOutboxEvent(
 func TestWebhookHandler_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
-	mock := &mockOutboxEnqueuer{}
+	mock := &mockPaymentRecorder{}
 	router := gin.Default()
 	router.POST("/webhook", handler.WebhookHandler(mock))
 
@@ -60,11 +65,55 @@ func TestWebhookHandler_Success(t *testing.T) {
 	assert.True(t, mock.called)
 	assert.Equal(t, "evt_001", mock.event.AggregateID)
 	assert.Equal(t, "payment_event", mock.event.EventType)
+	assert.Equal(t, "evt_001", mock.payment.ID)
+	assert.Equal(t, "paid", mock.payment.Status)
+	assert.Equal(t, time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC), mock.payment.OccurredAt)
+}
+
+func TestWebhookHandler_OccurredAtFormats(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	want := time.Date(2024, 4, 1, 12, 0, 0, 500_000_000, time.UTC)
+	tests := []struct {
+		name       string
+		occurredAt interface{}
+	}{
+		{"RFC3339 with fractional seconds", "2024-04-01T21:00:00.5+09:00"},
+		{"epoch number", 1711972800.5},
+		{"epoch string", "1711972800.5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &mockPaymentRecorder{}
+			router := gin.Default()
+			router.POST("/webhook", handler.WebhookHandler(mock))
+
+			body := map[string]interface{}{
+				"id":          "evt_001",
+				"amount":      1200,
+				"currency":    "USD",
+				"method":      "card",
+				"status":      "paid",
+				"occurred_at": tt.occurredAt,
+			}
+			jsonBody, _ := json.Marshal(body)
+
+			req := httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewBuffer(jsonBody))
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusCreated, w.Code)
+			assert.Equal(t, want, mock.event.EventAt)
+		})
+	}
 }
 
 func TestWebhookHandler_InvalidJSON(t *testing.T) {
 	router := gin.Default()
-	router.POST("/webhook", handler.WebhookHandler(&mockOutboxEnqueuer{}))
+	router.POST("/webhook", handler.WebhookHandler(&mockPaymentRecorder{}))
 
 	req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader("{invalid json"))
 	req.Header.Set("Content-Type", "application/json")
@@ -78,7 +127,7 @@ func TestWebhookHandler_InvalidJSON(t *testing.T) {
 
 func TestWebhookHandler_MissingField(t *testing.T) {
 	router := gin.Default()
-	router.POST("/webhook", handler.WebhookHandler(&mockOutboxEnqueuer{}))
+	router.POST("/webhook", handler.WebhookHandler(&mockPaymentRecorder{}))
 
 	body := map[string]interface{}{
 		"id": "evt_001",
@@ -97,7 +146,7 @@ func TestWebhookHandler_MissingField(t *testing.T) {
 
 func TestWebhookHandler_InvalidOccurredAtFormat(t *testing.T) {
 	router := gin.Default()
-	router.POST("/webhook", handler.WebhookHandler(&mockOutboxEnqueuer{}))
+	router.POST("/webhook", handler.WebhookHandler(&mockPaymentRecorder{}))
 
 	body := map[string]interface{}{
 		"id":          "evt_001",
@@ -121,7 +170,7 @@ func TestWebhookHandler_InvalidOccurredAtFormat(t *testing.T) {
 
 func TestWebhookHandler_InvalidStatus(t *testing.T) {
 	router := gin.Default()
-	router.POST("/webhook", handler.WebhookHandler(&mockOutboxEnqueuer{}))
+	router.POST("/webhook", handler.WebhookHandler(&mockPaymentRecorder{}))
 
 	body := map[string]interface{}{
 		"id":          "evt_001",
@@ -145,7 +194,7 @@ func TestWebhookHandler_InvalidStatus(t *testing.T) {
 
 func TestWebhookHandler_InternalError(t *testing.T) {
 	router := gin.Default()
-	mock := &mockOutboxEnqueuer{
+	mock := &mockPaymentRecorder{
 		err: errors.New("unexpected error"),
 	}
 	router.POST("/webhook", handler.WebhookHandler(mock))
@@ -173,7 +222,7 @@ func TestWebhookHandler_InternalError(t *testing.T) {
 func TestWebhookHandler_Duplicate(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
-	mock := &mockOutboxEnqueuer{
+	mock := &mockPaymentRecorder{
 		err: usecase.ErrDuplicateEvent,
 	}
 	router := gin.Default()
@@ -199,3 +248,65 @@ func TestWebhookHandler_Duplicate(t *testing.T) {
 	assert.True(t, mock.called)
 	assert.Contains(t, w.Body.String(), `"duplicate"`)
 }
+
+func TestParseWebhookRequest(t *testing.T) {
+	sub, err := handler.ParseWebhookRequest([]byte(`{
+		"id": "evt_001", "amount": 1200, "currency": "USD",
+		"method": "card", "status": "paid", "occurred_at": 1711972800
+	}`), handler.DecodeOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "evt_001", sub.Payment.ID)
+	assert.Equal(t, "evt_001", sub.Event.AggregateID)
+	assert.Equal(t, time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC), sub.Payment.OccurredAt)
+
+	_, err = handler.ParseWebhookRequest([]byte(`{"id": "evt_001"}`), handler.DecodeOptions{})
+	assert.ErrorIs(t, err, handler.ErrInvalidPayload)
+}
+
+func TestAsyncWebhookHandler_Accepted(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mock := &mockPaymentRecorder{}
+	router := gin.New()
+	router.POST("/webhook", handler.AsyncWebhookHandler(mock))
+
+	req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(validWebhookBody))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	require.True(t, mock.called)
+	var resp struct {
+		Status  string `json:"status"`
+		EventID string `json:"event_id"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "accepted", resp.Status)
+	assert.Equal(t, mock.event.ID.String(), resp.EventID)
+}
+
+func TestAsyncWebhookHandler_QueueError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/webhook", handler.AsyncWebhookHandler(&mockPaymentRecorder{err: errors.New("WAL is full")}))
+
+	req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(validWebhookBody))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), "failed to queue event")
+}
+
+func TestAsyncWebhookHandler_InvalidPayload(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mock := &mockPaymentRecorder{}
+	router := gin.New()
+	router.POST("/webhook", handler.AsyncWebhookHandler(mock))
+
+	req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(`{"id":"evt_001"}`))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.False(t, mock.called)
+}
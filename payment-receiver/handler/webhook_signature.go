@@ -0,0 +1,118 @@
+// Package handler provides HTTP handler functions.
+package handler
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"payment-receiver/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookSignatureHeader carries a provider's signature of the webhook body:
+// "t=<unix>,v1=<hex>", where v1 is HMAC-SHA256 over "<unix>.<body>" keyed
+// with the provider's secret.
+const WebhookSignatureHeader = "X-Provider-Signature"
+
+// DefaultSignatureTolerance is how far a signature timestamp may be from now.
+const DefaultSignatureTolerance = 5 * time.Minute
+
+// CodeInvalidSignature is returned when a signed provider's request has a
+// missing, malformed, stale or wrong signature.
+const CodeInvalidSignature = "invalid_signature"
+
+// WebhookSignature returns a middleware that verifies WebhookSignatureHeader
+// for providers that have a secret, rejecting failures with 401. Requests
+// from other providers pass unverified. The outcome is reported to
+// WebhookDeliveryAudit, which must run before this middleware.
+func WebhookSignature(
+	secrets map[string]string,
+	providerKey func(c *gin.Context) string,
+	tolerance time.Duration,
+) gin.HandlerFunc {
+	if tolerance <= 0 {
+		tolerance = DefaultSignatureTolerance
+	}
+
+	return func(c *gin.Context) {
+		secret, ok := secrets[providerKey(c)]
+		if !ok {
+			SetSignatureStatus(c, domain.SignatureNotConfigured)
+			c.Next()
+			return
+		}
+
+		header := c.GetHeader(WebhookSignatureHeader)
+		if header == "" {
+			SetSignatureStatus(c, domain.SignatureMissing)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing signature", "code": CodeInvalidSignature})
+			return
+		}
+
+		var body []byte
+		if c.Request.Body != nil {
+			var err error
+			body, err = io.ReadAll(c.Request.Body)
+			if err != nil {
+				// Leave the read error (e.g. *http.MaxBytesError) to the handler
+				c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), errReader{err}))
+				c.Next()
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		if !validSignature(header, secret, body, time.Now(), tolerance) {
+			SetSignatureStatus(c, domain.SignatureInvalid)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid signature", "code": CodeInvalidSignature})
+			return
+		}
+		SetSignatureStatus(c, domain.SignatureValid)
+		c.Next()
+	}
+}
+
+// validSignature checks a "t=<unix>,v1=<hex>" header against body. Any v1
+// entry may match, so providers can rotate secrets.
+func validSignature(header, secret string, body []byte, now time.Time, tolerance time.Duration) bool {
+	var ts string
+	var sigs []string
+	for _, part := range strings.Split(header, ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch k {
+		case "t":
+			ts = v
+		case "v1":
+			sigs = append(sigs, v)
+		}
+	}
+
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return false
+	}
+	if d := now.Sub(time.Unix(unix, 0)); d > tolerance || d < -tolerance {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	want := mac.Sum(nil)
+	for _, sig := range sigs {
+		got, err := hex.DecodeString(sig)
+		if err == nil && hmac.Equal(got, want) {
+			return true
+		}
+	}
+	return false
+}
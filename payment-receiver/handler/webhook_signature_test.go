@@ -0,0 +1,77 @@
+package handler_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"payment-receiver/domain"
+	"payment-receiver/handler"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func signBody(secret string, at time.Time, body string) string {
+	ts := strconv.FormatInt(at.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts + "." + body))
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestWebhookSignature(t *testing.T) {
+	now := time.Now()
+	valid := signBody("s3cret", now, auditedBody)
+	// A provider rotating secrets sends one v1 per secret
+	rotated := signBody("old", now, auditedBody) + "," + strings.SplitN(valid, ",", 2)[1]
+	tests := []struct {
+		name       string
+		provider   string
+		signature  string
+		wantCode   int
+		wantStatus string
+	}{
+		{"valid", "acme", valid, http.StatusCreated, domain.SignatureValid},
+		{"rotated secret", "acme", rotated, http.StatusCreated, domain.SignatureValid},
+		{"missing", "acme", "", http.StatusUnauthorized, domain.SignatureMissing},
+		{"wrong secret", "acme", signBody("guess", now, auditedBody), http.StatusUnauthorized, domain.SignatureInvalid},
+		{"other body", "acme", signBody("s3cret", now, `{}`), http.StatusUnauthorized, domain.SignatureInvalid},
+		{"stale", "acme", signBody("s3cret", now.Add(-time.Hour), auditedBody), http.StatusUnauthorized, domain.SignatureInvalid},
+		{"malformed", "acme", "v1=abc", http.StatusUnauthorized, domain.SignatureInvalid},
+		{"provider without secret", "other", "", http.StatusCreated, domain.SignatureNotConfigured},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			deliveries := &fakeDeliveryRecorder{}
+			router := gin.New()
+			router.POST("/webhook",
+				handler.WebhookDeliveryAudit(deliveries, nil),
+				handler.WebhookSignature(map[string]string{"acme": "s3cret"}, handler.ByProvider("X-Provider-Id"), 0),
+				handler.WebhookHandler(&mockPaymentRecorder{}),
+			)
+
+			req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(auditedBody))
+			req.Header.Set("X-Provider-Id", tt.provider)
+			if tt.signature != "" {
+				req.Header.Set(handler.WebhookSignatureHeader, tt.signature)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			if tt.wantCode == http.StatusUnauthorized {
+				assert.Contains(t, w.Body.String(), handler.CodeInvalidSignature)
+			}
+			require.Len(t, deliveries.deliveries, 1)
+			assert.Equal(t, tt.wantStatus, deliveries.deliveries[0].SignatureStatus)
+		})
+	}
+}
@@ -4,3 +4,18 @@ package infrastructure
 import "errors"
 
 var ErrDuplicateKey = errors.New("duplicate key constraint violation")
+
+// ErrWALFull is returned when appending would grow the WAL past its size limit.
+var ErrWALFull = errors.New("write-ahead log is full")
+
+// ErrStatementTimeout is returned when Postgres cancels a statement that ran
+// past statement_timeout.
+var ErrStatementTimeout = errors.New("statement timeout")
+
+// ErrSchemaDirty is returned when a previous migration failed halfway and the
+// schema has to be repaired by hand.
+var ErrSchemaDirty = errors.New("schema is dirty")
+
+// ErrSchemaOutdated is returned when the database has not been migrated to
+// the latest version the binary embeds.
+var ErrSchemaOutdated = errors.New("schema is older than expected")
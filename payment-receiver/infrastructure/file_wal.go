@@ -0,0 +1,387 @@
+// Package infrastructure implements repository interfaces using concrete tools like Redis and Postgres.
+package infrastructure
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"payment-receiver/domain"
+	"payment-receiver/usecase"
+
+	"github.com/google/uuid"
+)
+
+// DefaultWALMaxBytes bounds the WAL when FileWAL is opened with maxBytes 0.
+const DefaultWALMaxBytes = 256 << 20
+
+const (
+	walFileName        = "wal.log"
+	walCheckpointName  = "wal.checkpoint"
+	walHeaderSize      = 8
+	walMaxRecordLength = 16 << 20
+)
+
+var walCRCTable = crc32.MakeTable(crc32.Castagnoli)
+
+// FileWAL implements the PaymentWAL interface with an append-only file in a
+// local directory. Each record is a 4-byte length, a 4-byte CRC-32C and a
+// JSON body, and is fsync'd before Append returns. Uncommitted entries are
+// also kept in memory, so draining does not read the file back; the file is
+// only read on open, to recover what a crash left behind. A torn record at
+// the end of the file is truncated away. Once every entry is committed the
+// file is truncated to zero; while entries stay pending, the committed prefix
+// is reclaimed by rewriting the file once it reaches half of maxBytes.
+type FileWAL struct {
+	mu         sync.Mutex
+	f          *os.File
+	path       string
+	checkpoint string
+	maxBytes   int64
+	// start is the offset of the first uncommitted record and size the
+	// offset after the last one.
+	start   int64
+	size    int64
+	pending []walPending
+}
+
+type walPending struct {
+	entry usecase.PaymentEntry
+	end   int64
+}
+
+// walRecord is the on-disk form of a PaymentEntry. OutboxEvent.Payload holds
+// codec output that is not necessarily JSON, so the event is copied into a
+// struct that encodes it as bytes.
+type walRecord struct {
+	Payment *domain.Payment `json:"payment"`
+	Event   walEvent        `json:"event"`
+}
+
+type walEvent struct {
+	ID          uuid.UUID           `json:"id"`
+	AggregateID string              `json:"aggregate_id"`
+	EventType   string              `json:"event_type"`
+	Payload     []byte              `json:"payload"`
+	Codec       string              `json:"codec"`
+	Status      domain.OutboxStatus `json:"status"`
+	CreatedAt   time.Time           `json:"created_at"`
+	EventAt     time.Time           `json:"event_at"`
+}
+
+var _ usecase.PaymentWAL = (*FileWAL)(nil)
+
+// OpenFileWAL opens or creates the WAL in dir and loads every entry not yet
+// committed. maxBytes limits the size of the uncommitted entries; 0 means
+// DefaultWALMaxBytes. The file itself may grow to 1.5 times maxBytes before
+// its committed prefix is reclaimed.
+func OpenFileWAL(dir string, maxBytes int64) (*FileWAL, error) {
+	if maxBytes <= 0 {
+		maxBytes = DefaultWALMaxBytes
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create WAL directory: %w", err)
+	}
+
+	path := filepath.Join(dir, walFileName)
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open WAL: %w", err)
+	}
+	w := &FileWAL{f: f, path: path, checkpoint: filepath.Join(dir, walCheckpointName), maxBytes: maxBytes}
+	if err := w.recover(); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// recover loads the records after the checkpoint and truncates a torn tail.
+func (w *FileWAL) recover() error {
+	info, err := w.f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat WAL: %w", err)
+	}
+	size := info.Size()
+
+	start, err := w.readCheckpoint()
+	if err != nil {
+		return err
+	}
+	if start > size {
+		// The file was truncated after the last checkpoint was written.
+		start = 0
+	}
+
+	r := io.NewSectionReader(w.f, start, size-start)
+	offset := start
+	for offset < size {
+		entry, n, err := readWALRecord(r)
+		if err != nil {
+			log.Printf("WAL: discarding %d bytes after offset %d: %v", size-offset, offset, err)
+			if err := w.f.Truncate(offset); err != nil {
+				return fmt.Errorf("failed to truncate WAL: %w", err)
+			}
+			if err := w.f.Sync(); err != nil {
+				return fmt.Errorf("failed to sync WAL: %w", err)
+			}
+			break
+		}
+		offset += n
+		w.pending = append(w.pending, walPending{entry: entry, end: offset})
+	}
+	w.start = start
+	w.size = offset
+	return nil
+}
+
+// Append writes entry to the file and fsyncs it.
+func (w *FileWAL) Append(entry usecase.PaymentEntry) error {
+	rec, err := encodeWALRecord(entry)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.size-w.start+int64(len(rec)) > w.maxBytes {
+		return ErrWALFull
+	}
+	if _, err := w.f.Write(rec); err != nil {
+		// Drop a partial record so the next append starts on a boundary.
+		_ = w.f.Truncate(w.size)
+		return fmt.Errorf("failed to write WAL: %w", err)
+	}
+	if err := w.f.Sync(); err != nil {
+		_ = w.f.Truncate(w.size)
+		return fmt.Errorf("failed to sync WAL: %w", err)
+	}
+	w.size += int64(len(rec))
+	w.pending = append(w.pending, walPending{entry: entry, end: w.size})
+	return nil
+}
+
+// ReadBatch returns up to max uncommitted entries from memory.
+func (w *FileWAL) ReadBatch(max int) ([]usecase.PaymentEntry, int64, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	n := min(max, len(w.pending))
+	if n == 0 {
+		return nil, 0, nil
+	}
+	entries := make([]usecase.PaymentEntry, n)
+	for i := range entries {
+		entries[i] = w.pending[i].entry
+	}
+	return entries, w.pending[n-1].end, nil
+}
+
+// Commit drops the entries before next and records the new checkpoint. When
+// nothing is left the file is truncated instead, and once the committed
+// prefix reaches half of maxBytes the file is compacted.
+func (w *FileWAL) Commit(next int64) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	i := 0
+	for i < len(w.pending) && w.pending[i].end <= next {
+		i++
+	}
+	w.pending = w.pending[i:]
+
+	if len(w.pending) == 0 {
+		if err := w.f.Truncate(0); err != nil {
+			return fmt.Errorf("failed to truncate WAL: %w", err)
+		}
+		if err := w.f.Sync(); err != nil {
+			return fmt.Errorf("failed to sync WAL: %w", err)
+		}
+		w.size = 0
+		next = 0
+	}
+	w.start = next
+	if w.start >= w.maxBytes/2 {
+		return w.compact()
+	}
+	return w.writeCheckpoint(next)
+}
+
+// compact rewrites the file without the committed prefix before w.start.
+// The checkpoint is reset before the new file replaces the old one, so a
+// crash in between replays committed entries, which the recorder skips as
+// duplicates, instead of misreading the new file from the old offset.
+func (w *FileWAL) compact() error {
+	tmp := w.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to compact WAL: %w", err)
+	}
+	fail := func(err error) error {
+		_ = f.Close()
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to compact WAL: %w", err)
+	}
+	if _, err := io.Copy(f, io.NewSectionReader(w.f, w.start, w.size-w.start)); err != nil {
+		return fail(err)
+	}
+	if err := f.Sync(); err != nil {
+		return fail(err)
+	}
+	if err := w.writeCheckpoint(0); err != nil {
+		return fail(err)
+	}
+	if err := os.Rename(tmp, w.path); err != nil {
+		return fail(err)
+	}
+	if err := syncDir(filepath.Dir(w.path)); err != nil {
+		log.Printf("WAL: failed to sync directory after compaction: %v", err)
+	}
+
+	_ = w.f.Close()
+	w.f = f
+	for i := range w.pending {
+		w.pending[i].end -= w.start
+	}
+	w.size -= w.start
+	w.start = 0
+	return nil
+}
+
+// Pending returns the number of uncommitted entries.
+func (w *FileWAL) Pending() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.pending)
+}
+
+// Close closes the WAL file. Uncommitted entries are recovered on the next open.
+func (w *FileWAL) Close() error {
+	return w.f.Close()
+}
+
+func (w *FileWAL) readCheckpoint() (int64, error) {
+	data, err := os.ReadFile(w.checkpoint)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read WAL checkpoint: %w", err)
+	}
+	offset, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid WAL checkpoint %q", data)
+	}
+	return offset, nil
+}
+
+// writeCheckpoint replaces the checkpoint file atomically.
+func (w *FileWAL) writeCheckpoint(offset int64) error {
+	tmp := w.checkpoint + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to write WAL checkpoint: %w", err)
+	}
+	if _, err := f.WriteString(strconv.FormatInt(offset, 10)); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write WAL checkpoint: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to sync WAL checkpoint: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write WAL checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp, w.checkpoint); err != nil {
+		return fmt.Errorf("failed to replace WAL checkpoint: %w", err)
+	}
+	return nil
+}
+
+// syncDir fsyncs a directory so a rename in it is durable.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+func encodeWALRecord(entry usecase.PaymentEntry) ([]byte, error) {
+	e := entry.Event
+	body, err := json.Marshal(walRecord{
+		Payment: entry.Payment,
+		Event: walEvent{
+			ID:          e.ID,
+			AggregateID: e.AggregateID,
+			EventType:   e.EventType,
+			Payload:     e.Payload,
+			Codec:       e.Codec,
+			Status:      e.Status,
+			CreatedAt:   e.CreatedAt,
+			EventAt:     e.EventAt,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode WAL record: %w", err)
+	}
+
+	rec := make([]byte, walHeaderSize+len(body))
+	binary.BigEndian.PutUint32(rec[0:4], uint32(len(body)))
+	binary.BigEndian.PutUint32(rec[4:8], crc32.Checksum(body, walCRCTable))
+	copy(rec[walHeaderSize:], body)
+	return rec, nil
+}
+
+// readWALRecord reads one record and returns it with its size on disk.
+func readWALRecord(r io.Reader) (usecase.PaymentEntry, int64, error) {
+	var header [walHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return usecase.PaymentEntry{}, 0, fmt.Errorf("short record header: %w", err)
+	}
+	length := binary.BigEndian.Uint32(header[0:4])
+	if length > walMaxRecordLength {
+		return usecase.PaymentEntry{}, 0, fmt.Errorf("record length %d exceeds limit", length)
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return usecase.PaymentEntry{}, 0, fmt.Errorf("short record body: %w", err)
+	}
+	if crc32.Checksum(body, walCRCTable) != binary.BigEndian.Uint32(header[4:8]) {
+		return usecase.PaymentEntry{}, 0, errors.New("checksum mismatch")
+	}
+
+	var rec walRecord
+	if err := json.Unmarshal(body, &rec); err != nil {
+		return usecase.PaymentEntry{}, 0, fmt.Errorf("invalid record: %w", err)
+	}
+	if rec.Payment == nil || rec.Event.AggregateID == "" {
+		return usecase.PaymentEntry{}, 0, errors.New("incomplete record")
+	}
+	return usecase.PaymentEntry{
+		Payment: rec.Payment,
+		Event: &domain.OutboxEvent{
+			ID:          rec.Event.ID,
+			AggregateID: rec.Event.AggregateID,
+			EventType:   rec.Event.EventType,
+			Payload:     rec.Event.Payload,
+			Codec:       rec.Event.Codec,
+			Status:      rec.Event.Status,
+			CreatedAt:   rec.Event.CreatedAt,
+			EventAt:     rec.Event.EventAt,
+		},
+	}, int64(walHeaderSize) + int64(length), nil
+}
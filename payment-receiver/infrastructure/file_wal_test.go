@@ -0,0 +1,205 @@
+package infrastructure_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"payment-receiver/domain"
+	"payment-receiver/infrastructure"
+	"payment-receiver/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPaymentEntry(id string) usecase.PaymentEntry {
+	occurredAt := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+	return usecase.PaymentEntry{
+		Payment: &domain.Payment{
+			ID:         id,
+			Amount:     1200,
+			Currency:   "USD",
+			Method:     "card",
+			Status:     domain.StatusPaid,
+			OccurredAt: occurredAt,
+		},
+		Event: &domain.OutboxEvent{
+			ID:          uuid.New(),
+			AggregateID: id,
+			EventType:   "payment_event",
+			// Protobuf payloads are not valid JSON.
+			Payload:   []byte{0x0a, 0x07, 0xff, 0x00},
+			Codec:     "protobuf",
+			Status:    domain.StatusPending,
+			CreatedAt: occurredAt.Add(time.Second),
+			EventAt:   occurredAt,
+		},
+	}
+}
+
+func openWAL(t *testing.T, dir string, maxBytes int64) *infrastructure.FileWAL {
+	t.Helper()
+	wal, err := infrastructure.OpenFileWAL(dir, maxBytes)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = wal.Close() })
+	return wal
+}
+
+func aggregateIDs(entries []usecase.PaymentEntry) []string {
+	ids := make([]string, len(entries))
+	for i, e := range entries {
+		ids[i] = e.Event.AggregateID
+	}
+	return ids
+}
+
+func TestFileWAL_AppendReadCommit(t *testing.T) {
+	wal := openWAL(t, t.TempDir(), 0)
+	entries := []usecase.PaymentEntry{newPaymentEntry("evt_001"), newPaymentEntry("evt_002"), newPaymentEntry("evt_003")}
+	for _, e := range entries {
+		require.NoError(t, wal.Append(e))
+	}
+
+	batch, next, err := wal.ReadBatch(2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"evt_001", "evt_002"}, aggregateIDs(batch))
+
+	// Reading again without committing returns the same entries.
+	again, _, err := wal.ReadBatch(2)
+	require.NoError(t, err)
+	assert.Equal(t, aggregateIDs(batch), aggregateIDs(again))
+
+	require.NoError(t, wal.Commit(next))
+	batch, next, err = wal.ReadBatch(2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"evt_003"}, aggregateIDs(batch))
+	require.NoError(t, wal.Commit(next))
+
+	batch, _, err = wal.ReadBatch(2)
+	require.NoError(t, err)
+	assert.Empty(t, batch)
+	assert.Equal(t, 0, wal.Pending())
+}
+
+func TestFileWAL_RecoversUncommittedEntries(t *testing.T) {
+	dir := t.TempDir()
+	wal, err := infrastructure.OpenFileWAL(dir, 0)
+	require.NoError(t, err)
+	want := newPaymentEntry("evt_002")
+	for _, e := range []usecase.PaymentEntry{newPaymentEntry("evt_001"), want} {
+		require.NoError(t, wal.Append(e))
+	}
+	_, next, err := wal.ReadBatch(1)
+	require.NoError(t, err)
+	require.NoError(t, wal.Commit(next))
+	require.NoError(t, wal.Close())
+
+	reopened := openWAL(t, dir, 0)
+	assert.Equal(t, 1, reopened.Pending())
+	batch, _, err := reopened.ReadBatch(10)
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+	assert.Equal(t, want.Payment, batch[0].Payment)
+	assert.Equal(t, want.Event.ID, batch[0].Event.ID)
+	assert.Equal(t, want.Event.Payload, batch[0].Event.Payload)
+	assert.True(t, want.Event.EventAt.Equal(batch[0].Event.EventAt))
+}
+
+func TestFileWAL_TruncatesTornTail(t *testing.T) {
+	dir := t.TempDir()
+	wal, err := infrastructure.OpenFileWAL(dir, 0)
+	require.NoError(t, err)
+	require.NoError(t, wal.Append(newPaymentEntry("evt_001")))
+	require.NoError(t, wal.Append(newPaymentEntry("evt_002")))
+	require.NoError(t, wal.Close())
+
+	// Simulate a crash in the middle of writing the second record.
+	path := filepath.Join(dir, "wal.log")
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.NoError(t, os.Truncate(path, info.Size()-5))
+
+	reopened := openWAL(t, dir, 0)
+	batch, _, err := reopened.ReadBatch(10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"evt_001"}, aggregateIDs(batch))
+
+	// New appends start after the last complete record.
+	require.NoError(t, reopened.Append(newPaymentEntry("evt_003")))
+	require.NoError(t, reopened.Close())
+	again := openWAL(t, dir, 0)
+	batch, _, err = again.ReadBatch(10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"evt_001", "evt_003"}, aggregateIDs(batch))
+}
+
+func TestFileWAL_CorruptRecordIsDiscarded(t *testing.T) {
+	dir := t.TempDir()
+	wal, err := infrastructure.OpenFileWAL(dir, 0)
+	require.NoError(t, err)
+	require.NoError(t, wal.Append(newPaymentEntry("evt_001")))
+	require.NoError(t, wal.Close())
+
+	path := filepath.Join(dir, "wal.log")
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	data[len(data)-2] ^= 0xff
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	assert.Equal(t, 0, openWAL(t, dir, 0).Pending())
+}
+
+func TestFileWAL_TruncatesWhenFullyCommitted(t *testing.T) {
+	dir := t.TempDir()
+	wal := openWAL(t, dir, 0)
+	require.NoError(t, wal.Append(newPaymentEntry("evt_001")))
+	_, next, err := wal.ReadBatch(10)
+	require.NoError(t, err)
+	require.NoError(t, wal.Commit(next))
+
+	info, err := os.Stat(filepath.Join(dir, "wal.log"))
+	require.NoError(t, err)
+	assert.Zero(t, info.Size())
+}
+
+func TestFileWAL_Full(t *testing.T) {
+	wal := openWAL(t, t.TempDir(), 600)
+	require.NoError(t, wal.Append(newPaymentEntry("evt_001")))
+
+	err := wal.Append(newPaymentEntry("evt_002"))
+	assert.ErrorIs(t, err, infrastructure.ErrWALFull)
+
+	_, next, err := wal.ReadBatch(10)
+	require.NoError(t, err)
+	require.NoError(t, wal.Commit(next))
+	assert.NoError(t, wal.Append(newPaymentEntry("evt_002")), "committing frees space")
+}
+
+func TestFileWAL_ReclaimsCommittedPrefixWhileEntriesPending(t *testing.T) {
+	dir := t.TempDir()
+	wal := openWAL(t, dir, 2000)
+	require.NoError(t, wal.Append(newPaymentEntry("evt_000")))
+
+	// Each round commits the oldest entry and leaves the newest pending, so
+	// the file is never empty; far more than maxBytes passes through it.
+	for i := 1; i <= 50; i++ {
+		require.NoError(t, wal.Append(newPaymentEntry(fmt.Sprintf("evt_%03d", i))))
+		_, next, err := wal.ReadBatch(1)
+		require.NoError(t, err)
+		require.NoError(t, wal.Commit(next))
+		require.Equal(t, 1, wal.Pending())
+	}
+
+	info, err := os.Stat(filepath.Join(dir, "wal.log"))
+	require.NoError(t, err)
+	assert.LessOrEqual(t, info.Size(), int64(3000))
+
+	require.NoError(t, wal.Close())
+	entries, _, err := openWAL(t, dir, 2000).ReadBatch(10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"evt_050"}, aggregateIDs(entries))
+}
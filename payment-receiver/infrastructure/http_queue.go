@@ -0,0 +1,277 @@
+// Package infrastructure implements repository interfaces using concrete tools like Redis and Postgres.
+package infrastructure
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"payment-receiver/domain"
+	"payment-receiver/envelope"
+	"payment-receiver/eventtype"
+	"payment-receiver/repository"
+	"payment-receiver/usecase"
+
+	"github.com/google/uuid"
+	"github.com/sony/gobreaker"
+)
+
+// SignatureHeader carries the HMAC signature of outbound webhook requests.
+const SignatureHeader = "X-Payment-Signature"
+
+// HTTPSubscriber is a partner endpoint that receives outbox events.
+// Zero retry and breaker settings fall back to package defaults.
+type HTTPSubscriber struct {
+	Name             string
+	URL              string
+	Secret           string
+	MaxAttempts      int
+	InitialBackoff   time.Duration
+	MaxBackoff       time.Duration
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+type httpSubscriber struct {
+	HTTPSubscriber
+	breaker *gobreaker.CircuitBreaker
+}
+
+// HTTPQueue implements the OutboxQueue interface by POSTing events to every
+// configured subscriber. Each subscriber has its own retry budget and circuit
+// breaker, and every attempt is recorded so delivery status can be inspected.
+type HTTPQueue struct {
+	client      *http.Client
+	subscribers []*httpSubscriber
+	attempts    repository.DeliveryAttemptRepository
+	source      string
+	types       *eventtype.Registry
+}
+
+var _ usecase.OutboxQueue = (*HTTPQueue)(nil)
+
+// NewHTTPQueue creates an HTTPQueue for the given subscribers.
+// source and types behave as in NewRedisQueue.
+func NewHTTPQueue(
+	subscribers []HTTPSubscriber,
+	attempts repository.DeliveryAttemptRepository,
+	source string,
+	types *eventtype.Registry,
+) (*HTTPQueue, error) {
+	if len(subscribers) == 0 {
+		return nil, errors.New("at least one http subscriber is required")
+	}
+
+	q := &HTTPQueue{
+		client:   &http.Client{Timeout: 5 * time.Second},
+		attempts: attempts,
+		source:   source,
+		types:    types,
+	}
+	for _, s := range subscribers {
+		if s.Name == "" || s.URL == "" || s.Secret == "" {
+			return nil, errors.New("http subscriber name, url and secret are required")
+		}
+		q.subscribers = append(q.subscribers, newHTTPSubscriber(s))
+	}
+	return q, nil
+}
+
+func newHTTPSubscriber(s HTTPSubscriber) *httpSubscriber {
+	if s.MaxAttempts <= 0 {
+		s.MaxAttempts = 3
+	}
+	if s.InitialBackoff <= 0 {
+		s.InitialBackoff = 200 * time.Millisecond
+	}
+	if s.MaxBackoff <= 0 {
+		s.MaxBackoff = 5 * time.Second
+	}
+	if s.BreakerThreshold <= 0 {
+		s.BreakerThreshold = 5
+	}
+	if s.BreakerCooldown <= 0 {
+		s.BreakerCooldown = 30 * time.Second
+	}
+
+	threshold := uint32(s.BreakerThreshold)
+	return &httpSubscriber{
+		HTTPSubscriber: s,
+		breaker: gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:    s.Name,
+			Timeout: s.BreakerCooldown,
+			ReadyToTrip: func(counts gobreaker.Counts) bool {
+				return counts.ConsecutiveFailures >= threshold
+			},
+			IsSuccessful: breakerSuccess,
+		}),
+	}
+}
+
+// Enqueue delivers the event to every subscriber that has not received it yet.
+// It fails if any subscriber still has not received the event, so the outbox
+// row stays pending and only the remaining subscribers are retried later.
+func (q *HTTPQueue) Enqueue(ctx context.Context, event *domain.OutboxEvent) error {
+	env, err := newOutboxEnvelope(event, q.source, q.types)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, s := range q.subscribers {
+		if err := q.deliver(ctx, s, event, env); err != nil {
+			errs = append(errs, fmt.Errorf("subscriber %s: %w", s.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (q *HTTPQueue) deliver(
+	ctx context.Context,
+	s *httpSubscriber,
+	event *domain.OutboxEvent,
+	env *envelope.Envelope,
+) error {
+	done, err := q.attempts.HasSucceeded(ctx, event.ID, s.Name)
+	if err != nil {
+		return fmt.Errorf("failed to check delivery state: %w", err)
+	}
+	if done {
+		return nil
+	}
+
+	backoff := s.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := q.attempt(ctx, s, event, env, attempt)
+		if err == nil {
+			return nil
+		}
+		if attempt >= s.MaxAttempts || ctx.Err() != nil || !retryable(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, s.MaxBackoff)
+	}
+}
+
+func (q *HTTPQueue) attempt(
+	ctx context.Context,
+	s *httpSubscriber,
+	event *domain.OutboxEvent,
+	env *envelope.Envelope,
+	attempt int,
+) error {
+	started := time.Now()
+	statusCode := 0
+	_, err := s.breaker.Execute(func() (interface{}, error) {
+		code, err := q.post(ctx, s, env)
+		statusCode = code
+		return nil, err
+	})
+
+	record := &domain.DeliveryAttempt{
+		ID:            uuid.New(),
+		OutboxEventID: event.ID,
+		Subscriber:    s.Name,
+		Attempt:       attempt,
+		StatusCode:    statusCode,
+		Succeeded:     err == nil,
+		AttemptedAt:   started,
+		Duration:      time.Since(started),
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	if recErr := q.attempts.Record(ctx, record); recErr != nil {
+		log.Printf("failed to record delivery attempt for event %s: %v", event.ID, recErr)
+	}
+	return err
+}
+
+func (q *HTTPQueue) post(ctx context.Context, s *httpSubscriber, env *envelope.Envelope) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(env.Data))
+	if err != nil {
+		return 0, err
+	}
+
+	// CloudEvents HTTP binary content mode: attributes as "ce-" headers.
+	for k, v := range env.Attributes() {
+		if k == envelope.FieldDataContentType {
+			continue
+		}
+		req.Header.Set("ce-"+strings.TrimPrefix(k, "ce_"), v)
+	}
+	req.Header.Set("Content-Type", env.DataContentType)
+	req.Header.Set(SignatureHeader, SignPayload(s.Secret, time.Now(), env.Data))
+
+	resp, err := q.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, &httpStatusError{code: resp.StatusCode}
+	}
+	return resp.StatusCode, nil
+}
+
+// SignPayload returns the signature header value for body: "t=<unix>,v1=<hex>",
+// where v1 is HMAC-SHA256 over "<unix>.<body>" keyed with secret.
+func SignPayload(secret string, at time.Time, body []byte) string {
+	ts := strconv.FormatInt(at.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+type httpStatusError struct {
+	code int
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d", e.code)
+}
+
+// breakerSuccess reports whether a delivery outcome counts as healthy for the
+// circuit breaker. A 4xx other than 408 or 429 means the subscriber is up and
+// rejected this request, so it must not open the breaker for every event.
+func breakerSuccess(err error) bool {
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) {
+		code := statusErr.code
+		return code >= 400 && code < 500 &&
+			code != http.StatusRequestTimeout && code != http.StatusTooManyRequests
+	}
+	return err == nil
+}
+
+// retryable reports whether a failed attempt may succeed if repeated.
+func retryable(err error) bool {
+	if errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests) {
+		return false
+	}
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.code >= 500 || statusErr.code == http.StatusTooManyRequests
+	}
+	return true
+}
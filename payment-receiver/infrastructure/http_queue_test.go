@@ -0,0 +1,238 @@
+package infrastructure_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"payment-receiver/domain"
+	"payment-receiver/eventtype"
+	"payment-receiver/infrastructure"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryDeliveryAttempts struct {
+	mu       sync.Mutex
+	attempts []*domain.DeliveryAttempt
+}
+
+func (m *memoryDeliveryAttempts) Record(_ context.Context, a *domain.DeliveryAttempt) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.attempts = append(m.attempts, a)
+	return nil
+}
+
+func (m *memoryDeliveryAttempts) HasSucceeded(
+	_ context.Context,
+	eventID uuid.UUID,
+	subscriber string,
+) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, a := range m.attempts {
+		if a.OutboxEventID == eventID && a.Subscriber == subscriber && a.Succeeded {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *memoryDeliveryAttempts) ListByEvent(
+	_ context.Context,
+	eventID uuid.UUID,
+) ([]*domain.DeliveryAttempt, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []*domain.DeliveryAttempt
+	for _, a := range m.attempts {
+		if a.OutboxEventID == eventID {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
+
+func (m *memoryDeliveryAttempts) SubscriberStatuses(
+	_ context.Context,
+) ([]*domain.SubscriberStatus, error) {
+	return nil, nil
+}
+
+func (m *memoryDeliveryAttempts) countFor(subscriber string) (ok, failed int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, a := range m.attempts {
+		if a.Subscriber != subscriber {
+			continue
+		}
+		if a.Succeeded {
+			ok++
+		} else {
+			failed++
+		}
+	}
+	return ok, failed
+}
+
+func newHTTPQueue(
+	t *testing.T,
+	attempts *memoryDeliveryAttempts,
+	subs ...infrastructure.HTTPSubscriber,
+) *infrastructure.HTTPQueue {
+	for i := range subs {
+		subs[i].InitialBackoff = time.Millisecond
+		subs[i].MaxBackoff = time.Millisecond
+	}
+	q, err := infrastructure.NewHTTPQueue(subs, attempts, "", eventtype.Default())
+	require.NoError(t, err)
+	return q
+}
+
+func TestHTTPQueue_Enqueue_SignsAndDelivers(t *testing.T) {
+	ev := newTestOutboxEvent(t, "evt_001", testEventOptions{})
+
+	var got *http.Request
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+
+	attempts := &memoryDeliveryAttempts{}
+	q := newHTTPQueue(t, attempts, infrastructure.HTTPSubscriber{
+		Name: "partner", URL: srv.URL, Secret: "s3cret",
+	})
+
+	require.NoError(t, q.Enqueue(context.Background(), ev))
+
+	assert.Equal(t, []byte(ev.Payload), body)
+	assert.Equal(t, "application/protobuf", got.Header.Get("Content-Type"))
+	assert.Equal(t, ev.ID.String(), got.Header.Get("ce-id"))
+	assert.Equal(t, "payment_event", got.Header.Get("ce-type"))
+
+	sig := got.Header.Get(infrastructure.SignatureHeader)
+	ts, _, _ := strings.Cut(strings.TrimPrefix(sig, "t="), ",")
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	require.NoError(t, err)
+	assert.Equal(t, infrastructure.SignPayload("s3cret", time.Unix(unix, 0), body), sig)
+
+	ok, failed := attempts.countFor("partner")
+	assert.Equal(t, 1, ok)
+	assert.Equal(t, 0, failed)
+}
+
+func TestHTTPQueue_Enqueue_RetriesServerErrors(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	attempts := &memoryDeliveryAttempts{}
+	q := newHTTPQueue(t, attempts, infrastructure.HTTPSubscriber{
+		Name: "partner", URL: srv.URL, Secret: "s3cret", MaxAttempts: 3,
+	})
+
+	require.NoError(t, q.Enqueue(context.Background(), newTestOutboxEvent(t, "evt_001", testEventOptions{})))
+
+	ok, failed := attempts.countFor("partner")
+	assert.Equal(t, 1, ok)
+	assert.Equal(t, 2, failed)
+}
+
+func TestHTTPQueue_Enqueue_DoesNotRetryClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	t.Cleanup(srv.Close)
+
+	q := newHTTPQueue(t, &memoryDeliveryAttempts{}, infrastructure.HTTPSubscriber{
+		Name: "partner", URL: srv.URL, Secret: "s3cret", MaxAttempts: 5,
+	})
+
+	err := q.Enqueue(context.Background(), newTestOutboxEvent(t, "evt_001", testEventOptions{}))
+	assert.ErrorContains(t, err, "unexpected status 400")
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestHTTPQueue_Enqueue_SkipsSubscribersAlreadyDelivered(t *testing.T) {
+	var okCalls, failCalls atomic.Int32
+	okSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		okCalls.Add(1)
+	}))
+	t.Cleanup(okSrv.Close)
+	failSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		failCalls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(failSrv.Close)
+
+	q := newHTTPQueue(t, &memoryDeliveryAttempts{},
+		infrastructure.HTTPSubscriber{Name: "ok", URL: okSrv.URL, Secret: "a", MaxAttempts: 1},
+		infrastructure.HTTPSubscriber{Name: "down", URL: failSrv.URL, Secret: "b", MaxAttempts: 1},
+	)
+
+	ev := newTestOutboxEvent(t, "evt_001", testEventOptions{})
+	assert.ErrorContains(t, q.Enqueue(context.Background(), ev), "subscriber down")
+	assert.ErrorContains(t, q.Enqueue(context.Background(), ev), "subscriber down")
+
+	assert.Equal(t, int32(1), okCalls.Load())
+	assert.Equal(t, int32(2), failCalls.Load())
+}
+
+func TestHTTPQueue_Enqueue_CircuitBreakerOpens(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	t.Cleanup(srv.Close)
+
+	q := newHTTPQueue(t, &memoryDeliveryAttempts{}, infrastructure.HTTPSubscriber{
+		Name: "partner", URL: srv.URL, Secret: "s3cret",
+		MaxAttempts: 1, BreakerThreshold: 2, BreakerCooldown: time.Hour,
+	})
+
+	for i := 0; i < 4; i++ {
+		assert.Error(t, q.Enqueue(context.Background(), newTestOutboxEvent(t, "evt_001", testEventOptions{})))
+	}
+	assert.Equal(t, int32(2), calls.Load(), "breaker should stop calls after threshold")
+}
+
+func TestHTTPQueue_Enqueue_ClientErrorsDoNotOpenBreaker(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	t.Cleanup(srv.Close)
+
+	q := newHTTPQueue(t, &memoryDeliveryAttempts{}, infrastructure.HTTPSubscriber{
+		Name: "partner", URL: srv.URL, Secret: "s3cret",
+		MaxAttempts: 1, BreakerThreshold: 2, BreakerCooldown: time.Hour,
+	})
+
+	for i := 0; i < 4; i++ {
+		assert.ErrorContains(t, q.Enqueue(context.Background(), newTestOutboxEvent(t, "evt_001", testEventOptions{})), "unexpected status 400")
+	}
+	assert.Equal(t, int32(4), calls.Load(), "client errors should not open the breaker")
+}
@@ -0,0 +1,101 @@
+// Package infrastructure implements repository interfaces using concrete tools like Redis and Postgres.
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"payment-receiver/domain"
+	"payment-receiver/eventtype"
+	"payment-receiver/usecase"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// KafkaQueue implements the OutboxQueue interface using Kafka.
+// Records are keyed by AggregateID so events of one payment stay ordered
+// within a partition, and the producer writes idempotently with acks from all
+// in-sync replicas.
+type KafkaQueue struct {
+	client  *kgo.Client
+	topic   string
+	source  string
+	types   *eventtype.Registry
+	timeout time.Duration
+}
+
+var _ usecase.OutboxQueue = (*KafkaQueue)(nil)
+
+// NewKafkaQueue creates a KafkaQueue producing to topic on the given brokers.
+// source and types behave as in NewRedisQueue.
+func NewKafkaQueue(
+	brokers []string,
+	topic, source string,
+	types *eventtype.Registry,
+) (*KafkaQueue, error) {
+	if len(brokers) == 0 {
+		return nil, errors.New("at least one kafka broker is required")
+	}
+	if topic == "" {
+		return nil, errors.New("kafka topic is required")
+	}
+
+	client, err := kgo.NewClient(
+		kgo.SeedBrokers(brokers...),
+		kgo.DefaultProduceTopic(topic),
+		kgo.RequiredAcks(kgo.AllISRAcks()),
+		kgo.RecordPartitioner(kgo.StickyKeyPartitioner(nil)),
+		kgo.ProducerBatchCompression(kgo.SnappyCompression(), kgo.NoCompression()),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kafka client: %w", err)
+	}
+
+	return &KafkaQueue{
+		client:  client,
+		topic:   topic,
+		source:  source,
+		types:   types,
+		timeout: 5 * time.Second,
+	}, nil
+}
+
+// Enqueue publishes the stored payload to Kafka. CloudEvents attributes are
+// sent as "ce_" headers, following the CloudEvents Kafka binary content mode.
+func (q *KafkaQueue) Enqueue(ctx context.Context, event *domain.OutboxEvent) error {
+	ctx, cancel := context.WithTimeout(ctx, q.timeout)
+	defer cancel()
+
+	env, err := newOutboxEnvelope(event, q.source, q.types)
+	if err != nil {
+		return err
+	}
+
+	attrs := env.Attributes()
+	headers := make([]kgo.RecordHeader, 0, len(attrs)+1)
+	for k, v := range attrs {
+		headers = append(headers, kgo.RecordHeader{Key: k, Value: []byte(v)})
+	}
+	headers = append(headers, kgo.RecordHeader{
+		Key:   "content-type",
+		Value: []byte(env.DataContentType),
+	})
+
+	record := &kgo.Record{
+		Topic:   q.topic,
+		Key:     []byte(event.AggregateID),
+		Value:   env.Data,
+		Headers: headers,
+	}
+	if err := q.client.ProduceSync(ctx, record).FirstErr(); err != nil {
+		return fmt.Errorf("failed to produce to kafka: %w", err)
+	}
+	return nil
+}
+
+// Close flushes buffered records and closes the underlying client.
+func (q *KafkaQueue) Close() {
+	q.client.Close()
+}
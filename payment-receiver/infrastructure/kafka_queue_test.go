@@ -0,0 +1,95 @@
+package infrastructure_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"payment-receiver/envelope"
+	"payment-receiver/eventtype"
+	"payment-receiver/infrastructure"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func setupKafka(t *testing.T) []string {
+	cluster, err := kfake.NewCluster(kfake.NumBrokers(1), kfake.SeedTopics(3, "payment-events"))
+	require.NoError(t, err)
+	t.Cleanup(cluster.Close)
+	return cluster.ListenAddrs()
+}
+
+func consumeKafka(t *testing.T, brokers []string, n int) []*kgo.Record {
+	client, err := kgo.NewClient(
+		kgo.SeedBrokers(brokers...),
+		kgo.ConsumeTopics("payment-events"),
+		kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()),
+	)
+	require.NoError(t, err)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var records []*kgo.Record
+	for len(records) < n {
+		fetches := client.PollFetches(ctx)
+		require.NoError(t, ctx.Err(), "timed out waiting for records")
+		records = append(records, fetches.Records()...)
+	}
+	return records
+}
+
+func TestKafkaQueue_Enqueue(t *testing.T) {
+	brokers := setupKafka(t)
+	queue, err := infrastructure.NewKafkaQueue(brokers, "payment-events", "", eventtype.Default())
+	require.NoError(t, err)
+	t.Cleanup(queue.Close)
+
+	ev := newTestOutboxEvent(t, "evt_001", testEventOptions{})
+	require.NoError(t, queue.Enqueue(context.Background(), ev))
+
+	records := consumeKafka(t, brokers, 1)
+	require.Len(t, records, 1)
+	rec := records[0]
+
+	headers := map[string]string{}
+	for _, h := range rec.Headers {
+		headers[h.Key] = string(h.Value)
+	}
+	assert.Equal(t, "evt_001", string(rec.Key))
+	assert.Equal(t, []byte(ev.Payload), rec.Value)
+	assert.Equal(t, ev.ID.String(), headers[envelope.FieldID])
+	assert.Equal(t, "payment_event", headers[envelope.FieldType])
+	assert.Equal(t, "application/protobuf", headers["content-type"])
+}
+
+func TestKafkaQueue_Enqueue_SameAggregateSamePartition(t *testing.T) {
+	brokers := setupKafka(t)
+	queue, err := infrastructure.NewKafkaQueue(brokers, "payment-events", "", eventtype.Default())
+	require.NoError(t, err)
+	t.Cleanup(queue.Close)
+
+	for i := 0; i < 3; i++ {
+		ev := newTestOutboxEvent(t, "evt_001", testEventOptions{})
+		ev.ID = uuid.New()
+		require.NoError(t, queue.Enqueue(context.Background(), ev))
+	}
+
+	records := consumeKafka(t, brokers, 3)
+	for _, rec := range records {
+		assert.Equal(t, records[0].Partition, rec.Partition)
+	}
+}
+
+func TestNewKafkaQueue_RequiresConfig(t *testing.T) {
+	_, err := infrastructure.NewKafkaQueue(nil, "payment-events", "", nil)
+	assert.Error(t, err)
+
+	_, err = infrastructure.NewKafkaQueue([]string{"localhost:9092"}, "", "", nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,81 @@
+// Package infrastructure implements repository interfaces using concrete tools like Redis and Postgres.
+package infrastructure
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"payment-receiver/usecase"
+
+	"golang.org/x/time/rate"
+)
+
+// memoryLimiterIdleTTL is how long an unused bucket is kept before it is dropped.
+const memoryLimiterIdleTTL = 10 * time.Minute
+
+// MemoryRateLimiter implements the RateLimiter interface with per-process
+// token buckets. Each replica enforces its own quota.
+type MemoryRateLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+}
+
+type memoryBucket struct {
+	limiter  *rate.Limiter
+	limit    usecase.RateLimit
+	lastUsed time.Time
+}
+
+var _ usecase.RateLimiter = (*MemoryRateLimiter)(nil)
+
+// NewMemoryRateLimiter creates an empty in-process rate limiter.
+func NewMemoryRateLimiter() *MemoryRateLimiter {
+	return &MemoryRateLimiter{buckets: map[string]*memoryBucket{}}
+}
+
+// Allow takes a token from the bucket for key.
+func (l *MemoryRateLimiter) Allow(
+	_ context.Context,
+	key string,
+	limit usecase.RateLimit,
+) (bool, time.Duration, error) {
+	if !limit.Enabled() {
+		return true, 0, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok || b.limit != limit {
+		b = &memoryBucket{limiter: rate.NewLimiter(rate.Limit(limit.Rate), limit.Burst), limit: limit}
+		l.buckets[key] = b
+	}
+	b.lastUsed = now
+
+	r := b.limiter.ReserveN(now, 1)
+	if delay := r.DelayFrom(now); delay > 0 {
+		r.CancelAt(now)
+		return false, delay, nil
+	}
+	return true, 0, nil
+}
+
+// sweep drops buckets that have been idle for memoryLimiterIdleTTL, at most
+// once per TTL.
+func (l *MemoryRateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < memoryLimiterIdleTTL {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if now.Sub(b.lastUsed) >= memoryLimiterIdleTTL {
+			delete(l.buckets, key)
+		}
+	}
+}
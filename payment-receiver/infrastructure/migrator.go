@@ -0,0 +1,336 @@
+// Package infrastructure implements repository interfaces using concrete tools like Redis and Postgres.
+package infrastructure
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+)
+
+// Migration is one schema change in golang-migrate layout:
+// <version>_<name>.up.sql and <version>_<name>.down.sql.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus reports whether a migration has been applied.
+type MigrationStatus struct {
+	Migration
+	Applied bool
+}
+
+// migrationLockID is the pg_advisory_lock key held while migrating, so
+// replicas starting together apply each migration once.
+const migrationLockID int64 = 0x6f7574626f78 // "outbox"
+
+var migrationFile = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// LoadMigrations reads the migrations in the root of fsys, ordered by version.
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+	byVersion := make(map[int64]*Migration)
+	for _, e := range entries {
+		if e.IsDir() || path.Ext(e.Name()) != ".sql" {
+			continue
+		}
+		m := migrationFile.FindStringSubmatch(e.Name())
+		if m == nil {
+			return nil, fmt.Errorf("invalid migration file name %q", e.Name())
+		}
+		version, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %q: %w", e.Name(), err)
+		}
+		body, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %q: %w", e.Name(), err)
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		} else if mig.Name != m[2] {
+			return nil, fmt.Errorf("migration version %d used by %q and %q", version, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			mig.Up = string(body)
+		} else {
+			mig.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", mig.Version, mig.Name)
+		}
+		migrations = append(migrations, *mig)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int { return cmp.Compare(a.Version, b.Version) })
+	return migrations, nil
+}
+
+// Migrator applies embedded migrations, recording the version in the same
+// schema_migrations table as the golang-migrate CLI so either can be used on
+// the same database.
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// NewMigrator creates a migrator for the migrations in the root of fsys.
+func NewMigrator(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := LoadMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Latest returns the version the embedded migrations lead to, or 0 when
+// there are none.
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version returns the applied version (0 when none) and whether the last
+// migration failed halfway.
+func (m *Migrator) Version(ctx context.Context) (version int64, dirty bool, err error) {
+	return schemaVersion(ctx, m.db)
+}
+
+// Status lists every embedded migration and whether it has been applied.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	version, _, err := m.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]MigrationStatus, len(m.migrations))
+	for i, mig := range m.migrations {
+		statuses[i] = MigrationStatus{Migration: mig, Applied: mig.Version <= version}
+	}
+	return statuses, nil
+}
+
+// CheckCurrent returns ErrSchemaOutdated when migrations are pending and
+// ErrSchemaDirty when the last one failed. A newer schema is accepted so old
+// binaries keep running during a rolling deploy.
+func (m *Migrator) CheckCurrent(ctx context.Context) error {
+	version, dirty, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("%w at version %d", ErrSchemaDirty, version)
+	}
+	if version < m.Latest() {
+		return fmt.Errorf("%w: at version %d, expected %d", ErrSchemaOutdated, version, m.Latest())
+	}
+	return nil
+}
+
+// Up applies every pending migration, each in its own transaction, and
+// returns those applied.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		version, err := m.lockedVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, mig := range m.migrations {
+			if mig.Version <= version {
+				continue
+			}
+			if err := applyMigration(ctx, conn, mig.Up, mig.Version); err != nil {
+				return fmt.Errorf("migration %d_%s up: %w", mig.Version, mig.Name, err)
+			}
+			applied = append(applied, mig)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the last steps applied migrations and returns those reverted.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var reverted []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		version, err := m.lockedVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+		i := slices.IndexFunc(m.migrations, func(mig Migration) bool { return mig.Version == version })
+		if version != 0 && i < 0 {
+			return fmt.Errorf("applied version %d is not embedded in this binary", version)
+		}
+		for ; i >= 0 && len(reverted) < steps; i-- {
+			mig := m.migrations[i]
+			if mig.Down == "" {
+				return fmt.Errorf("migration %d_%s has no down file", mig.Version, mig.Name)
+			}
+			var previous int64
+			if i > 0 {
+				previous = m.migrations[i-1].Version
+			}
+			if err := applyMigration(ctx, conn, mig.Down, previous); err != nil {
+				return fmt.Errorf("migration %d_%s down: %w", mig.Version, mig.Name, err)
+			}
+			reverted = append(reverted, mig)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// withLock runs fn on a single connection holding the migration advisory
+// lock, creating schema_migrations first.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer func() {
+		if err := conn.Close(); err != nil {
+			log.Println("failed to close connection:", err)
+		}
+	}()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, migrationLockID); err != nil {
+			log.Println("failed to release migration lock:", err)
+		}
+	}()
+
+	if _, err := conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT NOT NULL PRIMARY KEY,
+			dirty BOOLEAN NOT NULL
+		)
+	`); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	return fn(conn)
+}
+
+// lockedVersion reads the applied version, refusing to continue when dirty.
+func (m *Migrator) lockedVersion(ctx context.Context, conn *sql.Conn) (int64, error) {
+	version, dirty, err := schemaVersion(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+	if dirty {
+		return 0, fmt.Errorf("%w at version %d", ErrSchemaDirty, version)
+	}
+	return version, nil
+}
+
+// schemaVersion reads schema_migrations, treating a missing table or row as
+// version 0.
+func schemaVersion(ctx context.Context, db dbtx) (int64, bool, error) {
+	var exists bool
+	if err := db.QueryRowContext(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists); err != nil {
+		return 0, false, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if !exists {
+		return 0, false, nil
+	}
+	var version int64
+	var dirty bool
+	err := db.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, dirty, nil
+}
+
+// nonTransactional matches statements PostgreSQL refuses to run inside a
+// transaction block.
+var nonTransactional = regexp.MustCompile(`(?i)\bCONCURRENTLY\b`)
+
+var sqlComment = regexp.MustCompile(`--[^\n]*|/\*(?s:.*?)\*/`)
+
+// StripSQLComments removes -- and /* */ comments from script, so keyword
+// checks only see statements. Comment markers inside string literals are not
+// recognised; migrations do not use them there.
+func StripSQLComments(script string) string {
+	return sqlComment.ReplaceAllString(script, "")
+}
+
+// applyMigration runs script and records version in one transaction; version
+// 0 clears schema_migrations. Scripts using CONCURRENTLY cannot run in a
+// transaction, so they are bracketed by a dirty version instead, as
+// golang-migrate does, and must hold a single statement.
+func applyMigration(ctx context.Context, conn *sql.Conn, script string, version int64) (err error) {
+	if nonTransactional.MatchString(StripSQLComments(script)) {
+		if err := setSchemaVersion(ctx, conn, version, true); err != nil {
+			return err
+		}
+		if _, err := conn.ExecContext(ctx, script); err != nil {
+			return err
+		}
+		return setSchemaVersion(ctx, conn, version, false)
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Println("failed to roll back transaction:", rbErr)
+			}
+		}
+	}()
+
+	if _, err = tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if err = setSchemaVersion(ctx, tx, version, false); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// setSchemaVersion replaces the row in schema_migrations. A clean version 0
+// leaves the table empty, golang-migrate's "no version".
+func setSchemaVersion(ctx context.Context, db dbtx, version int64, dirty bool) error {
+	if _, err := db.ExecContext(ctx, `TRUNCATE schema_migrations`); err != nil {
+		return fmt.Errorf("failed to record version: %w", err)
+	}
+	if version == 0 && !dirty {
+		return nil
+	}
+	if _, err := db.ExecContext(ctx, `INSERT INTO schema_migrations (version, dirty) VALUES ($1, $2)`, version, dirty); err != nil {
+		return fmt.Errorf("failed to record version: %w", err)
+	}
+	return nil
+}
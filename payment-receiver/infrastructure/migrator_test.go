@@ -0,0 +1,72 @@
+package infrastructure_test
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"payment-receiver/infrastructure"
+	"payment-receiver/migrations"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sqlFile(s string) *fstest.MapFile {
+	return &fstest.MapFile{Data: []byte(s)}
+}
+
+func TestLoadMigrations_OrdersByVersion(t *testing.T) {
+	fsys := fstest.MapFS{
+		"20250102000000_second.up.sql":   sqlFile("CREATE TABLE b ();"),
+		"20250102000000_second.down.sql": sqlFile("DROP TABLE b;"),
+		"20250101000000_first.up.sql":    sqlFile("CREATE TABLE a ();"),
+		"README.md":                      sqlFile("ignored"),
+	}
+
+	got, err := infrastructure.LoadMigrations(fsys)
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, infrastructure.Migration{Version: 20250101000000, Name: "first", Up: "CREATE TABLE a ();"}, got[0])
+	assert.Equal(t, int64(20250102000000), got[1].Version)
+	assert.Equal(t, "DROP TABLE b;", got[1].Down)
+}
+
+func TestLoadMigrations_RejectsInvalidSets(t *testing.T) {
+	tests := map[string]fstest.MapFS{
+		"bad name":      {"create_table.up.sql": sqlFile("")},
+		"missing up":    {"1_a.down.sql": sqlFile("DROP TABLE a;")},
+		"version reuse": {"1_a.up.sql": sqlFile("SELECT 1;"), "1_b.up.sql": sqlFile("SELECT 1;")},
+	}
+	for name, fsys := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := infrastructure.LoadMigrations(fsys)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestStripSQLComments(t *testing.T) {
+	script := "-- not built CONCURRENTLY; the table is new\nCREATE INDEX a_b ON a (b); /* or\nCONCURRENTLY */\n"
+	assert.Equal(t, "\nCREATE INDEX a_b ON a (b); \n", infrastructure.StripSQLComments(script))
+}
+
+func TestMigrator_UpIsIdempotentAndCurrent(t *testing.T) {
+	db := setupTestDB(t)
+	m, err := infrastructure.NewMigrator(db, migrations.FS)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = m.Up(ctx)
+	require.NoError(t, err)
+	applied, err := m.Up(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+	assert.NoError(t, m.CheckCurrent(ctx))
+
+	statuses, err := m.Status(ctx)
+	require.NoError(t, err)
+	for _, s := range statuses {
+		assert.True(t, s.Applied, "%d_%s", s.Version, s.Name)
+	}
+}
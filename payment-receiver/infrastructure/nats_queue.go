@@ -0,0 +1,141 @@
+// Package infrastructure implements repository interfaces using concrete tools like Redis and Postgres.
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"payment-receiver/codec"
+	"payment-receiver/domain"
+	"payment-receiver/eventtype"
+	pb "payment-receiver/gen/proto"
+	"payment-receiver/usecase"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// NATSQueue implements the OutboxQueue interface using NATS JetStream.
+// The outbox event ID is sent as Nats-Msg-Id so the server drops republished
+// events within the stream's duplicate window.
+type NATSQueue struct {
+	nc      *nats.Conn
+	js      jetstream.JetStream
+	prefix  string
+	source  string
+	types   *eventtype.Registry
+	timeout time.Duration
+}
+
+var _ usecase.OutboxQueue = (*NATSQueue)(nil)
+
+// NewNATSQueue connects to url and makes sure stream exists and captures
+// every subject under subjectPrefix. source and types behave as in NewRedisQueue.
+func NewNATSQueue(
+	url, stream, subjectPrefix, source string,
+	types *eventtype.Registry,
+) (*NATSQueue, error) {
+	if stream == "" || subjectPrefix == "" {
+		return nil, errors.New("nats stream and subject prefix are required")
+	}
+
+	nc, err := nats.Connect(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to nats: %w", err)
+	}
+	js, err := jetstream.New(nc)
+	if err != nil {
+		nc.Close()
+		return nil, fmt.Errorf("failed to create jetstream context: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     stream,
+		Subjects: []string{subjectPrefix + ".>"},
+	}); err != nil {
+		nc.Close()
+		return nil, fmt.Errorf("failed to ensure jetstream stream %s: %w", stream, err)
+	}
+
+	return &NATSQueue{
+		nc:      nc,
+		js:      js,
+		prefix:  subjectPrefix,
+		source:  source,
+		types:   types,
+		timeout: 5 * time.Second,
+	}, nil
+}
+
+// Enqueue publishes the stored payload to JetStream and waits for the server
+// acknowledgement, so the dispatcher only marks the event sent once it is stored.
+// A duplicate acknowledgement means an earlier attempt already succeeded.
+func (q *NATSQueue) Enqueue(ctx context.Context, event *domain.OutboxEvent) error {
+	ctx, cancel := context.WithTimeout(ctx, q.timeout)
+	defer cancel()
+
+	env, err := newOutboxEnvelope(event, q.source, q.types)
+	if err != nil {
+		return err
+	}
+	subject, err := q.subject(event)
+	if err != nil {
+		return err
+	}
+
+	msg := nats.NewMsg(subject)
+	msg.Data = env.Data
+	for k, v := range env.Attributes() {
+		msg.Header.Set(k, v)
+	}
+	msg.Header.Set("content-type", env.DataContentType)
+
+	if _, err := q.js.PublishMsg(ctx, msg, jetstream.WithMsgID(event.ID.String())); err != nil {
+		return fmt.Errorf("failed to publish to jetstream: %w", err)
+	}
+	return nil
+}
+
+// Close drains and closes the NATS connection.
+func (q *NATSQueue) Close() {
+	if err := q.nc.Drain(); err != nil {
+		q.nc.Close()
+	}
+}
+
+// subjectToken matches a single NATS subject token. Dots, wildcards and
+// whitespace would change which subject, and so which consumers, an event
+// reaches.
+var subjectToken = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// subject returns "<prefix>.<status>" for payment events and
+// "<prefix>.<event type>" for everything else.
+func (q *NATSQueue) subject(event *domain.OutboxEvent) (string, error) {
+	if event.EventType != eventtype.PaymentEvent {
+		if !subjectToken.MatchString(event.EventType) {
+			return "", fmt.Errorf("event type %q is not a valid subject token", event.EventType)
+		}
+		return q.prefix + "." + event.EventType, nil
+	}
+
+	c, err := codec.Lookup(event.Codec)
+	if err != nil {
+		return "", err
+	}
+	var payment pb.PaymentEvent
+	if err := c.Unmarshal(event.Payload, &payment); err != nil {
+		return "", fmt.Errorf("failed to read payment status: %w", err)
+	}
+	if payment.Status == "" {
+		return "", errors.New("payment event has no status")
+	}
+	if !subjectToken.MatchString(payment.Status) {
+		return "", fmt.Errorf("payment status %q is not a valid subject token", payment.Status)
+	}
+	return q.prefix + "." + payment.Status, nil
+}
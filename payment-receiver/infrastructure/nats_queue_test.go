@@ -0,0 +1,124 @@
+package infrastructure_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"payment-receiver/envelope"
+	"payment-receiver/eventtype"
+	"payment-receiver/infrastructure"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupNATS(t *testing.T) string {
+	srv, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+	})
+	require.NoError(t, err)
+	go srv.Start()
+	t.Cleanup(srv.Shutdown)
+	require.True(t, srv.ReadyForConnections(5*time.Second), "nats server not ready")
+	return srv.ClientURL()
+}
+
+func streamMessages(t *testing.T, url string) []jetstream.Msg {
+	nc, err := nats.Connect(url)
+	require.NoError(t, err)
+	defer nc.Close()
+	js, err := jetstream.New(nc)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cons, err := js.OrderedConsumer(ctx, "PAYMENTS", jetstream.OrderedConsumerConfig{})
+	require.NoError(t, err)
+
+	stream, err := js.Stream(ctx, "PAYMENTS")
+	require.NoError(t, err)
+	info, err := stream.Info(ctx)
+	require.NoError(t, err)
+	if info.State.Msgs == 0 {
+		return nil
+	}
+
+	batch, err := cons.FetchNoWait(int(info.State.Msgs))
+	require.NoError(t, err)
+	var msgs []jetstream.Msg
+	for msg := range batch.Messages() {
+		msgs = append(msgs, msg)
+	}
+	return msgs
+}
+
+func TestNATSQueue_Enqueue(t *testing.T) {
+	url := setupNATS(t)
+	queue, err := infrastructure.NewNATSQueue(url, "PAYMENTS", "payments", "", eventtype.Default())
+	require.NoError(t, err)
+	t.Cleanup(queue.Close)
+
+	ev := newTestOutboxEvent(t, "evt_001", testEventOptions{Status: "refunded"})
+	require.NoError(t, queue.Enqueue(context.Background(), ev))
+
+	msgs := streamMessages(t, url)
+	require.Len(t, msgs, 1)
+	msg := msgs[0]
+	assert.Equal(t, "payments.refunded", msg.Subject())
+	assert.Equal(t, []byte(ev.Payload), msg.Data())
+	assert.Equal(t, ev.ID.String(), msg.Headers().Get(jetstream.MsgIDHeader))
+	assert.Equal(t, "payment_event", msg.Headers().Get(envelope.FieldType))
+}
+
+func TestNATSQueue_Enqueue_DeduplicatesRepublish(t *testing.T) {
+	url := setupNATS(t)
+	queue, err := infrastructure.NewNATSQueue(url, "PAYMENTS", "payments", "", eventtype.Default())
+	require.NoError(t, err)
+	t.Cleanup(queue.Close)
+
+	// Publishing twice simulates MarkAsSent failing after a successful publish.
+	ev := newTestOutboxEvent(t, "evt_001", testEventOptions{})
+	require.NoError(t, queue.Enqueue(context.Background(), ev))
+	require.NoError(t, queue.Enqueue(context.Background(), ev))
+
+	assert.Len(t, streamMessages(t, url), 1)
+}
+
+func TestNATSQueue_Enqueue_OtherEventTypeUsesTypeSubject(t *testing.T) {
+	url := setupNATS(t)
+	queue, err := infrastructure.NewNATSQueue(url, "PAYMENTS", "payments", "", eventtype.Default())
+	require.NoError(t, err)
+	t.Cleanup(queue.Close)
+
+	ev := newTestOutboxEvent(t, "evt_001", testEventOptions{})
+	ev.EventType = "refund_requested"
+	require.NoError(t, queue.Enqueue(context.Background(), ev))
+
+	msgs := streamMessages(t, url)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "payments.refund_requested", msgs[0].Subject())
+}
+
+func TestNATSQueue_Enqueue_RejectsInvalidSubjectTokens(t *testing.T) {
+	url := setupNATS(t)
+	queue, err := infrastructure.NewNATSQueue(url, "PAYMENTS", "payments", "", eventtype.Default())
+	require.NoError(t, err)
+	t.Cleanup(queue.Close)
+
+	for _, status := range []string{"paid.refunded", "*", ">", "paid now"} {
+		assert.Error(t, queue.Enqueue(context.Background(), newTestOutboxEvent(t, "evt_001", testEventOptions{Status: status})), status)
+	}
+
+	ev := newTestOutboxEvent(t, "evt_001", testEventOptions{})
+	ev.EventType = "refund.*"
+	assert.Error(t, queue.Enqueue(context.Background(), ev))
+
+	assert.Empty(t, streamMessages(t, url))
+}
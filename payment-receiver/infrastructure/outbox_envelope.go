@@ -0,0 +1,33 @@
+package infrastructure
+
+import (
+	"payment-receiver/codec"
+	"payment-receiver/domain"
+	"payment-receiver/envelope"
+	"payment-receiver/eventtype"
+)
+
+// newOutboxEnvelope validates the event payload against its registered type and
+// wraps it in a CloudEvents envelope ready for publishing.
+func newOutboxEnvelope(
+	event *domain.OutboxEvent,
+	source string,
+	types *eventtype.Registry,
+) (*envelope.Envelope, error) {
+	c, err := codec.Lookup(event.Codec)
+	if err != nil {
+		return nil, err
+	}
+	if err := types.Validate(event.EventType, event.Payload, c); err != nil {
+		return nil, err
+	}
+
+	env, err := envelope.FromOutboxEvent(event, source)
+	if err != nil {
+		return nil, err
+	}
+	if t, ok := types.Lookup(event.EventType); ok {
+		env.DataSchema = t.DataSchema
+	}
+	return env, nil
+}
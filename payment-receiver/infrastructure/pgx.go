@@ -0,0 +1,240 @@
+// Package infrastructure implements repository interfaces using concrete tools like Redis and Postgres.
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"payment-receiver/repository"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PgxConfig tunes the pgx connection pool. Zero fields keep the value from
+// the DSN, or the pgx default when the DSN does not set it.
+type PgxConfig struct {
+	DSN             string
+	MaxConns        int32
+	MinConns        int32
+	MaxConnLifetime time.Duration
+	MaxConnIdleTime time.Duration
+	// StatementTimeout sets statement_timeout on every pooled connection.
+	StatementTimeout time.Duration
+	// StatementCacheCapacity bounds the prepared statements cached per
+	// connection. DisableStatementCache sends every query unprepared, which
+	// is needed behind PgBouncer in transaction pooling mode.
+	StatementCacheCapacity int
+	DisableStatementCache  bool
+}
+
+// PgxPoolConfig builds the pgxpool configuration described by cfg.
+func PgxPoolConfig(cfg PgxConfig) (*pgxpool.Config, error) {
+	pc, err := pgxpool.ParseConfig(cfg.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse postgres DSN: %w", err)
+	}
+	if cfg.MaxConns > 0 {
+		pc.MaxConns = cfg.MaxConns
+	}
+	if cfg.MinConns > 0 {
+		pc.MinConns = cfg.MinConns
+	}
+	if pc.MinConns > pc.MaxConns {
+		return nil, fmt.Errorf("min conns %d exceeds max conns %d", pc.MinConns, pc.MaxConns)
+	}
+	if cfg.MaxConnLifetime > 0 {
+		pc.MaxConnLifetime = cfg.MaxConnLifetime
+	}
+	if cfg.MaxConnIdleTime > 0 {
+		pc.MaxConnIdleTime = cfg.MaxConnIdleTime
+	}
+	if cfg.StatementTimeout > 0 {
+		pc.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(cfg.StatementTimeout.Milliseconds(), 10)
+	}
+	if cfg.StatementCacheCapacity > 0 {
+		pc.ConnConfig.StatementCacheCapacity = cfg.StatementCacheCapacity
+	}
+	if cfg.DisableStatementCache {
+		pc.ConnConfig.DefaultQueryExecMode = pgx.QueryExecModeExec
+		pc.ConnConfig.StatementCacheCapacity = 0
+	}
+	return pc, nil
+}
+
+// NewPgxPool establishes and returns a new pgx connection pool.
+func NewPgxPool(ctx context.Context, cfg PgxConfig) (*pgxpool.Pool, error) {
+	pc, err := PgxPoolConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := pgxpool.NewWithConfig(ctx, pc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open postgres pool: %w", err)
+	}
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("failed to ping postgres: %w", err)
+	}
+	return pool, nil
+}
+
+// PgxConfigFromEnv builds a PgxConfig for dsn from POSTGRES_MAX_CONNS,
+// POSTGRES_MIN_CONNS, POSTGRES_MAX_CONN_LIFETIME, POSTGRES_MAX_CONN_IDLE_TIME,
+// POSTGRES_STATEMENT_TIMEOUT and POSTGRES_STATEMENT_CACHE_CAPACITY (0
+// disables prepared statement caching).
+func PgxConfigFromEnv(dsn string) (PgxConfig, error) {
+	cfg := PgxConfig{DSN: dsn}
+	var err error
+	if cfg.MaxConns, err = envInt32("POSTGRES_MAX_CONNS"); err != nil {
+		return cfg, err
+	}
+	if cfg.MinConns, err = envInt32("POSTGRES_MIN_CONNS"); err != nil {
+		return cfg, err
+	}
+	if cfg.MaxConnLifetime, err = envDuration("POSTGRES_MAX_CONN_LIFETIME"); err != nil {
+		return cfg, err
+	}
+	if cfg.MaxConnIdleTime, err = envDuration("POSTGRES_MAX_CONN_IDLE_TIME"); err != nil {
+		return cfg, err
+	}
+	if cfg.StatementTimeout, err = envDuration("POSTGRES_STATEMENT_TIMEOUT"); err != nil {
+		return cfg, err
+	}
+	if v := os.Getenv("POSTGRES_STATEMENT_CACHE_CAPACITY"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return cfg, fmt.Errorf("invalid POSTGRES_STATEMENT_CACHE_CAPACITY %q", v)
+		}
+		cfg.StatementCacheCapacity = n
+		cfg.DisableStatementCache = n == 0
+	}
+	return cfg, nil
+}
+
+// NewPgxPoolFromEnv opens a pgx pool on dsn, configured by PgxConfigFromEnv,
+// when POSTGRES_DRIVER is pgx. It returns nil for the default pq driver.
+func NewPgxPoolFromEnv(ctx context.Context, dsn string) (*pgxpool.Pool, error) {
+	switch driver := os.Getenv("POSTGRES_DRIVER"); driver {
+	case "", "pq":
+		return nil, nil
+	case "pgx":
+	default:
+		return nil, fmt.Errorf("unknown POSTGRES_DRIVER %q", driver)
+	}
+
+	cfg, err := PgxConfigFromEnv(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return NewPgxPool(ctx, cfg)
+}
+
+func envInt32(key string) (int32, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(v, 10, 32)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid %s %q", key, v)
+	}
+	return int32(n), nil
+}
+
+func envDuration(key string) (time.Duration, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid %s %q", key, v)
+	}
+	return d, nil
+}
+
+// PgxTxManager implements the TxManager interface using a pgx pool.
+type PgxTxManager struct {
+	pool *pgxpool.Pool
+}
+
+var _ repository.TxManager = (*PgxTxManager)(nil)
+
+// NewPgxTxManager creates a new pgx transaction manager.
+func NewPgxTxManager(pool *pgxpool.Pool) *PgxTxManager {
+	return &PgxTxManager{pool: pool}
+}
+
+type pgxTxKey struct{}
+
+// WithinTx runs fn in a transaction carried by the context passed to it.
+func (m *PgxTxManager) WithinTx(
+	ctx context.Context,
+	fn func(ctx context.Context) error,
+) (err error) {
+	if _, ok := ctx.Value(pgxTxKey{}).(pgx.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := m.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", pgxError(err))
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback(context.WithoutCancel(ctx))
+			panic(p)
+		}
+		if err != nil {
+			if rbErr := tx.Rollback(context.WithoutCancel(ctx)); rbErr != nil {
+				log.Println("failed to roll back transaction:", rbErr)
+			}
+		}
+	}()
+
+	if err = fn(context.WithValue(ctx, pgxTxKey{}, tx)); err != nil {
+		return err
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", pgxError(err))
+	}
+	return nil
+}
+
+// pgxdb is the subset of *pgxpool.Pool and pgx.Tx used by the repositories.
+type pgxdb interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// pgxConn returns the transaction bound to ctx by WithinTx, or pool otherwise.
+func pgxConn(ctx context.Context, pool *pgxpool.Pool) pgxdb {
+	if tx, ok := ctx.Value(pgxTxKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	return pool
+}
+
+// pgxError maps Postgres error codes the repositories care about onto
+// package errors, keeping the original error in the chain.
+func pgxError(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+	switch pgErr.Code {
+	case "23505": // unique_violation
+		return fmt.Errorf("%w: %w", ErrDuplicateKey, err)
+	case "57014": // query_canceled, raised when statement_timeout expires
+		return fmt.Errorf("%w: %w", ErrStatementTimeout, err)
+	}
+	return err
+}
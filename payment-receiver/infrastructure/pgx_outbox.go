@@ -0,0 +1,173 @@
+// Package infrastructure implements repository interfaces using concrete tools like Redis and Postgres.
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"payment-receiver/domain"
+	"payment-receiver/gen/outboxpgx"
+	"payment-receiver/repository"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PgxOutbox implements the OutboxRepository interface using a pgx pool.
+type PgxOutbox struct {
+	pool *pgxpool.Pool
+}
+
+var _ repository.OutboxRepository = (*PgxOutbox)(nil)
+
+// NewPgxOutbox creates a new pgx outbox repository.
+func NewPgxOutbox(pool *pgxpool.Pool) *PgxOutbox {
+	return &PgxOutbox{pool: pool}
+}
+
+// queries returns the generated outbox queries, bound to the transaction
+// carried by ctx when there is one.
+func (o *PgxOutbox) queries(ctx context.Context) *outboxpgx.Queries {
+	return outboxpgx.New(pgxConn(ctx, o.pool))
+}
+
+// Insert inserts a new outbox event.
+func (o *PgxOutbox) Insert(ctx context.Context, event *domain.OutboxEvent) error {
+	err := o.queries(ctx).InsertOutboxEvent(ctx, outboxpgx.InsertOutboxEventParams{
+		ID:          event.ID,
+		AggregateID: event.AggregateID,
+		EventType:   event.EventType,
+		Payload:     event.Payload,
+		Codec:       codecName(event),
+		Status:      string(event.Status),
+		CreatedAt:   event.CreatedAt,
+		EventAt:     event.EventAt,
+	})
+	return pgxError(err)
+}
+
+// InsertBatch inserts events with a single multi-row INSERT built from
+// arrays, like PostgresOutbox.InsertBatch.
+func (o *PgxOutbox) InsertBatch(ctx context.Context, events []*domain.OutboxEvent) ([]int, error) {
+	if len(events) == 0 {
+		return nil, nil
+	}
+
+	n := len(events)
+	arg := outboxpgx.InsertOutboxEventsParams{
+		Ids:          make([]uuid.UUID, n),
+		AggregateIds: make([]string, n),
+		EventTypes:   make([]string, n),
+		Payloads:     make([][]byte, n),
+		Codecs:       make([]string, n),
+		Statuses:     make([]string, n),
+		CreatedAts:   make([]time.Time, n),
+		EventAts:     make([]time.Time, n),
+	}
+	for i, ev := range events {
+		arg.Ids[i] = ev.ID
+		arg.AggregateIds[i] = ev.AggregateID
+		arg.EventTypes[i] = ev.EventType
+		arg.Payloads[i] = ev.Payload
+		arg.Codecs[i] = codecName(ev)
+		arg.Statuses[i] = string(ev.Status)
+		arg.CreatedAts[i] = ev.CreatedAt
+		arg.EventAts[i] = ev.EventAt
+	}
+
+	ids, err := o.queries(ctx).InsertOutboxEvents(ctx, arg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert outbox batch: %w", pgxError(err))
+	}
+
+	inserted := make(map[uuid.UUID]struct{}, len(ids))
+	for _, id := range ids {
+		inserted[id] = struct{}{}
+	}
+	var duplicates []int
+	for i, ev := range events {
+		if _, ok := inserted[ev.ID]; !ok {
+			duplicates = append(duplicates, i)
+		}
+	}
+	return duplicates, nil
+}
+
+// FetchPending retrieves pending events up to a limit.
+func (o *PgxOutbox) FetchPending(
+	ctx context.Context,
+	limit int,
+) ([]*domain.OutboxEvent, error) {
+	rows, err := o.queries(ctx).FetchPendingOutboxEvents(ctx, int32(limit))
+	if err != nil {
+		return nil, pgxError(err)
+	}
+	var events []*domain.OutboxEvent
+	for _, row := range rows {
+		events = append(events, pgxOutboxEvent(row))
+	}
+	return events, nil
+}
+
+// MarkAsSent marks an event as sent.
+func (o *PgxOutbox) MarkAsSent(ctx context.Context, id uuid.UUID) error {
+	err := o.queries(ctx).MarkOutboxEventSent(ctx, outboxpgx.MarkOutboxEventSentParams{
+		SentAt: pgtype.Timestamp{Time: time.Now(), Valid: true},
+		ID:     id,
+	})
+	return pgxError(err)
+}
+
+// MarkAttemptFailed records a failed delivery of a pending event.
+func (o *PgxOutbox) MarkAttemptFailed(
+	ctx context.Context,
+	id uuid.UUID,
+	maxAttempts int,
+) (domain.OutboxStatus, error) {
+	status, err := o.queries(ctx).RecordOutboxEventFailure(ctx, outboxpgx.RecordOutboxEventFailureParams{
+		MaxAttempts: int32(maxAttempts),
+		ID:          id,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", repository.ErrNotFound
+	}
+	if err != nil {
+		return "", pgxError(err)
+	}
+	return domain.OutboxStatus(status), nil
+}
+
+// ExistsByAggregateID checks if an event with the given aggregate ID exists
+func (o *PgxOutbox) ExistsByAggregateID(
+	ctx context.Context,
+	aggregateID string,
+) (bool, error) {
+	exists, err := o.queries(ctx).OutboxEventExistsByAggregateID(ctx, aggregateID)
+	if err != nil {
+		return false, pgxError(err)
+	}
+	return exists, nil
+}
+
+// pgxOutboxEvent converts a generated pgx outbox row to the domain type.
+func pgxOutboxEvent(row outboxpgx.OutboxEvent) *domain.OutboxEvent {
+	ev := &domain.OutboxEvent{
+		ID:          row.ID,
+		AggregateID: row.AggregateID,
+		EventType:   row.EventType,
+		Payload:     row.Payload,
+		Codec:       row.Codec,
+		Status:      domain.OutboxStatus(row.Status),
+		CreatedAt:   row.CreatedAt,
+		EventAt:     row.EventAt,
+		Attempts:    int(row.Attempts),
+	}
+	if row.SentAt.Valid {
+		ev.SentAt = &row.SentAt.Time
+	}
+	return ev
+}
@@ -0,0 +1,44 @@
+// Package infrastructure implements repository interfaces using concrete tools like Redis and Postgres.
+package infrastructure
+
+import (
+	"context"
+	"time"
+
+	"payment-receiver/domain"
+	"payment-receiver/repository"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PgxPayments implements the PaymentRepository interface using a pgx pool, so
+// payments can share a PgxTxManager transaction with PgxOutbox.
+type PgxPayments struct {
+	pool *pgxpool.Pool
+}
+
+var _ repository.PaymentRepository = (*PgxPayments)(nil)
+
+// NewPgxPayments creates a new pgx payment repository.
+func NewPgxPayments(pool *pgxpool.Pool) *PgxPayments {
+	return &PgxPayments{pool: pool}
+}
+
+// Upsert inserts the payment or applies it over an older stored state.
+func (r *PgxPayments) Upsert(ctx context.Context, payment *domain.Payment) error {
+	now := time.Now()
+	_, err := pgxConn(ctx, r.pool).Exec(ctx, `
+		INSERT INTO payments (
+			id, amount, currency, method, status, occurred_at, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+		ON CONFLICT (id) DO UPDATE SET
+			amount = EXCLUDED.amount,
+			currency = EXCLUDED.currency,
+			method = EXCLUDED.method,
+			status = EXCLUDED.status,
+			occurred_at = EXCLUDED.occurred_at,
+			updated_at = EXCLUDED.updated_at
+		WHERE payments.occurred_at <= EXCLUDED.occurred_at
+	`, payment.ID, payment.Amount, payment.Currency, payment.Method, payment.Status, payment.OccurredAt, now)
+	return pgxError(err)
+}
@@ -0,0 +1,42 @@
+// Package infrastructure implements repository interfaces using concrete tools like Redis and Postgres.
+package infrastructure
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"payment-receiver/domain"
+	"payment-receiver/repository"
+)
+
+// PostgresAuditLog implements the AuditLogRepository interface using PostgreSQL.
+type PostgresAuditLog struct {
+	db *sql.DB
+}
+
+var _ repository.AuditLogRepository = (*PostgresAuditLog)(nil)
+
+// NewPostgresAuditLog creates a new Postgres audit log repository.
+func NewPostgresAuditLog(db *sql.DB) *PostgresAuditLog {
+	return &PostgresAuditLog{db: db}
+}
+
+// Record stores an audit entry.
+func (r *PostgresAuditLog) Record(ctx context.Context, entry *domain.AuditEntry) error {
+	details := entry.Details
+	if details == nil {
+		details = map[string]any{}
+	}
+	raw, err := json.Marshal(details)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit details: %w", err)
+	}
+
+	_, err = conn(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO admin_audit_log (id, actor, action, target, details, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, entry.ID, entry.Actor, entry.Action, entry.Target, raw, entry.CreatedAt)
+	return err
+}
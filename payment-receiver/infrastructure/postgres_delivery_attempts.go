@@ -0,0 +1,135 @@
+// Package infrastructure implements repository interfaces using concrete tools like Redis and Postgres.
+package infrastructure
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"payment-receiver/domain"
+	"payment-receiver/repository"
+
+	"github.com/google/uuid"
+)
+
+// PostgresDeliveryAttempts implements the DeliveryAttemptRepository interface using PostgreSQL.
+type PostgresDeliveryAttempts struct {
+	db *sql.DB
+}
+
+var _ repository.DeliveryAttemptRepository = (*PostgresDeliveryAttempts)(nil)
+
+// NewPostgresDeliveryAttempts creates a new Postgres delivery attempt repository.
+func NewPostgresDeliveryAttempts(db *sql.DB) *PostgresDeliveryAttempts {
+	return &PostgresDeliveryAttempts{db: db}
+}
+
+// Record inserts a delivery attempt.
+func (r *PostgresDeliveryAttempts) Record(ctx context.Context, a *domain.DeliveryAttempt) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO delivery_attempts (
+			id, outbox_event_id, subscriber, attempt, status_code, error, succeeded,
+			attempted_at, duration_ms
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`, a.ID, a.OutboxEventID, a.Subscriber, a.Attempt, a.StatusCode, a.Error, a.Succeeded,
+		a.AttemptedAt, a.Duration.Milliseconds())
+	return err
+}
+
+// HasSucceeded reports whether the event was already delivered to the subscriber.
+func (r *PostgresDeliveryAttempts) HasSucceeded(
+	ctx context.Context,
+	eventID uuid.UUID,
+	subscriber string,
+) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM delivery_attempts
+			WHERE outbox_event_id = $1 AND subscriber = $2 AND succeeded
+		)
+	`, eventID, subscriber).Scan(&exists)
+	if err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
+// ListByEvent returns all attempts for an event, oldest first.
+func (r *PostgresDeliveryAttempts) ListByEvent(
+	ctx context.Context,
+	eventID uuid.UUID,
+) ([]*domain.DeliveryAttempt, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, outbox_event_id, subscriber, attempt, status_code, error, succeeded,
+			attempted_at, duration_ms
+		FROM delivery_attempts
+		WHERE outbox_event_id = $1
+		ORDER BY attempted_at ASC, attempt ASC
+	`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Println("failed to close rows:", err)
+		}
+	}()
+
+	var attempts []*domain.DeliveryAttempt
+	for rows.Next() {
+		var a domain.DeliveryAttempt
+		var durationMS int64
+		if err := rows.Scan(&a.ID, &a.OutboxEventID, &a.Subscriber, &a.Attempt, &a.StatusCode,
+			&a.Error, &a.Succeeded, &a.AttemptedAt, &durationMS); err != nil {
+			return nil, err
+		}
+		a.Duration = time.Duration(durationMS) * time.Millisecond
+		attempts = append(attempts, &a)
+	}
+	return attempts, rows.Err()
+}
+
+// SubscriberStatuses summarizes attempts per subscriber, including the latest outcome.
+func (r *PostgresDeliveryAttempts) SubscriberStatuses(
+	ctx context.Context,
+) ([]*domain.SubscriberStatus, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT s.subscriber, s.succeeded, s.failed, l.attempted_at, l.status_code, l.error
+		FROM (
+			SELECT subscriber,
+				COUNT(*) FILTER (WHERE succeeded) AS succeeded,
+				COUNT(*) FILTER (WHERE NOT succeeded) AS failed
+			FROM delivery_attempts
+			GROUP BY subscriber
+		) s
+		JOIN LATERAL (
+			SELECT attempted_at, status_code, error
+			FROM delivery_attempts
+			WHERE subscriber = s.subscriber
+			ORDER BY attempted_at DESC
+			LIMIT 1
+		) l ON true
+		ORDER BY s.subscriber
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Println("failed to close rows:", err)
+		}
+	}()
+
+	var statuses []*domain.SubscriberStatus
+	for rows.Next() {
+		var s domain.SubscriberStatus
+		if err := rows.Scan(&s.Subscriber, &s.Succeeded, &s.Failed, &s.LastAttemptAt,
+			&s.LastStatusCode, &s.LastError); err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, &s)
+	}
+	return statuses, rows.Err()
+}
@@ -0,0 +1,93 @@
+// Package infrastructure implements repository interfaces using concrete tools like Redis and Postgres.
+package infrastructure
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"payment-receiver/repository"
+
+	"github.com/google/uuid"
+)
+
+// PostgresDestinationDeliveries implements the DestinationDeliveryRepository interface using PostgreSQL.
+type PostgresDestinationDeliveries struct {
+	db *sql.DB
+}
+
+var _ repository.DestinationDeliveryRepository = (*PostgresDestinationDeliveries)(nil)
+
+// NewPostgresDestinationDeliveries creates a new Postgres destination delivery repository.
+func NewPostgresDestinationDeliveries(db *sql.DB) *PostgresDestinationDeliveries {
+	return &PostgresDestinationDeliveries{db: db}
+}
+
+// Delivered returns the destinations that have already received the event.
+func (r *PostgresDestinationDeliveries) Delivered(
+	ctx context.Context,
+	eventID uuid.UUID,
+) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT destination
+		FROM outbox_destination_deliveries
+		WHERE outbox_event_id = $1 AND status = 'delivered'
+	`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Println("failed to close rows:", err)
+		}
+	}()
+
+	var destinations []string
+	for rows.Next() {
+		var d string
+		if err := rows.Scan(&d); err != nil {
+			return nil, err
+		}
+		destinations = append(destinations, d)
+	}
+	return destinations, rows.Err()
+}
+
+// MarkDelivered records a successful delivery to destination.
+func (r *PostgresDestinationDeliveries) MarkDelivered(
+	ctx context.Context,
+	eventID uuid.UUID,
+	destination string,
+) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO outbox_destination_deliveries (
+			outbox_event_id, destination, status, attempts, updated_at, delivered_at
+		) VALUES ($1, $2, 'delivered', 1, now(), now())
+		ON CONFLICT (outbox_event_id, destination) DO UPDATE
+		SET status = 'delivered',
+			attempts = outbox_destination_deliveries.attempts + 1,
+			last_error = '',
+			updated_at = now(),
+			delivered_at = now()
+	`, eventID, destination)
+	return err
+}
+
+// MarkFailed records a failed delivery to destination.
+func (r *PostgresDestinationDeliveries) MarkFailed(
+	ctx context.Context,
+	eventID uuid.UUID,
+	destination, reason string,
+) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO outbox_destination_deliveries (
+			outbox_event_id, destination, status, attempts, last_error, updated_at
+		) VALUES ($1, $2, 'failed', 1, $3, now())
+		ON CONFLICT (outbox_event_id, destination) DO UPDATE
+		SET status = 'failed',
+			attempts = outbox_destination_deliveries.attempts + 1,
+			last_error = EXCLUDED.last_error,
+			updated_at = now()
+	`, eventID, destination, reason)
+	return err
+}
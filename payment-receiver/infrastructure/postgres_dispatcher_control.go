@@ -0,0 +1,59 @@
+// Package infrastructure implements repository interfaces using concrete tools like Redis and Postgres.
+package infrastructure
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"payment-receiver/domain"
+	"payment-receiver/repository"
+)
+
+// PostgresDispatcherControl implements the DispatcherControl interface using PostgreSQL.
+type PostgresDispatcherControl struct {
+	db *sql.DB
+}
+
+var _ repository.DispatcherControl = (*PostgresDispatcherControl)(nil)
+
+// NewPostgresDispatcherControl creates a new Postgres dispatcher control.
+func NewPostgresDispatcherControl(db *sql.DB) *PostgresDispatcherControl {
+	return &PostgresDispatcherControl{db: db}
+}
+
+// State returns the current pause switch. A missing row means running.
+func (r *PostgresDispatcherControl) State(ctx context.Context) (*domain.DispatcherState, error) {
+	var state domain.DispatcherState
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT paused, reason, updated_by, updated_at
+		FROM dispatcher_control
+		WHERE id
+	`).Scan(&state.Paused, &state.Reason, &state.UpdatedBy, &state.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &domain.DispatcherState{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// SetPaused flips the pause switch and records who did it.
+func (r *PostgresDispatcherControl) SetPaused(
+	ctx context.Context,
+	paused bool,
+	actor, reason string,
+) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO dispatcher_control (id, paused, reason, updated_by, updated_at)
+		VALUES (TRUE, $1, $2, $3, $4)
+		ON CONFLICT (id) DO UPDATE SET
+			paused = EXCLUDED.paused,
+			reason = EXCLUDED.reason,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = EXCLUDED.updated_at
+	`, paused, reason, actor, time.Now().UTC())
+	return err
+}
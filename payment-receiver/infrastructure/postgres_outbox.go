@@ -6,10 +6,12 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-	"log"
 	"time"
 
+	"payment-receiver/codec"
 	"payment-receiver/domain"
+	"payment-receiver/gen/outboxdb"
+	"payment-receiver/repository"
 
 	"github.com/google/uuid"
 	"github.com/lib/pq"
@@ -20,18 +22,36 @@ type PostgresOutbox struct {
 	db *sql.DB
 }
 
+var (
+	_ repository.OutboxRepository                = (*PostgresOutbox)(nil)
+	_ repository.OutboxReader                    = (*PostgresOutbox)(nil)
+	_ repository.OutboxAdminRepository           = (*PostgresOutbox)(nil)
+	_ repository.OutboxPayloadBackfillRepository = (*PostgresOutbox)(nil)
+)
+
 // NewPostgresOutbox creates a new Postgres outbox repository.
 func NewPostgresOutbox(db *sql.DB) *PostgresOutbox {
 	return &PostgresOutbox{db: db}
 }
 
+// queries returns the generated outbox queries, bound to the transaction
+// carried by ctx when there is one.
+func (o *PostgresOutbox) queries(ctx context.Context) *outboxdb.Queries {
+	return outboxdb.New(conn(ctx, o.db))
+}
+
 // Insert inserts a new outbox event.
 func (o *PostgresOutbox) Insert(ctx context.Context, event *domain.OutboxEvent) error {
-	_, err := o.db.ExecContext(ctx, `
-		INSERT INTO outbox_events (
-			id, aggregate_id, event_type, payload, status, created_at, event_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7)
-	`, event.ID, event.AggregateID, event.EventType, event.Payload, event.Status, event.CreatedAt, event.EventAt)
+	err := o.queries(ctx).InsertOutboxEvent(ctx, outboxdb.InsertOutboxEventParams{
+		ID:          event.ID,
+		AggregateID: event.AggregateID,
+		EventType:   event.EventType,
+		Payload:     event.Payload,
+		Codec:       codecName(event),
+		Status:      string(event.Status),
+		CreatedAt:   event.CreatedAt,
+		EventAt:     event.EventAt,
+	})
 	if err != nil {
 		var pgErr *pq.Error
 		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
@@ -42,51 +62,92 @@ func (o *PostgresOutbox) Insert(ctx context.Context, event *domain.OutboxEvent)
 	return nil
 }
 
+// InsertBatch inserts events with a single multi-row INSERT built from
+// arrays, so the statement size does not depend on the batch size. Conflicting
+// aggregate IDs are skipped with ON CONFLICT DO NOTHING and reported by index.
+// COPY is not used because it cannot skip conflicting rows.
+func (o *PostgresOutbox) InsertBatch(ctx context.Context, events []*domain.OutboxEvent) ([]int, error) {
+	if len(events) == 0 {
+		return nil, nil
+	}
+
+	n := len(events)
+	arg := outboxdb.InsertOutboxEventsParams{
+		Ids:          make([]uuid.UUID, n),
+		AggregateIds: make([]string, n),
+		EventTypes:   make([]string, n),
+		Payloads:     make([][]byte, n),
+		Codecs:       make([]string, n),
+		Statuses:     make([]string, n),
+		CreatedAts:   make([]time.Time, n),
+		EventAts:     make([]time.Time, n),
+	}
+	for i, ev := range events {
+		arg.Ids[i] = ev.ID
+		arg.AggregateIds[i] = ev.AggregateID
+		arg.EventTypes[i] = ev.EventType
+		arg.Payloads[i] = ev.Payload
+		arg.Codecs[i] = codecName(ev)
+		arg.Statuses[i] = string(ev.Status)
+		arg.CreatedAts[i] = ev.CreatedAt
+		arg.EventAts[i] = ev.EventAt
+	}
+
+	ids, err := o.queries(ctx).InsertOutboxEvents(ctx, arg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert outbox batch: %w", err)
+	}
+
+	inserted := make(map[uuid.UUID]struct{}, len(ids))
+	for _, id := range ids {
+		inserted[id] = struct{}{}
+	}
+	var duplicates []int
+	for i, ev := range events {
+		if _, ok := inserted[ev.ID]; !ok {
+			duplicates = append(duplicates, i)
+		}
+	}
+	return duplicates, nil
+}
+
 // FetchPending retrieves pending events up to a limit.
 func (o *PostgresOutbox) FetchPending(
 	ctx context.Context,
 	limit int,
 ) ([]*domain.OutboxEvent, error) {
-	rows, err := o.db.QueryContext(ctx, `
-		SELECT id, aggregate_id, event_type, payload, status, event_at, created_at, sent_at
-		FROM outbox_events
-		WHERE status = 'pending'
-		ORDER BY event_at ASC
-		LIMIT $1
-	`, limit)
+	rows, err := o.queries(ctx).FetchPendingOutboxEvents(ctx, int32(limit))
 	if err != nil {
 		return nil, err
 	}
-	defer func() {
-		if err := rows.Close(); err != nil {
-			log.Println("failed to close rows:", err)
-		}
-	}()
-
-	var events []*domain.OutboxEvent
-	for rows.Next() {
-		var ev domain.OutboxEvent
-		var sentAt sql.NullTime
-		if err := rows.Scan(&ev.ID, &ev.AggregateID, &ev.EventType, &ev.Payload, &ev.Status, &ev.EventAt, &ev.CreatedAt, &sentAt); err != nil {
-			return nil, err
-		}
-		if sentAt.Valid {
-			ev.SentAt = &sentAt.Time
-		}
-		events = append(events, &ev)
-	}
-	return events, nil
+	return outboxEvents(rows), nil
 }
 
 // MarkAsSent marks an event as sent.
 func (o *PostgresOutbox) MarkAsSent(ctx context.Context, id uuid.UUID) error {
-	sentAt := time.Now()
-	_, err := o.db.ExecContext(ctx, `
-		UPDATE outbox_events
-		SET status = 'sent', sent_at = $1
-		WHERE id = $2
-	`, sentAt, id)
-	return err
+	return o.queries(ctx).MarkOutboxEventSent(ctx, outboxdb.MarkOutboxEventSentParams{
+		SentAt: sql.NullTime{Time: time.Now(), Valid: true},
+		ID:     id,
+	})
+}
+
+// MarkAttemptFailed records a failed delivery of a pending event.
+func (o *PostgresOutbox) MarkAttemptFailed(
+	ctx context.Context,
+	id uuid.UUID,
+	maxAttempts int,
+) (domain.OutboxStatus, error) {
+	status, err := o.queries(ctx).RecordOutboxEventFailure(ctx, outboxdb.RecordOutboxEventFailureParams{
+		MaxAttempts: int32(maxAttempts),
+		ID:          id,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", repository.ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return domain.OutboxStatus(status), nil
 }
 
 // ExistsByAggregateID checks if an event with the given aggregate ID exists
@@ -94,14 +155,171 @@ func (o *PostgresOutbox) ExistsByAggregateID(
 	ctx context.Context,
 	aggregateID string,
 ) (bool, error) {
-	var exists bool
-	err := o.db.QueryRowContext(ctx, `
-		SELECT EXISTS (
-			SELECT 1 FROM outbox_events WHERE aggregate_id = $1
-		)
-	`, aggregateID).Scan(&exists)
+	return o.queries(ctx).OutboxEventExistsByAggregateID(ctx, aggregateID)
+}
+
+// FindByID retrieves a single outbox event.
+func (o *PostgresOutbox) FindByID(ctx context.Context, id uuid.UUID) (*domain.OutboxEvent, error) {
+	row, err := o.queries(ctx).GetOutboxEvent(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return outboxEvent(row), nil
+}
+
+// ListByAggregateID retrieves every event of an aggregate, oldest first.
+func (o *PostgresOutbox) ListByAggregateID(
+	ctx context.Context,
+	aggregateID string,
+) ([]*domain.OutboxEvent, error) {
+	rows, err := o.queries(ctx).ListOutboxEventsByAggregateID(ctx, aggregateID)
+	if err != nil {
+		return nil, err
+	}
+	return outboxEvents(rows), nil
+}
+
+// List retrieves events matching filter, newest first.
+func (o *PostgresOutbox) List(
+	ctx context.Context,
+	filter repository.OutboxFilter,
+) ([]*domain.OutboxEvent, error) {
+	arg := outboxdb.ListOutboxEventsParams{
+		Status:      sql.NullString{String: string(filter.Status), Valid: filter.Status != ""},
+		EventType:   sql.NullString{String: filter.EventType, Valid: filter.EventType != ""},
+		AggregateID: sql.NullString{String: filter.AggregateID, Valid: filter.AggregateID != ""},
+		CreatedFrom: sql.NullTime{Time: filter.From, Valid: !filter.From.IsZero()},
+		CreatedTo:   sql.NullTime{Time: filter.To, Valid: !filter.To.IsZero()},
+		Limit:       int32(filter.Limit),
+	}
+	if filter.After != nil {
+		arg.AfterCreatedAt = sql.NullTime{Time: filter.After.CreatedAt, Valid: true}
+		arg.AfterID = uuid.NullUUID{UUID: filter.After.ID, Valid: true}
+	}
+	rows, err := o.queries(ctx).ListOutboxEvents(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+	return outboxEvents(rows), nil
+}
+
+// RequeueByID moves a single event in status from back to pending.
+func (o *PostgresOutbox) RequeueByID(
+	ctx context.Context,
+	id uuid.UUID,
+	from domain.OutboxStatus,
+) (bool, error) {
+	n, err := o.queries(ctx).RequeueOutboxEvent(ctx, outboxdb.RequeueOutboxEventParams{
+		ID:     id,
+		Status: string(from),
+	})
+	return n > 0, err
+}
+
+// RequeueRange moves events in status from, created in [start, end), back to pending.
+func (o *PostgresOutbox) RequeueRange(
+	ctx context.Context,
+	from domain.OutboxStatus,
+	start, end time.Time,
+) (int64, error) {
+	return o.queries(ctx).RequeueOutboxEventsCreatedBetween(ctx, outboxdb.RequeueOutboxEventsCreatedBetweenParams{
+		Status:      string(from),
+		CreatedFrom: start,
+		CreatedTo:   end,
+	})
+}
+
+// Stats summarizes the pending and failed backlog.
+func (o *PostgresOutbox) Stats(ctx context.Context) (*domain.OutboxStats, error) {
+	row, err := o.queries(ctx).OutboxStats(ctx)
 	if err != nil {
-		return false, err
+		return nil, err
+	}
+	return &domain.OutboxStats{
+		Pending:         row.Pending,
+		Failed:          row.Failed,
+		OldestPendingAt: nullTime(row.OldestPendingAt),
+		LastSentAt:      nullTime(row.LastSentAt),
+	}, nil
+}
+
+// ListLegacyJSONPayloads returns payment events tagged protobuf whose payload
+// is still JSON text, ordered by ID after the given one.
+func (o *PostgresOutbox) ListLegacyJSONPayloads(
+	ctx context.Context,
+	after uuid.UUID,
+	limit int,
+) ([]*domain.OutboxEvent, error) {
+	rows, err := o.queries(ctx).ListLegacyJSONPayloads(ctx, outboxdb.ListLegacyJSONPayloadsParams{
+		AfterID:   after,
+		BatchSize: int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return outboxEvents(rows), nil
+}
+
+// ReplaceLegacyJSONPayloads rewrites the payloads of events that still hold
+// JSON text in one statement.
+func (o *PostgresOutbox) ReplaceLegacyJSONPayloads(ctx context.Context, events []*domain.OutboxEvent) (int64, error) {
+	if len(events) == 0 {
+		return 0, nil
+	}
+	arg := outboxdb.ReplaceLegacyJSONPayloadsParams{
+		Ids:      make([]uuid.UUID, len(events)),
+		Payloads: make([][]byte, len(events)),
+	}
+	for i, ev := range events {
+		arg.Ids[i] = ev.ID
+		arg.Payloads[i] = ev.Payload
+	}
+	return o.queries(ctx).ReplaceLegacyJSONPayloads(ctx, arg)
+}
+
+// outboxEvent converts a generated outbox row to the domain type.
+func outboxEvent(row outboxdb.OutboxEvent) *domain.OutboxEvent {
+	return &domain.OutboxEvent{
+		ID:          row.ID,
+		AggregateID: row.AggregateID,
+		EventType:   row.EventType,
+		Payload:     row.Payload,
+		Codec:       row.Codec,
+		Status:      domain.OutboxStatus(row.Status),
+		CreatedAt:   row.CreatedAt,
+		SentAt:      nullTime(row.SentAt),
+		EventAt:     row.EventAt,
+		Attempts:    int(row.Attempts),
+	}
+}
+
+func outboxEvents(rows []outboxdb.OutboxEvent) []*domain.OutboxEvent {
+	var events []*domain.OutboxEvent
+	for _, row := range rows {
+		events = append(events, outboxEvent(row))
+	}
+	return events
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// rowScanner is implemented by *sql.Row, *sql.Rows, pgx.Row and pgx.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// codecName returns the codec recorded for the event, defaulting to protobuf.
+func codecName(event *domain.OutboxEvent) string {
+	if event.Codec == "" {
+		return codec.NameProtobuf
 	}
-	return exists, nil
+	return event.Codec
 }
@@ -0,0 +1,83 @@
+package infrastructure_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"payment-receiver/domain"
+	"payment-receiver/infrastructure"
+
+	"github.com/google/uuid"
+)
+
+// The benchmarks compare outbox intake throughput of one INSERT per event
+// against InsertBatch. They need PostgreSQL (see setupTestDB):
+//
+//	go test ./infrastructure -run '^$' -bench Outbox -benchtime 5000x
+
+func BenchmarkOutboxInsert(b *testing.B) {
+	repo := infrastructure.NewPostgresOutbox(setupTestDB(b))
+	ctx := context.Background()
+	prefix := "bench_" + uuid.NewString()[:8]
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := repo.Insert(ctx, newBatchTestEvent(fmt.Sprintf("%s_%d", prefix, i))); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "events/s")
+}
+
+func BenchmarkOutboxInsertBatch(b *testing.B) {
+	for _, size := range []int{10, 100, 1000} {
+		b.Run(fmt.Sprintf("size=%d", size), func(b *testing.B) {
+			repo := infrastructure.NewPostgresOutbox(setupTestDB(b))
+			ctx := context.Background()
+			prefix := "bench_" + uuid.NewString()[:8]
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i += size {
+				n := min(size, b.N-i)
+				events := make([]*domain.OutboxEvent, n)
+				for j := range events {
+					events[j] = newBatchTestEvent(fmt.Sprintf("%s_%d", prefix, i+j))
+				}
+				if _, err := repo.InsertBatch(ctx, events); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "events/s")
+		})
+	}
+}
+
+// BenchmarkOutboxInsertBatch_Duplicates measures a batch whose events all
+// exist already, as when a WAL is replayed after a crash.
+func BenchmarkOutboxInsertBatch_Duplicates(b *testing.B) {
+	repo := infrastructure.NewPostgresOutbox(setupTestDB(b))
+	ctx := context.Background()
+	events := make([]*domain.OutboxEvent, 100)
+	prefix := "bench_" + uuid.NewString()[:8]
+	for j := range events {
+		events[j] = newBatchTestEvent(fmt.Sprintf("%s_%d", prefix, j))
+	}
+	if _, err := repo.InsertBatch(ctx, events); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		duplicates, err := repo.InsertBatch(ctx, events)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(duplicates) != len(events) {
+			b.Fatalf("got %d duplicates, want %d", len(duplicates), len(events))
+		}
+	}
+}
@@ -9,12 +9,13 @@ import (
 
 	"payment-receiver/domain"
 	"payment-receiver/infrastructure"
+	"payment-receiver/repository"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 )
 
-func setupTestDB(t *testing.T) *sql.DB {
+func setupTestDB(t testing.TB) *sql.DB {
 	dsn := os.Getenv("POSTGRES_DSN")
 	if dsn == "" {
 		// fallback for local/integration test environments
@@ -74,3 +75,69 @@ func TestInsert_DuplicateAggregateID(t *testing.T) {
 	err = repo.Insert(ctx, &dup)
 	assert.ErrorIs(t, err, infrastructure.ErrDuplicateKey)
 }
+
+func newBatchTestEvent(aggregateID string) *domain.OutboxEvent {
+	now := time.Now()
+	return &domain.OutboxEvent{
+		ID:          uuid.New(),
+		AggregateID: aggregateID,
+		EventType:   "payment_event",
+		Payload:     []byte{0x0a, 0x07, 0xff, 0x00},
+		Codec:       "protobuf",
+		Status:      domain.StatusPending,
+		CreatedAt:   now,
+		EventAt:     now,
+	}
+}
+
+func TestInsertBatch_ReportsDuplicates(t *testing.T) {
+	db := setupTestDB(t)
+	repo := infrastructure.NewPostgresOutbox(db)
+	ctx := context.Background()
+
+	prefix := "evt_batch_" + uuid.NewString()[:8]
+	existing := newBatchTestEvent(prefix + "_1")
+	assert.NoError(t, repo.Insert(ctx, existing))
+
+	batch := []*domain.OutboxEvent{
+		newBatchTestEvent(prefix + "_1"), // already stored
+		newBatchTestEvent(prefix + "_2"),
+		newBatchTestEvent(prefix + "_2"), // repeated within the batch
+		newBatchTestEvent(prefix + "_3"),
+	}
+	duplicates, err := repo.InsertBatch(ctx, batch)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 2}, duplicates)
+
+	stored, err := repo.FindByID(ctx, batch[3].ID)
+	assert.NoError(t, err)
+	assert.Equal(t, batch[3].Payload, []byte(stored.Payload))
+	assert.Equal(t, "protobuf", stored.Codec)
+}
+
+func TestMarkAttemptFailed_FailsAfterMaxAttemptsAndRequeueResets(t *testing.T) {
+	db := setupTestDB(t)
+	repo := infrastructure.NewPostgresOutbox(db)
+	ctx := context.Background()
+
+	ev := newBatchTestEvent("evt_attempts_" + uuid.NewString()[:8])
+	assert.NoError(t, repo.Insert(ctx, ev))
+
+	status, err := repo.MarkAttemptFailed(ctx, ev.ID, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.StatusPending, status)
+	status, err = repo.MarkAttemptFailed(ctx, ev.ID, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.StatusFailed, status)
+
+	_, err = repo.MarkAttemptFailed(ctx, ev.ID, 2)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	ok, err := repo.RequeueByID(ctx, ev.ID, domain.StatusFailed)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	stored, err := repo.FindByID(ctx, ev.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.StatusPending, stored.Status)
+	assert.Zero(t, stored.Attempts)
+}
@@ -0,0 +1,115 @@
+// Package infrastructure implements repository interfaces using concrete tools like Redis and Postgres.
+package infrastructure
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"payment-receiver/domain"
+	"payment-receiver/repository"
+)
+
+// PostgresPayments implements the PaymentRepository interface using PostgreSQL.
+type PostgresPayments struct {
+	db *sql.DB
+}
+
+var (
+	_ repository.PaymentRepository = (*PostgresPayments)(nil)
+	_ repository.PaymentReader     = (*PostgresPayments)(nil)
+)
+
+// NewPostgresPayments creates a new Postgres payment repository.
+func NewPostgresPayments(db *sql.DB) *PostgresPayments {
+	return &PostgresPayments{db: db}
+}
+
+// Upsert inserts the payment or applies it over an older stored state.
+func (r *PostgresPayments) Upsert(ctx context.Context, payment *domain.Payment) error {
+	now := time.Now()
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO payments (
+			id, amount, currency, method, status, occurred_at, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+		ON CONFLICT (id) DO UPDATE SET
+			amount = EXCLUDED.amount,
+			currency = EXCLUDED.currency,
+			method = EXCLUDED.method,
+			status = EXCLUDED.status,
+			occurred_at = EXCLUDED.occurred_at,
+			updated_at = EXCLUDED.updated_at
+		WHERE payments.occurred_at <= EXCLUDED.occurred_at
+	`, payment.ID, payment.Amount, payment.Currency, payment.Method, payment.Status, payment.OccurredAt, now)
+	return err
+}
+
+// FindByID retrieves the current state of a payment.
+func (r *PostgresPayments) FindByID(ctx context.Context, id string) (*domain.Payment, error) {
+	row := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT id, amount, currency, method, status, occurred_at, created_at, updated_at
+		FROM payments
+		WHERE id = $1
+	`, id)
+	p, err := scanPayment(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.ErrNotFound
+	}
+	return p, err
+}
+
+// List retrieves payments matching filter, newest first.
+func (r *PostgresPayments) List(
+	ctx context.Context,
+	filter repository.PaymentFilter,
+) ([]*domain.Payment, error) {
+	var f queryFilter
+	if filter.Status != "" {
+		f.add("status = %s", filter.Status)
+	}
+	if filter.Currency != "" {
+		f.add("currency = %s", filter.Currency)
+	}
+	if !filter.From.IsZero() {
+		f.add("occurred_at >= %s", filter.From)
+	}
+	if !filter.To.IsZero() {
+		f.add("occurred_at < %s", filter.To)
+	}
+	if filter.After != nil {
+		f.add("(occurred_at, id) < (%s, %s)", filter.After.OccurredAt, filter.After.ID)
+	}
+	query, args := f.build(`
+		SELECT id, amount, currency, method, status, occurred_at, created_at, updated_at
+		FROM payments`, "occurred_at DESC, id DESC", filter.Limit)
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Println("failed to close rows:", err)
+		}
+	}()
+
+	var payments []*domain.Payment
+	for rows.Next() {
+		p, err := scanPayment(rows)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, p)
+	}
+	return payments, rows.Err()
+}
+
+func scanPayment(row rowScanner) (*domain.Payment, error) {
+	var p domain.Payment
+	if err := row.Scan(&p.ID, &p.Amount, &p.Currency, &p.Method, &p.Status, &p.OccurredAt, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
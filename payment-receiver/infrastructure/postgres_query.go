@@ -0,0 +1,34 @@
+// Package infrastructure implements repository interfaces using concrete tools like Redis and Postgres.
+package infrastructure
+
+import (
+	"fmt"
+	"strings"
+)
+
+// queryFilter accumulates WHERE conditions with numbered placeholders.
+type queryFilter struct {
+	conds []string
+	args  []any
+}
+
+// add appends a condition; each %s in format is replaced by the placeholder
+// of the matching value.
+func (f *queryFilter) add(format string, vals ...any) {
+	placeholders := make([]any, len(vals))
+	for i, v := range vals {
+		f.args = append(f.args, v)
+		placeholders[i] = fmt.Sprintf("$%d", len(f.args))
+	}
+	f.conds = append(f.conds, fmt.Sprintf(format, placeholders...))
+}
+
+// build appends the WHERE clause, orderBy and a LIMIT to query.
+func (f *queryFilter) build(query, orderBy string, limit int) (string, []any) {
+	if len(f.conds) > 0 {
+		query += "\n\t\tWHERE " + strings.Join(f.conds, " AND ")
+	}
+	args := append(f.args, limit)
+	query += fmt.Sprintf("\n\t\tORDER BY %s\n\t\tLIMIT $%d", orderBy, len(args))
+	return query, args
+}
@@ -0,0 +1,76 @@
+// Package infrastructure implements repository interfaces using concrete tools like Redis and Postgres.
+package infrastructure
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+
+	"payment-receiver/repository"
+)
+
+// PostgresTxManager implements the TxManager interface using PostgreSQL.
+type PostgresTxManager struct {
+	db *sql.DB
+}
+
+var _ repository.TxManager = (*PostgresTxManager)(nil)
+
+// NewPostgresTxManager creates a new Postgres transaction manager.
+func NewPostgresTxManager(db *sql.DB) *PostgresTxManager {
+	return &PostgresTxManager{db: db}
+}
+
+type txKey struct{}
+
+// WithinTx runs fn in a transaction carried by the context passed to it.
+func (m *PostgresTxManager) WithinTx(
+	ctx context.Context,
+	fn func(ctx context.Context) error,
+) (err error) {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Println("failed to roll back transaction:", rbErr)
+			}
+		}
+	}()
+
+	if err = fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// dbtx is the subset of *sql.DB and *sql.Tx used by the repositories. It
+// satisfies outboxdb.DBTX.
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// conn returns the transaction bound to ctx by WithinTx, or db otherwise.
+func conn(ctx context.Context, db *sql.DB) dbtx {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return db
+}
@@ -0,0 +1,48 @@
+package infrastructure_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"payment-receiver/domain"
+	"payment-receiver/infrastructure"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithinTx_RollsBackPaymentAndOutbox(t *testing.T) {
+	db := setupTestDB(t)
+	txm := infrastructure.NewPostgresTxManager(db)
+	outbox := infrastructure.NewPostgresOutbox(db)
+	payments := infrastructure.NewPostgresPayments(db)
+
+	ctx := context.Background()
+	id := "evt_tx_" + uuid.NewString()
+	errAbort := errors.New("abort")
+
+	err := txm.WithinTx(ctx, func(ctx context.Context) error {
+		require.NoError(t, payments.Upsert(ctx, &domain.Payment{
+			ID: id, Amount: 100, Currency: "USD", Method: "card", Status: domain.StatusPaid,
+			OccurredAt: time.Now(),
+		}))
+		require.NoError(t, outbox.Insert(ctx, &domain.OutboxEvent{
+			ID: uuid.New(), AggregateID: id, EventType: "payment_event",
+			Payload: []byte(`{}`), Status: domain.StatusPending,
+			CreatedAt: time.Now(), EventAt: time.Now(),
+		}))
+		return errAbort
+	})
+	assert.ErrorIs(t, err, errAbort)
+
+	exists, err := outbox.ExistsByAggregateID(ctx, id)
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	var count int
+	require.NoError(t, db.QueryRowContext(ctx, `SELECT count(*) FROM payments WHERE id = $1`, id).Scan(&count))
+	assert.Zero(t, count)
+}
@@ -0,0 +1,138 @@
+// Package infrastructure implements repository interfaces using concrete tools like Redis and Postgres.
+package infrastructure
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"payment-receiver/domain"
+	"payment-receiver/repository"
+
+	"github.com/google/uuid"
+)
+
+// PostgresWebhookDeliveries implements the WebhookDeliveryRepository interface using PostgreSQL.
+type PostgresWebhookDeliveries struct {
+	db *sql.DB
+}
+
+var _ repository.WebhookDeliveryRepository = (*PostgresWebhookDeliveries)(nil)
+
+// NewPostgresWebhookDeliveries creates a new Postgres webhook delivery repository.
+func NewPostgresWebhookDeliveries(db *sql.DB) *PostgresWebhookDeliveries {
+	return &PostgresWebhookDeliveries{db: db}
+}
+
+// Record stores a webhook delivery.
+func (r *PostgresWebhookDeliveries) Record(ctx context.Context, d *domain.WebhookDelivery) error {
+	headers := d.Headers
+	if headers == nil {
+		headers = map[string][]string{}
+	}
+	rawHeaders, err := json.Marshal(headers)
+	if err != nil {
+		return fmt.Errorf("failed to encode headers: %w", err)
+	}
+	body := d.Body
+	if body == nil {
+		body = []byte{}
+	}
+
+	_, err = conn(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO webhook_deliveries (
+			id, received_at, method, path, source_ip, headers, body,
+			signature_status, status_code, outbox_event_id
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`, d.ID, d.ReceivedAt, d.Method, d.Path, d.SourceIP, rawHeaders, body,
+		d.SignatureStatus, d.StatusCode, d.OutboxEventID)
+	return err
+}
+
+// FindByID retrieves a single webhook delivery.
+func (r *PostgresWebhookDeliveries) FindByID(ctx context.Context, id uuid.UUID) (*domain.WebhookDelivery, error) {
+	row := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT id, received_at, method, path, source_ip, headers, body,
+			signature_status, status_code, outbox_event_id
+		FROM webhook_deliveries
+		WHERE id = $1
+	`, id)
+	d, err := scanWebhookDelivery(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.ErrNotFound
+	}
+	return d, err
+}
+
+// List retrieves deliveries matching filter, newest first.
+func (r *PostgresWebhookDeliveries) List(
+	ctx context.Context,
+	filter repository.WebhookDeliveryFilter,
+) ([]*domain.WebhookDelivery, error) {
+	var f queryFilter
+	if !filter.Since.IsZero() {
+		f.add("received_at >= %s", filter.Since)
+	}
+	if filter.OutboxEventID != nil {
+		f.add("outbox_event_id = %s", *filter.OutboxEventID)
+	}
+	if filter.StatusCode != 0 {
+		f.add("status_code = %s", filter.StatusCode)
+	}
+	query, args := f.build(`
+		SELECT id, received_at, method, path, source_ip, headers, body,
+			signature_status, status_code, outbox_event_id
+		FROM webhook_deliveries`, "received_at DESC, id DESC", filter.Limit)
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Println("failed to close rows:", err)
+		}
+	}()
+
+	var deliveries []*domain.WebhookDelivery
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// DeleteReceivedBefore removes deliveries received before t.
+func (r *PostgresWebhookDeliveries) DeleteReceivedBefore(ctx context.Context, t time.Time) (int64, error) {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `
+		DELETE FROM webhook_deliveries WHERE received_at < $1
+	`, t)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func scanWebhookDelivery(row rowScanner) (*domain.WebhookDelivery, error) {
+	var d domain.WebhookDelivery
+	var rawHeaders []byte
+	var outboxEventID uuid.NullUUID
+	if err := row.Scan(&d.ID, &d.ReceivedAt, &d.Method, &d.Path, &d.SourceIP, &rawHeaders, &d.Body,
+		&d.SignatureStatus, &d.StatusCode, &outboxEventID); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(rawHeaders, &d.Headers); err != nil {
+		return nil, fmt.Errorf("failed to decode headers: %w", err)
+	}
+	if outboxEventID.Valid {
+		d.OutboxEventID = &outboxEventID.UUID
+	}
+	return &d, nil
+}
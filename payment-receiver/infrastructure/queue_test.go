@@ -0,0 +1,35 @@
+package infrastructure_test
+
+import (
+	"testing"
+	"time"
+
+	"payment-receiver/codec"
+	"payment-receiver/domain"
+	pb "payment-receiver/gen/proto"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testEventOptions adjusts the payment event built by newTestOutboxEvent.
+// Zero fields keep the defaults: status "paid", occurred now.
+type testEventOptions struct {
+	Status  string
+	EventAt time.Time
+}
+
+// newTestOutboxEvent builds a protobuf payment_event for the queue tests.
+func newTestOutboxEvent(t *testing.T, aggregateID string, opts testEventOptions) *domain.OutboxEvent {
+	t.Helper()
+	if opts.Status == "" {
+		opts.Status = "paid"
+	}
+	if opts.EventAt.IsZero() {
+		opts.EventAt = time.Now()
+	}
+	payment := &pb.PaymentEvent{Id: aggregateID, Amount: 1200, Currency: "USD", Method: "card", Status: opts.Status}
+	domain.SetProtoOccurredAt(payment, opts.EventAt)
+	ev, err := domain.NewOutboxEvent(aggregateID, "payment_event", opts.EventAt, payment, codec.Protobuf)
+	require.NoError(t, err)
+	return ev
+}
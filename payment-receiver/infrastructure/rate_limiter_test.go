@@ -0,0 +1,98 @@
+package infrastructure_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"payment-receiver/infrastructure"
+	"payment-receiver/usecase"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryRateLimiter_TokenBucket(t *testing.T) {
+	limiter := infrastructure.NewMemoryRateLimiter()
+	ctx := context.Background()
+	limit := usecase.RateLimit{Rate: 1, Burst: 2}
+
+	for i := 0; i < 2; i++ {
+		allowed, _, err := limiter.Allow(ctx, "ip:203.0.113.7", limit)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	}
+
+	allowed, retryAfter, err := limiter.Allow(ctx, "ip:203.0.113.7", limit)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.InDelta(t, time.Second, retryAfter, float64(50*time.Millisecond))
+
+	allowed, _, err = limiter.Allow(ctx, "ip:198.51.100.9", limit)
+	require.NoError(t, err)
+	assert.True(t, allowed, "other keys have their own bucket")
+}
+
+func TestMemoryRateLimiter_Refills(t *testing.T) {
+	limiter := infrastructure.NewMemoryRateLimiter()
+	ctx := context.Background()
+	limit := usecase.RateLimit{Rate: 50, Burst: 1}
+
+	allowed, _, err := limiter.Allow(ctx, "k", limit)
+	require.NoError(t, err)
+	require.True(t, allowed)
+	allowed, _, err = limiter.Allow(ctx, "k", limit)
+	require.NoError(t, err)
+	require.False(t, allowed)
+
+	time.Sleep(30 * time.Millisecond)
+	allowed, _, err = limiter.Allow(ctx, "k", limit)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+}
+
+func TestRedisRateLimiter_SharesBucketAcrossInstances(t *testing.T) {
+	mr := miniredis.RunT(t)
+	cfg := infrastructure.RedisClientConfig{Addrs: []string{mr.Addr()}}
+	a, err := infrastructure.NewRedisRateLimiter(cfg, "ratelimit:")
+	require.NoError(t, err)
+	t.Cleanup(a.Close)
+	b, err := infrastructure.NewRedisRateLimiter(cfg, "ratelimit:")
+	require.NoError(t, err)
+	t.Cleanup(b.Close)
+
+	ctx := context.Background()
+	limit := usecase.RateLimit{Rate: 1, Burst: 2}
+
+	allowed, _, err := a.Allow(ctx, "provider:acme", limit)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	allowed, _, err = b.Allow(ctx, "provider:acme", limit)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	allowed, retryAfter, err := a.Allow(ctx, "provider:acme", limit)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.InDelta(t, time.Second, retryAfter, float64(100*time.Millisecond))
+
+	assert.True(t, mr.Exists("ratelimit:provider:acme"))
+	assert.Greater(t, mr.TTL("ratelimit:provider:acme"), time.Duration(0))
+}
+
+func TestRedisRateLimiter_ReturnsErrorWhenUnavailable(t *testing.T) {
+	mr := miniredis.RunT(t)
+	limiter, err := infrastructure.NewRedisRateLimiter(infrastructure.RedisClientConfig{Addrs: []string{mr.Addr()}}, "ratelimit:")
+	require.NoError(t, err)
+	t.Cleanup(limiter.Close)
+	mr.Close()
+
+	_, _, err = limiter.Allow(context.Background(), "ip:203.0.113.7", usecase.RateLimit{Rate: 1, Burst: 1})
+	assert.Error(t, err)
+}
+
+func TestNewRedisRateLimiter_RequiresAddress(t *testing.T) {
+	_, err := infrastructure.NewRedisRateLimiter(infrastructure.RedisClientConfig{}, "ratelimit:")
+	assert.Error(t, err)
+}
@@ -3,62 +3,277 @@ package infrastructure
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"payment-receiver/domain"
-	pb "payment-receiver/gen/proto" // Protobuf-generated Go code
+	"payment-receiver/eventtype"
 	"payment-receiver/usecase"
 
 	redis "github.com/redis/go-redis/v9"
-	"google.golang.org/protobuf/proto"
 )
 
+// DefaultRedisStream is the stream used when RedisQueueConfig.Stream is empty.
+const DefaultRedisStream = "payment-events"
+
+// DefaultRedisDedupTTL is used when RedisQueueConfig.DedupTTL is zero.
+const DefaultRedisDedupTTL = 24 * time.Hour
+
+// RedisClientConfig configures a Redis connection. A single address connects
+// to a standalone server, several addresses without MasterName connect to a
+// Cluster, and with MasterName they are treated as Sentinel addresses.
+type RedisClientConfig struct {
+	Addrs      []string
+	MasterName string
+	Username   string
+	Password   string
+	DB         int
+	// TLS enables TLS on every connection when non-nil.
+	TLS *tls.Config
+}
+
+func newRedisClient(cfg RedisClientConfig) redis.UniversalClient {
+	return redis.NewUniversalClient(&redis.UniversalOptions{
+		Addrs:      cfg.Addrs,
+		MasterName: cfg.MasterName,
+		Username:   cfg.Username,
+		Password:   cfg.Password,
+		DB:         cfg.DB,
+		TLSConfig:  cfg.TLS,
+	})
+}
+
+// RedisClientConfigFromEnv reads REDIS_ADDR (comma-separated, see
+// RedisClientConfig), REDIS_MASTER_NAME, REDIS_USERNAME, REDIS_PASSWORD,
+// REDIS_DB, REDIS_TLS and REDIS_TLS_SERVER_NAME.
+func RedisClientConfigFromEnv() (RedisClientConfig, error) {
+	cfg := RedisClientConfig{
+		MasterName: os.Getenv("REDIS_MASTER_NAME"),
+		Username:   os.Getenv("REDIS_USERNAME"),
+		Password:   os.Getenv("REDIS_PASSWORD"),
+	}
+	for _, addr := range strings.Split(os.Getenv("REDIS_ADDR"), ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			cfg.Addrs = append(cfg.Addrs, addr)
+		}
+	}
+
+	if v := os.Getenv("REDIS_DB"); v != "" {
+		db, err := strconv.Atoi(v)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid REDIS_DB: %w", err)
+		}
+		cfg.DB = db
+	}
+	if v := os.Getenv("REDIS_TLS"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid REDIS_TLS: %w", err)
+		}
+		if enabled {
+			cfg.TLS = &tls.Config{
+				MinVersion: tls.VersionTLS12,
+				ServerName: os.Getenv("REDIS_TLS_SERVER_NAME"),
+			}
+		}
+	}
+	return cfg, nil
+}
+
+// RedisQueueConfig configures the Redis client and stream behind a RedisQueue.
+type RedisQueueConfig struct {
+	RedisClientConfig
+
+	// Stream is the stream key, defaulting to DefaultRedisStream.
+	Stream string
+	// Source is used as the CloudEvents source attribute of published events.
+	Source string
+
+	// MaxLen approximately caps the stream at this many entries.
+	MaxLen int64
+	// MaxAge approximately trims entries older than this via MINID.
+	// Only one of MaxLen and MaxAge may be set.
+	MaxAge time.Duration
+
+	// IDFromEventTime makes XADD use the event's EventAt as the entry ID
+	// timestamp. Redis assigns the ID instead when that would not be greater
+	// than the newest entry, so the stream stays append-only.
+	IDFromEventTime bool
+
+	// DedupTTL is how long a published event ID is remembered so that a
+	// republish returns the original entry, defaulting to DefaultRedisDedupTTL.
+	DedupTTL time.Duration
+}
+
 // RedisQueue implements the Queue interface using Redis.
 type RedisQueue struct {
-	rdb     *redis.Client
-	queue   string
-	timeout time.Duration
+	rdb             redis.UniversalClient
+	queue           string
+	source          string
+	types           *eventtype.Registry
+	maxLen          int64
+	maxAge          time.Duration
+	idFromEventTime bool
+	dedupTTL        time.Duration
+	timeout         time.Duration
 }
 
 var _ usecase.OutboxQueue = (*RedisQueue)(nil)
 
 // NewRedisQueue creates and initializes a new RedisQueue instance.
-func NewRedisQueue(addr, password, queueName string) *RedisQueue {
-	rdb := redis.NewClient(&redis.Options{
-		Addr:     addr,
-		Password: password,
-		DB:       0,
-	})
+// types, when non-nil, supplies dataschema URIs and per-type payload validation.
+func NewRedisQueue(cfg RedisQueueConfig, types *eventtype.Registry) (*RedisQueue, error) {
+	if len(cfg.Addrs) == 0 {
+		return nil, errors.New("redis: at least one address is required")
+	}
+	if cfg.MaxLen < 0 || cfg.MaxAge < 0 {
+		return nil, errors.New("redis: stream trimming limits must not be negative")
+	}
+	if cfg.MaxLen > 0 && cfg.MaxAge > 0 {
+		return nil, errors.New("redis: MaxLen and MaxAge are mutually exclusive")
+	}
+	if cfg.DedupTTL < 0 {
+		return nil, errors.New("redis: DedupTTL must not be negative")
+	}
+	if cfg.DedupTTL == 0 {
+		cfg.DedupTTL = DefaultRedisDedupTTL
+	}
+	if cfg.Stream == "" {
+		cfg.Stream = DefaultRedisStream
+	}
+
+	rdb := newRedisClient(cfg.RedisClientConfig)
 
 	return &RedisQueue{
-		rdb:     rdb,
-		queue:   queueName,
-		timeout: 5 * time.Second,
-	}
+		rdb:             rdb,
+		queue:           cfg.Stream,
+		source:          cfg.Source,
+		types:           types,
+		maxLen:          cfg.MaxLen,
+		maxAge:          cfg.MaxAge,
+		idFromEventTime: cfg.IDFromEventTime,
+		dedupTTL:        cfg.DedupTTL,
+		timeout:         5 * time.Second,
+	}, nil
 }
 
-// Enqueue serializes the event payload using Protobuf and sends it to Redis Stream.
+// publishScript records the outbox event ID under a dedup key and appends the
+// entry in one atomic step. A replayed event returns the stream ID stored on
+// its first publish instead of appending a duplicate.
+//
+// KEYS[1] dedup key, KEYS[2] stream
+// ARGV[1] dedup TTL in ms, ARGV[2] entry ID or "*", ARGV[3] "" | "MAXLEN" | "MINID",
+// ARGV[4] trim threshold, ARGV[5..] field/value pairs
+var publishScript = redis.NewScript(`
+local existing = redis.call('GET', KEYS[1])
+if existing then
+	return {existing, 1}
+end
+
+local function xadd(call, id)
+	local args = {'XADD', KEYS[2]}
+	if ARGV[3] ~= '' then
+		table.insert(args, ARGV[3])
+		table.insert(args, '~')
+		table.insert(args, ARGV[4])
+	end
+	table.insert(args, id)
+	for i = 5, #ARGV do
+		table.insert(args, ARGV[i])
+	end
+	return call(unpack(args))
+end
+
+-- An explicit ID that does not sort after the stream top falls back to an
+-- ID assigned by Redis; any other error is returned as is.
+local id
+if ARGV[2] ~= '*' then
+	local res = xadd(redis.pcall, ARGV[2])
+	if type(res) == 'string' then
+		id = res
+	elseif type(res) == 'table' and res.err and not string.find(res.err, 'equal or smaller') then
+		return redis.error_reply(res.err)
+	end
+end
+if not id then
+	id = xadd(redis.call, '*')
+end
+
+redis.call('SET', KEYS[1], id, 'PX', ARGV[1])
+return {id, 0}
+`)
+
+// Enqueue publishes the stored payload verbatim to Redis Stream, wrapped in a
+// CloudEvents envelope that carries its type and content type.
 func (q *RedisQueue) Enqueue(ctx context.Context, event *domain.OutboxEvent) error {
+	_, _, err := q.Publish(ctx, event)
+	return err
+}
+
+// Publish appends event to the stream at most once per DedupTTL and returns
+// its stream ID. replayed reports that the event had already been published,
+// in which case the ID of the original entry is returned.
+func (q *RedisQueue) Publish(ctx context.Context, event *domain.OutboxEvent) (id string, replayed bool, err error) {
 	ctx, cancel := context.WithTimeout(ctx, q.timeout)
 	defer cancel()
 
-	// Unmarshal OutboxEvent.Payload into Protobuf model
-	var paymentEvent pb.PaymentEvent
-	if err := proto.Unmarshal(event.Payload, &paymentEvent); err != nil {
-		return fmt.Errorf("failed to unmarshal protobuf payload: %w", err)
+	env, err := newOutboxEnvelope(event, q.source, q.types)
+	if err != nil {
+		return "", false, err
+	}
+
+	entryID := "*"
+	if q.idFromEventTime && !event.EventAt.IsZero() {
+		entryID = fmt.Sprintf("%d-0", event.EventAt.UnixMilli())
+	}
+	trim, threshold := "", ""
+	switch {
+	case q.maxLen > 0:
+		trim, threshold = "MAXLEN", strconv.FormatInt(q.maxLen, 10)
+	case q.maxAge > 0:
+		trim, threshold = "MINID", fmt.Sprintf("%d-0", time.Now().Add(-q.maxAge).UnixMilli())
+	}
+
+	values := env.Encode()
+	fields := make([]string, 0, len(values))
+	for k := range values {
+		fields = append(fields, k)
+	}
+	sort.Strings(fields)
+
+	args := []interface{}{q.dedupTTL.Milliseconds(), entryID, trim, threshold}
+	for _, k := range fields {
+		args = append(args, k, values[k])
 	}
 
-	// Marshal back to binary protobuf for transport (optional, could use Payload as-is)
-	data, err := proto.Marshal(&paymentEvent)
+	res, err := publishScript.Run(ctx, q.rdb, []string{q.dedupKey(event.ID.String()), q.queue}, args...).Slice()
 	if err != nil {
-		return fmt.Errorf("failed to marshal protobuf: %w", err)
+		return "", false, fmt.Errorf("redis publish: %w", err)
+	}
+	if len(res) != 2 {
+		return "", false, fmt.Errorf("redis publish: unexpected reply %v", res)
 	}
+	id, _ = res[0].(string)
+	flag, _ := res[1].(int64)
+	return id, flag == 1, nil
+}
 
-	return q.rdb.XAdd(ctx, &redis.XAddArgs{
-		Stream: q.queue,
-		Values: map[string]interface{}{
-			"data": data,
-		},
-	}).Err()
+// Close releases the underlying Redis connections.
+func (q *RedisQueue) Close() {
+	_ = q.rdb.Close()
+}
+
+// dedupKey returns the key recording that eventID was published. It shares
+// the stream's hash slot so the script stays valid on Redis Cluster.
+func (q *RedisQueue) dedupKey(eventID string) string {
+	if strings.Contains(q.queue, "{") {
+		return q.queue + ":published:" + eventID
+	}
+	return "{" + q.queue + "}:published:" + eventID
 }
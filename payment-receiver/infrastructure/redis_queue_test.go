@@ -0,0 +1,275 @@
+package infrastructure_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"payment-receiver/codec"
+	"payment-receiver/domain"
+	"payment-receiver/envelope"
+	"payment-receiver/eventtype"
+	pb "payment-receiver/gen/proto"
+	"payment-receiver/infrastructure"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestRedisQueue_Enqueue_MixedCodecs(t *testing.T) {
+	mr := miniredis.RunT(t)
+	queue := newRedisQueue(t, infrastructure.RedisQueueConfig{RedisClientConfig: redisClientConfig(mr.Addr())})
+
+	payment := &pb.PaymentEvent{
+		Id:         "evt_001",
+		Amount:     1200,
+		Currency:   "USD",
+		Method:     "card",
+		Status:     "paid",
+		OccurredAt: timestamppb.New(time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)),
+	}
+
+	payloads := map[string][]byte{}
+	for _, c := range []codec.Codec{codec.Protobuf, codec.JSON, codec.Avro} {
+		ev, err := domain.NewOutboxEvent(payment.Id, "payment_event", time.Now(), payment, c)
+		require.NoError(t, err)
+		require.NoError(t, queue.Enqueue(context.Background(), ev), c.Name())
+		payloads[c.ContentType()] = ev.Payload
+	}
+
+	entries, err := mr.Stream("payment-events")
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+
+	for _, entry := range entries {
+		values := map[string]interface{}{}
+		for i := 0; i+1 < len(entry.Values); i += 2 {
+			values[entry.Values[i]] = entry.Values[i+1]
+		}
+		env, err := envelope.Decode(values)
+		require.NoError(t, err)
+		assert.Equal(t, "type.googleapis.com/payment.PaymentEvent", env.DataSchema)
+
+		stored := payloads[env.DataContentType]
+		require.NotNil(t, stored, env.DataContentType)
+		assert.Equal(t, stored, env.Data, "payload must be published verbatim")
+	}
+}
+
+func TestRedisQueue_Enqueue_PreservesUnknownFields(t *testing.T) {
+	mr := miniredis.RunT(t)
+	queue := newRedisQueue(t, infrastructure.RedisQueueConfig{RedisClientConfig: redisClientConfig(mr.Addr())})
+
+	payload, err := proto.Marshal(&pb.PaymentEvent{Id: "evt_001"})
+	require.NoError(t, err)
+	// Field 99 is unknown to this build, as if written by a newer producer.
+	payload = protowire.AppendTag(payload, 99, protowire.BytesType)
+	payload = protowire.AppendString(payload, "from the future")
+
+	err = queue.Enqueue(context.Background(), &domain.OutboxEvent{
+		ID:        uuid.New(),
+		EventType: "payment_event",
+		Payload:   payload,
+		Codec:     codec.NameProtobuf,
+	})
+	require.NoError(t, err)
+
+	entries, err := mr.Stream("payment-events")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Contains(t, entries[0].Values, string(payload))
+}
+
+func TestRedisQueue_Enqueue_RejectsInvalidPayload(t *testing.T) {
+	mr := miniredis.RunT(t)
+	queue := newRedisQueue(t, infrastructure.RedisQueueConfig{RedisClientConfig: redisClientConfig(mr.Addr())})
+
+	err := queue.Enqueue(context.Background(), &domain.OutboxEvent{
+		ID:        uuid.New(),
+		EventType: "payment_event",
+		Payload:   []byte("{not protobuf"),
+		Codec:     codec.NameProtobuf,
+	})
+	assert.ErrorIs(t, err, eventtype.ErrInvalidPayload)
+	assert.False(t, mr.Exists("payment-events"))
+}
+
+func TestRedisQueue_Enqueue_UnknownCodec(t *testing.T) {
+	mr := miniredis.RunT(t)
+	queue := newRedisQueue(t, infrastructure.RedisQueueConfig{RedisClientConfig: redisClientConfig(mr.Addr())})
+
+	err := queue.Enqueue(context.Background(), &domain.OutboxEvent{
+		EventType: "payment_event",
+		Payload:   []byte("<xml/>"),
+		Codec:     "xml",
+	})
+	assert.ErrorIs(t, err, codec.ErrUnknownCodec)
+}
+
+func redisClientConfig(addr string) infrastructure.RedisClientConfig {
+	return infrastructure.RedisClientConfig{Addrs: []string{addr}}
+}
+
+func newRedisQueue(t *testing.T, cfg infrastructure.RedisQueueConfig) *infrastructure.RedisQueue {
+	t.Helper()
+	queue, err := infrastructure.NewRedisQueue(cfg, eventtype.Default())
+	require.NoError(t, err)
+	t.Cleanup(queue.Close)
+	return queue
+}
+
+func TestNewRedisQueue_InvalidConfig(t *testing.T) {
+	cases := map[string]infrastructure.RedisQueueConfig{
+		"no address":        {},
+		"negative max len":  {RedisClientConfig: redisClientConfig("localhost:6379"), MaxLen: -1},
+		"both trim options": {RedisClientConfig: redisClientConfig("localhost:6379"), MaxLen: 10, MaxAge: time.Hour},
+	}
+	for name, cfg := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := infrastructure.NewRedisQueue(cfg, nil)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestRedisQueue_Enqueue_TrimsByMaxLen(t *testing.T) {
+	mr := miniredis.RunT(t)
+	queue := newRedisQueue(t, infrastructure.RedisQueueConfig{
+		RedisClientConfig: redisClientConfig(mr.Addr()),
+		Stream:            "trimmed",
+		MaxLen:            2,
+	})
+
+	for i := 0; i < 5; i++ {
+		ev := newTestOutboxEvent(t, uuid.NewString(), testEventOptions{})
+		require.NoError(t, queue.Enqueue(context.Background(), ev))
+	}
+
+	entries, err := mr.Stream("trimmed")
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
+
+func TestRedisQueue_Enqueue_TrimsByMaxAge(t *testing.T) {
+	mr := miniredis.RunT(t)
+	queue := newRedisQueue(t, infrastructure.RedisQueueConfig{
+		RedisClientConfig: redisClientConfig(mr.Addr()),
+		MaxAge:            time.Hour,
+		IDFromEventTime:   true,
+	})
+
+	old := newTestOutboxEvent(t, "evt_old", testEventOptions{EventAt: time.Now().Add(-2 * time.Hour)})
+	require.NoError(t, queue.Enqueue(context.Background(), old))
+	fresh := newTestOutboxEvent(t, "evt_fresh", testEventOptions{})
+	require.NoError(t, queue.Enqueue(context.Background(), fresh))
+
+	entries, err := mr.Stream(infrastructure.DefaultRedisStream)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, fmt.Sprintf("%d-0", fresh.EventAt.UnixMilli()), entries[0].ID)
+}
+
+func TestRedisQueue_Enqueue_IDFromEventTime(t *testing.T) {
+	mr := miniredis.RunT(t)
+	queue := newRedisQueue(t, infrastructure.RedisQueueConfig{
+		RedisClientConfig: redisClientConfig(mr.Addr()),
+		IDFromEventTime:   true,
+	})
+
+	at := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, queue.Enqueue(context.Background(), newTestOutboxEvent(t, "evt_1", testEventOptions{EventAt: at})))
+	// Same millisecond: Redis rejects the explicit ID, so one is assigned.
+	require.NoError(t, queue.Enqueue(context.Background(), newTestOutboxEvent(t, "evt_2", testEventOptions{EventAt: at})))
+
+	entries, err := mr.Stream(infrastructure.DefaultRedisStream)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, fmt.Sprintf("%d-0", at.UnixMilli()), entries[0].ID)
+	assert.NotEqual(t, entries[0].ID, entries[1].ID)
+}
+
+func TestRedisQueue_Publish_ReplayReturnsOriginalID(t *testing.T) {
+	mr := miniredis.RunT(t)
+	queue := newRedisQueue(t, infrastructure.RedisQueueConfig{RedisClientConfig: redisClientConfig(mr.Addr())})
+	ev := newTestOutboxEvent(t, "evt_1", testEventOptions{})
+
+	firstID, replayed, err := queue.Publish(context.Background(), ev)
+	require.NoError(t, err)
+	assert.False(t, replayed)
+
+	// Simulates MarkAsSent failing after a successful publish.
+	secondID, replayed, err := queue.Publish(context.Background(), ev)
+	require.NoError(t, err)
+	assert.True(t, replayed)
+	assert.Equal(t, firstID, secondID)
+
+	entries, err := mr.Stream(infrastructure.DefaultRedisStream)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, firstID, entries[0].ID)
+}
+
+func TestRedisQueue_Publish_DedupKeyExpires(t *testing.T) {
+	mr := miniredis.RunT(t)
+	queue := newRedisQueue(t, infrastructure.RedisQueueConfig{
+		RedisClientConfig: redisClientConfig(mr.Addr()),
+		DedupTTL:          time.Minute,
+	})
+	ev := newTestOutboxEvent(t, "evt_1", testEventOptions{})
+
+	firstID, _, err := queue.Publish(context.Background(), ev)
+	require.NoError(t, err)
+
+	key := "{" + infrastructure.DefaultRedisStream + "}:published:" + ev.ID.String()
+	stored, err := mr.Get(key)
+	require.NoError(t, err)
+	assert.Equal(t, firstID, stored)
+	assert.Equal(t, time.Minute, mr.TTL(key))
+
+	mr.FastForward(2 * time.Minute)
+
+	_, replayed, err := queue.Publish(context.Background(), ev)
+	require.NoError(t, err)
+	assert.False(t, replayed)
+
+	entries, err := mr.Stream(infrastructure.DefaultRedisStream)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
+
+func TestRedisQueue_Publish_InvalidPayloadIsNotRecorded(t *testing.T) {
+	mr := miniredis.RunT(t)
+	queue := newRedisQueue(t, infrastructure.RedisQueueConfig{RedisClientConfig: redisClientConfig(mr.Addr())})
+	ev := newTestOutboxEvent(t, "evt_1", testEventOptions{})
+	ev.Codec = "unknown"
+
+	_, _, err := queue.Publish(context.Background(), ev)
+	require.Error(t, err)
+	assert.Empty(t, mr.Keys())
+}
+
+func TestRedisClientConfigFromEnv(t *testing.T) {
+	t.Setenv("REDIS_ADDR", "10.0.0.1:26379, 10.0.0.2:26379")
+	t.Setenv("REDIS_MASTER_NAME", "mymaster")
+	t.Setenv("REDIS_DB", "2")
+	t.Setenv("REDIS_TLS", "true")
+	t.Setenv("REDIS_TLS_SERVER_NAME", "redis.internal")
+
+	cfg, err := infrastructure.RedisClientConfigFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.1:26379", "10.0.0.2:26379"}, cfg.Addrs)
+	assert.Equal(t, "mymaster", cfg.MasterName)
+	assert.Equal(t, 2, cfg.DB)
+	require.NotNil(t, cfg.TLS)
+	assert.Equal(t, "redis.internal", cfg.TLS.ServerName)
+
+	t.Setenv("REDIS_DB", "two")
+	_, err = infrastructure.RedisClientConfigFromEnv()
+	assert.ErrorContains(t, err, "REDIS_DB")
+}
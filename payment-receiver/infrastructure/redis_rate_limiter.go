@@ -0,0 +1,103 @@
+// Package infrastructure implements repository interfaces using concrete tools like Redis and Postgres.
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"payment-receiver/usecase"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+// tokenBucketScript refills and takes one token from a bucket stored as a hash.
+//
+// KEYS[1] bucket key
+// ARGV[1] rate (tokens per second), ARGV[2] burst, ARGV[3] now in ms
+// Returns {allowed (0|1), retry after in ms}.
+var tokenBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+	tokens = burst
+	ts = now
+end
+
+local elapsed = math.max(0, now - ts)
+tokens = math.min(burst, tokens + elapsed * rate / 1000)
+
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) * 1000 / rate)
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst * 1000 / rate) + 1000)
+return {allowed, retry}
+`)
+
+// RedisRateLimiter implements the RateLimiter interface with token buckets
+// kept in Redis, so every replica shares one quota per key.
+type RedisRateLimiter struct {
+	rdb     redis.UniversalClient
+	prefix  string
+	timeout time.Duration
+}
+
+var _ usecase.RateLimiter = (*RedisRateLimiter)(nil)
+
+// NewRedisRateLimiter creates a rate limiter whose bucket keys start with prefix.
+func NewRedisRateLimiter(cfg RedisClientConfig, prefix string) (*RedisRateLimiter, error) {
+	if len(cfg.Addrs) == 0 {
+		return nil, errors.New("redis: at least one address is required")
+	}
+	return &RedisRateLimiter{
+		rdb:     newRedisClient(cfg),
+		prefix:  prefix,
+		timeout: 200 * time.Millisecond,
+	}, nil
+}
+
+// Allow takes a token from the shared bucket for key.
+func (l *RedisRateLimiter) Allow(
+	ctx context.Context,
+	key string,
+	limit usecase.RateLimit,
+) (bool, time.Duration, error) {
+	if !limit.Enabled() {
+		return true, 0, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, l.timeout)
+	defer cancel()
+
+	res, err := tokenBucketScript.Run(ctx, l.rdb, []string{l.prefix + key},
+		strconv.FormatFloat(limit.Rate, 'f', -1, 64),
+		limit.Burst,
+		time.Now().UnixMilli(),
+	).Int64Slice()
+	if err != nil {
+		return false, 0, fmt.Errorf("redis rate limit: %w", err)
+	}
+	if len(res) != 2 {
+		return false, 0, fmt.Errorf("redis rate limit: unexpected reply %v", res)
+	}
+	return res[0] == 1, time.Duration(res[1]) * time.Millisecond, nil
+}
+
+// Close releases the underlying Redis connections.
+func (l *RedisRateLimiter) Close() {
+	_ = l.rdb.Close()
+}
@@ -0,0 +1,119 @@
+package infrastructure_test
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSqlcGeneratedCodeIsUpToDate runs sqlc diff when sqlc is installed.
+func TestSqlcGeneratedCodeIsUpToDate(t *testing.T) {
+	if _, err := exec.LookPath("sqlc"); err != nil {
+		t.Skip("sqlc not installed")
+	}
+	cmd := exec.Command("sqlc", "diff")
+	cmd.Dir = ".."
+	out, err := cmd.CombinedOutput()
+	assert.NoError(t, err, "gen/outboxdb or gen/outboxpgx is stale, run `make sqlc`:\n%s", out)
+}
+
+// TestSqlcQueriesMatchGeneratedCode checks every query in queries/outbox.sql
+// against the constants sqlc generated for it in each output package, so
+// edits to the .sql file that were not regenerated fail even where sqlc is
+// not installed.
+func TestSqlcQueriesMatchGeneratedCode(t *testing.T) {
+	src, err := os.ReadFile("../queries/outbox.sql")
+	require.NoError(t, err)
+	want := sqlcQueries(string(src))
+	require.NotEmpty(t, want)
+
+	for _, pkg := range []string{"outboxdb", "outboxpgx"} {
+		t.Run(pkg, func(t *testing.T) {
+			got := generatedQueries(t, "../gen/"+pkg+"/outbox.sql.go")
+			assert.ElementsMatch(t, mapKeys(want), mapKeys(got), "queries and generated constants differ, run `make sqlc`")
+			for name, query := range want {
+				if gen, ok := got[name]; ok {
+					assert.Equal(t, query, gen, "query %s is stale, run `make sqlc`", name)
+				}
+			}
+		})
+	}
+}
+
+var sqlcParam = regexp.MustCompile(`sqlc\.n?arg\('(\w+)'\)|@(\w+)`)
+
+// sqlcQueries renders each named query the way sqlc embeds it in generated
+// code: comments dropped, trailing semicolon removed and named parameters
+// numbered in order of first use.
+func sqlcQueries(src string) map[string]string {
+	queries := make(map[string]string)
+	for _, block := range regexp.MustCompile(`(?m)^-- name: `).Split(src, -1)[1:] {
+		lines := strings.Split(block, "\n")
+		header := "-- name: " + lines[0]
+		var body []string
+		for _, l := range lines[1:] {
+			if !strings.HasPrefix(strings.TrimSpace(l), "--") {
+				body = append(body, l)
+			}
+		}
+		query := strings.TrimSuffix(strings.TrimSpace(strings.Join(body, "\n")), ";")
+
+		var params []string
+		query = sqlcParam.ReplaceAllStringFunc(query, func(m string) string {
+			sub := sqlcParam.FindStringSubmatch(m)
+			name := sub[1] + sub[2]
+			i := slices.Index(params, name)
+			if i < 0 {
+				params = append(params, name)
+				i = len(params) - 1
+			}
+			return fmt.Sprintf("$%d", i+1)
+		})
+		queries[strings.Fields(lines[0])[0]] = header + "\n" + query + "\n"
+	}
+	return queries
+}
+
+// generatedQueries returns the query constants of a sqlc output file keyed by
+// query name.
+func generatedQueries(t *testing.T, path string) map[string]string {
+	f, err := parser.ParseFile(token.NewFileSet(), path, nil, 0)
+	require.NoError(t, err)
+	queries := make(map[string]string)
+	ast.Inspect(f, func(n ast.Node) bool {
+		spec, ok := n.(*ast.ValueSpec)
+		if !ok || len(spec.Values) != 1 {
+			return true
+		}
+		lit, ok := spec.Values[0].(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			return true
+		}
+		value, err := strconv.Unquote(lit.Value)
+		require.NoError(t, err)
+		if name, ok := strings.CutPrefix(value, "-- name: "); ok {
+			queries[strings.Fields(name)[0]] = value
+		}
+		return true
+	})
+	return queries
+}
+
+func mapKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
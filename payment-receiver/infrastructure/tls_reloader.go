@@ -0,0 +1,162 @@
+// Package infrastructure implements repository interfaces using concrete tools like Redis and Postgres.
+package infrastructure
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// TLSReloader serves a certificate/key pair and, optionally, a client CA
+// bundle that are re-read from disk when the files change, so certificates
+// can be rotated without restarting the server.
+type TLSReloader struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string
+	clientAuth   tls.ClientAuthType
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	clientCA *x509.CertPool
+	modTimes map[string]time.Time
+}
+
+// NewTLSReloader loads the server certificate and the optional client CA
+// bundle. When clientCAFile is set, client certificates are checked against
+// it according to clientAuth.
+func NewTLSReloader(certFile, keyFile, clientCAFile string, clientAuth tls.ClientAuthType) (*TLSReloader, error) {
+	if certFile == "" || keyFile == "" {
+		return nil, errors.New("tls: certificate and key files are required")
+	}
+	r := &TLSReloader{
+		certFile:     certFile,
+		keyFile:      keyFile,
+		clientCAFile: clientCAFile,
+		clientAuth:   clientAuth,
+	}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload re-reads every file. On error the previously loaded material stays
+// in use.
+func (r *TLSReloader) Reload() error {
+	modTimes, err := r.statFiles()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("tls: failed to load key pair: %w", err)
+	}
+
+	var pool *x509.CertPool
+	if r.clientCAFile != "" {
+		pem, err := os.ReadFile(r.clientCAFile)
+		if err != nil {
+			return fmt.Errorf("tls: failed to read client CA bundle: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("tls: no certificates found in %s", r.clientCAFile)
+		}
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.clientCA = pool
+	r.modTimes = modTimes
+	r.mu.Unlock()
+	return nil
+}
+
+// TLSConfig returns a server configuration that always uses the most recently
+// loaded certificate and client CA bundle.
+func (r *TLSReloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: r.getCertificate,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+			cfg := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*r.cert},
+			}
+			if r.clientCA != nil {
+				cfg.ClientCAs = r.clientCA
+				cfg.ClientAuth = r.clientAuth
+			}
+			return cfg, nil
+		},
+	}
+}
+
+func (r *TLSReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// Watch polls the files every interval and reloads them after any of them
+// changes, until ctx is done.
+func (r *TLSReloader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !r.changed() {
+				continue
+			}
+			if err := r.Reload(); err != nil {
+				log.Printf("TLS certificates not reloaded: %v", err)
+				continue
+			}
+			log.Printf("TLS certificates reloaded")
+		}
+	}
+}
+
+func (r *TLSReloader) changed() bool {
+	modTimes, err := r.statFiles()
+	if err != nil {
+		return false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for name, t := range modTimes {
+		if !t.Equal(r.modTimes[name]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *TLSReloader) statFiles() (map[string]time.Time, error) {
+	modTimes := map[string]time.Time{}
+	for _, name := range []string{r.certFile, r.keyFile, r.clientCAFile} {
+		if name == "" {
+			continue
+		}
+		info, err := os.Stat(name)
+		if err != nil {
+			return nil, fmt.Errorf("tls: %w", err)
+		}
+		modTimes[name] = info.ModTime()
+	}
+	return modTimes, nil
+}
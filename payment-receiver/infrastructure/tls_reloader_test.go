@@ -0,0 +1,198 @@
+package infrastructure_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"payment-receiver/infrastructure"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns PEM encoded certificate and key for commonName.
+func (ca *testCA) issue(t *testing.T, serial int64, commonName string, usage x509.ExtKeyUsage) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{commonName},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeFile(t *testing.T, path string, data []byte, modTime time.Time) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+// serveTLS serves 200 OK over TLS with cfg and returns the address.
+func serveTLS(t *testing.T, cfg *tls.Config) string {
+	t.Helper()
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", cfg)
+	require.NoError(t, err)
+	srv := &http.Server{Handler: http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})}
+	go func() { _ = srv.Serve(ln) }()
+	t.Cleanup(func() { _ = srv.Close() })
+	return ln.Addr().String()
+}
+
+// peerSerial connects to addr and returns the server certificate's serial number.
+func peerSerial(t *testing.T, addr string, cfg *tls.Config) int64 {
+	t.Helper()
+	conn, err := tls.Dial("tcp", addr, cfg)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, conn.Handshake())
+	return conn.ConnectionState().PeerCertificates[0].SerialNumber.Int64()
+}
+
+func TestTLSReloader_ReloadsChangedCertificate(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	past := time.Now().Add(-time.Minute)
+
+	certPEM, keyPEM := ca.issue(t, 100, "webhook.local", x509.ExtKeyUsageServerAuth)
+	writeFile(t, certFile, certPEM, past)
+	writeFile(t, keyFile, keyPEM, past)
+
+	reloader, err := infrastructure.NewTLSReloader(certFile, keyFile, "", tls.NoClientCert)
+	require.NoError(t, err)
+	addr := serveTLS(t, reloader.TLSConfig())
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	client := &tls.Config{RootCAs: roots, ServerName: "webhook.local"}
+	assert.Equal(t, int64(100), peerSerial(t, addr, client))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go reloader.Watch(ctx, 10*time.Millisecond)
+
+	// A broken key pair keeps the previous certificate in use.
+	writeFile(t, certFile, []byte("not a certificate"), time.Now())
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int64(100), peerSerial(t, addr, client))
+
+	certPEM, keyPEM = ca.issue(t, 200, "webhook.local", x509.ExtKeyUsageServerAuth)
+	now := time.Now().Add(time.Second)
+	writeFile(t, keyFile, keyPEM, now)
+	writeFile(t, certFile, certPEM, now)
+	assert.Eventually(t, func() bool {
+		return peerSerial(t, addr, client) == 200
+	}, 2*time.Second, 20*time.Millisecond)
+}
+
+func TestTLSReloader_VerifiesClientCertificates(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	certFile, keyFile, caFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"), filepath.Join(dir, "ca.crt")
+	certPEM, keyPEM := ca.issue(t, 100, "webhook.local", x509.ExtKeyUsageServerAuth)
+	writeFile(t, certFile, certPEM, time.Now())
+	writeFile(t, keyFile, keyPEM, time.Now())
+	writeFile(t, caFile, ca.pem, time.Now())
+
+	reloader, err := infrastructure.NewTLSReloader(certFile, keyFile, caFile, tls.RequireAndVerifyClientCert)
+	require.NoError(t, err)
+	addr := serveTLS(t, reloader.TLSConfig())
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	get := func(certs ...tls.Certificate) error {
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			RootCAs:      roots,
+			ServerName:   "webhook.local",
+			Certificates: certs,
+		}}}
+		resp, err := client.Get("https://" + addr)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	assert.Error(t, get(), "a client certificate is required")
+
+	clientCert, clientKey := ca.issue(t, 300, "acme-webhooks", x509.ExtKeyUsageClientAuth)
+	pair, err := tls.X509KeyPair(clientCert, clientKey)
+	require.NoError(t, err)
+	assert.NoError(t, get(pair))
+
+	otherCA := newTestCA(t)
+	otherCert, otherKey := otherCA.issue(t, 400, "acme-webhooks", x509.ExtKeyUsageClientAuth)
+	other, err := tls.X509KeyPair(otherCert, otherKey)
+	require.NoError(t, err)
+	assert.Error(t, get(other), "certificates from other CAs are rejected")
+}
+
+func TestNewTLSReloader_Errors(t *testing.T) {
+	_, err := infrastructure.NewTLSReloader("", "", "", tls.NoClientCert)
+	assert.Error(t, err)
+
+	dir := t.TempDir()
+	_, err = infrastructure.NewTLSReloader(filepath.Join(dir, "missing.crt"), filepath.Join(dir, "missing.key"), "", tls.NoClientCert)
+	assert.Error(t, err)
+
+	ca := newTestCA(t)
+	certFile, keyFile, caFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"), filepath.Join(dir, "ca.crt")
+	certPEM, keyPEM := ca.issue(t, 100, "webhook.local", x509.ExtKeyUsageServerAuth)
+	writeFile(t, certFile, certPEM, time.Now())
+	writeFile(t, keyFile, keyPEM, time.Now())
+	writeFile(t, caFile, []byte("no certificates here"), time.Now())
+	_, err = infrastructure.NewTLSReloader(certFile, keyFile, caFile, tls.RequireAndVerifyClientCert)
+	assert.Error(t, err)
+}
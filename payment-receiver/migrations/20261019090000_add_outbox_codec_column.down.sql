@@ -0,0 +1,3 @@
+-- Drop the payload codec column
+ALTER TABLE outbox_events
+DROP COLUMN IF EXISTS codec;
@@ -0,0 +1,3 @@
+-- Record the serialization format of each payload; existing rows are protobuf
+ALTER TABLE outbox_events
+ADD COLUMN IF NOT EXISTS codec TEXT NOT NULL DEFAULT 'protobuf';
@@ -0,0 +1,18 @@
+-- Record every HTTP delivery attempt per subscriber
+CREATE TABLE IF NOT EXISTS delivery_attempts (
+    id UUID PRIMARY KEY,
+    outbox_event_id UUID NOT NULL REFERENCES outbox_events (id) ON DELETE CASCADE,
+    subscriber TEXT NOT NULL,
+    attempt INT NOT NULL,
+    status_code INT NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    succeeded BOOLEAN NOT NULL,
+    attempted_at TIMESTAMP NOT NULL DEFAULT now(),
+    duration_ms BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_delivery_attempts_event_subscriber
+ON delivery_attempts (outbox_event_id, subscriber);
+
+CREATE INDEX IF NOT EXISTS idx_delivery_attempts_subscriber_attempted_at
+ON delivery_attempts (subscriber, attempted_at);
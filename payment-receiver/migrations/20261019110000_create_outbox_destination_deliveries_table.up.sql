@@ -0,0 +1,11 @@
+-- Track delivery state of each outbox event per routed destination
+CREATE TABLE IF NOT EXISTS outbox_destination_deliveries (
+    outbox_event_id UUID NOT NULL REFERENCES outbox_events (id) ON DELETE CASCADE,
+    destination TEXT NOT NULL,
+    status TEXT NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMP NOT NULL DEFAULT now(),
+    delivered_at TIMESTAMP,
+    PRIMARY KEY (outbox_event_id, destination)
+);
@@ -0,0 +1,13 @@
+-- Current state of each payment, written in the same transaction as its outbox event
+CREATE TABLE IF NOT EXISTS payments (
+    id TEXT PRIMARY KEY,
+    amount BIGINT NOT NULL,
+    currency TEXT NOT NULL,
+    method TEXT NOT NULL,
+    status TEXT NOT NULL,
+    occurred_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    updated_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_payments_status ON payments (status);
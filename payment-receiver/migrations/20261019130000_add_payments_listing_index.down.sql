@@ -0,0 +1 @@
+DROP INDEX CONCURRENTLY IF EXISTS idx_payments_occurred_at_id;
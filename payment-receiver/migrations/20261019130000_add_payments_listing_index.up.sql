@@ -0,0 +1,3 @@
+-- Support keyset pagination of payments, newest first. Built concurrently so
+-- webhook upserts are not blocked, outside a transaction
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_payments_occurred_at_id ON payments (occurred_at DESC, id DESC);
@@ -0,0 +1,10 @@
+-- Single-row pause switch shared by all dispatcher instances
+CREATE TABLE IF NOT EXISTS dispatcher_control (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    paused BOOLEAN NOT NULL DEFAULT FALSE,
+    reason TEXT NOT NULL DEFAULT '',
+    updated_by TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+INSERT INTO dispatcher_control (id) VALUES (TRUE) ON CONFLICT DO NOTHING;
@@ -0,0 +1 @@
+DROP INDEX CONCURRENTLY IF EXISTS idx_outbox_events_status_created_at;
@@ -0,0 +1,3 @@
+-- Support admin listings of outbox events by status, newest first. Built
+-- concurrently so webhook inserts are not blocked, outside a transaction
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_outbox_events_status_created_at ON outbox_events (status, created_at DESC, id DESC);
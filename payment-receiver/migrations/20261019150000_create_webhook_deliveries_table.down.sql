@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP FUNCTION IF EXISTS webhook_deliveries_reject_update();
@@ -0,0 +1,37 @@
+-- Append-only audit log of every raw incoming webhook request
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id UUID PRIMARY KEY,
+    received_at TIMESTAMP NOT NULL,
+    method TEXT NOT NULL,
+    path TEXT NOT NULL,
+    source_ip TEXT NOT NULL,
+    headers JSONB NOT NULL,
+    body BYTEA NOT NULL,
+    signature_status TEXT NOT NULL,
+    status_code INT NOT NULL,
+    outbox_event_id UUID REFERENCES outbox_events (id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_received_at ON webhook_deliveries (received_at);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_outbox_event_id ON webhook_deliveries (outbox_event_id);
+
+-- Rows may be removed by retention but never rewritten
+CREATE OR REPLACE FUNCTION webhook_deliveries_reject_update() RETURNS trigger AS $$
+BEGIN
+    IF NEW.outbox_event_id IS NULL AND OLD.outbox_event_id IS NOT NULL
+        AND (NEW.id, NEW.received_at, NEW.method, NEW.path, NEW.source_ip, NEW.headers, NEW.body,
+             NEW.signature_status, NEW.status_code)
+            IS NOT DISTINCT FROM
+            (OLD.id, OLD.received_at, OLD.method, OLD.path, OLD.source_ip, OLD.headers, OLD.body,
+             OLD.signature_status, OLD.status_code) THEN
+        -- ON DELETE SET NULL from outbox_events
+        RETURN NEW;
+    END IF;
+    RAISE EXCEPTION 'webhook_deliveries is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER webhook_deliveries_append_only
+BEFORE UPDATE ON webhook_deliveries
+FOR EACH ROW EXECUTE FUNCTION webhook_deliveries_reject_update();
@@ -0,0 +1,3 @@
+-- Drop the delivery attempt counter
+ALTER TABLE outbox_events
+DROP COLUMN IF EXISTS attempts;
@@ -0,0 +1,3 @@
+-- Count failed deliveries so the dispatcher can give up on an event
+ALTER TABLE outbox_events
+ADD COLUMN IF NOT EXISTS attempts INTEGER NOT NULL DEFAULT 0;
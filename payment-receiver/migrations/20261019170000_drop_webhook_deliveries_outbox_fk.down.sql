@@ -0,0 +1,5 @@
+-- Restore the outbox_event_id foreign key; rows referencing unstored events
+-- are not validated
+ALTER TABLE webhook_deliveries
+ADD CONSTRAINT webhook_deliveries_outbox_event_id_fkey
+FOREIGN KEY (outbox_event_id) REFERENCES outbox_events (id) ON DELETE SET NULL NOT VALID;
@@ -0,0 +1,5 @@
+-- Asynchronously accepted webhooks reference their outbox event before the
+-- background writer stores it, and duplicates reference one that is never
+-- stored, so outbox_event_id cannot be a foreign key
+ALTER TABLE webhook_deliveries
+DROP CONSTRAINT IF EXISTS webhook_deliveries_outbox_event_id_fkey;
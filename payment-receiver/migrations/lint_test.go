@@ -0,0 +1,143 @@
+package migrations_test
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"payment-receiver/infrastructure"
+	"payment-receiver/migrations"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lintedAfter is the last migration written before the expand/contract rules
+// in README.md; it and everything older are exempt.
+const lintedAfter int64 = 20250525162648
+
+var (
+	columnType        = regexp.MustCompile(`(?i)\bALTER\s+COLUMN\s+\w+\s+(SET\s+DATA\s+)?TYPE\b`)
+	createTable       = regexp.MustCompile(`(?i)\bCREATE\s+TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?(\w+)`)
+	createIndex       = regexp.MustCompile(`(?i)\bCREATE\s+(?:UNIQUE\s+)?INDEX\s+(CONCURRENTLY\s+)?(?:IF\s+NOT\s+EXISTS\s+)?\w*\s*ON\s+(?:ONLY\s+)?(\w+)`)
+	concurrently      = regexp.MustCompile(`(?i)\bCONCURRENTLY\b`)
+	addColumn         = regexp.MustCompile(`(?i)\bADD\s+COLUMN\b[^,;]*`)
+	notNull           = regexp.MustCompile(`(?i)\bNOT\s+NULL\b`)
+	hasDefault        = regexp.MustCompile(`(?i)\bDEFAULT\b`)
+	addConstraint     = regexp.MustCompile(`(?i)\bADD\s+CONSTRAINT\s+\w+\s+(FOREIGN\s+KEY|CHECK)\b[^;]*`)
+	notValid          = regexp.MustCompile(`(?i)\bNOT\s+VALID\b`)
+	contractStatement = regexp.MustCompile(`(?i)\b(DROP\s+COLUMN|DROP\s+TABLE|RENAME\s+COLUMN|RENAME\s+TO|SET\s+NOT\s+NULL)\b`)
+)
+
+// lintMigration returns the expand/contract rules an up migration breaks.
+func lintMigration(m infrastructure.Migration) []string {
+	sql := infrastructure.StripSQLComments(m.Up)
+	var problems []string
+
+	if columnType.MatchString(sql) {
+		problems = append(problems, "changes a column type, which rewrites and locks the table; add a new column and backfill it")
+	}
+
+	created := map[string]bool{}
+	for _, t := range createTable.FindAllStringSubmatch(sql, -1) {
+		created[strings.ToLower(t[1])] = true
+	}
+	for _, idx := range createIndex.FindAllStringSubmatch(sql, -1) {
+		if idx[1] == "" && !created[strings.ToLower(idx[2])] {
+			problems = append(problems, fmt.Sprintf("indexes existing table %s without CONCURRENTLY", idx[2]))
+		}
+	}
+	if concurrently.MatchString(sql) && statementCount(sql) > 1 {
+		problems = append(problems, "uses CONCURRENTLY, which needs a migration with a single statement")
+	}
+
+	for _, col := range addColumn.FindAllString(sql, -1) {
+		if notNull.MatchString(col) && !hasDefault.MatchString(col) {
+			problems = append(problems, "adds a NOT NULL column without a default")
+		}
+	}
+	for _, c := range addConstraint.FindAllString(sql, -1) {
+		if !notValid.MatchString(c) {
+			problems = append(problems, "adds a constraint without NOT VALID; validate it in a later migration")
+		}
+	}
+
+	if !strings.HasPrefix(m.Name, "contract_") {
+		for _, s := range contractStatement.FindAllString(sql, -1) {
+			problems = append(problems, fmt.Sprintf("%s belongs in a contract_ migration", strings.ToUpper(s)))
+		}
+	}
+	return problems
+}
+
+func statementCount(sql string) int {
+	n := 0
+	for _, s := range strings.Split(sql, ";") {
+		if strings.TrimSpace(s) != "" {
+			n++
+		}
+	}
+	return n
+}
+
+func TestMigrations_FollowExpandContract(t *testing.T) {
+	all, err := infrastructure.LoadMigrations(migrations.FS)
+	require.NoError(t, err)
+	for _, m := range all {
+		if m.Version <= lintedAfter {
+			continue
+		}
+		for _, p := range lintMigration(m) {
+			t.Errorf("%d_%s %s (see migrations/README.md)", m.Version, m.Name, p)
+		}
+	}
+}
+
+func TestLintMigration_FlagsPayloadByteaConversion(t *testing.T) {
+	all, err := infrastructure.LoadMigrations(migrations.FS)
+	require.NoError(t, err)
+	for _, m := range all {
+		if m.Name == "change_payload_column_to_bytea" {
+			assert.NotEmpty(t, lintMigration(m))
+			return
+		}
+	}
+	t.Fatal("payload bytea migration not found")
+}
+
+func TestLintMigration(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		ok   bool
+	}{
+		{"add nullable column", "ALTER TABLE outbox_events ADD COLUMN payload_v2 BYTEA;", true},
+		{"add not null with default", "ALTER TABLE outbox_events ADD COLUMN codec TEXT NOT NULL DEFAULT 'protobuf';", true},
+		{"add not null without default", "ALTER TABLE outbox_events ADD COLUMN codec TEXT NOT NULL;", false},
+		{"change type", "ALTER TABLE outbox_events ALTER COLUMN payload TYPE bytea USING payload::text::bytea;", false},
+		{"index on new table", "CREATE TABLE t (id INT);\nCREATE INDEX idx_t ON t (id);", true},
+		{"index on existing table", "CREATE INDEX idx_outbox_codec ON outbox_events (codec);", false},
+		{"concurrent index", "CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_outbox_codec ON outbox_events (codec);", true},
+		{"concurrent index with more statements", "CREATE INDEX CONCURRENTLY idx_a ON outbox_events (codec);\nSELECT 1;", false},
+		{"foreign key not valid", "ALTER TABLE a ADD CONSTRAINT fk_b FOREIGN KEY (b_id) REFERENCES b (id) NOT VALID;", true},
+		{"foreign key validated", "ALTER TABLE a ADD CONSTRAINT fk_b FOREIGN KEY (b_id) REFERENCES b (id);", false},
+		{"drop column", "ALTER TABLE outbox_events DROP COLUMN payload_json;", false},
+		{"commented out", "-- ALTER TABLE outbox_events DROP COLUMN payload_json;\nSELECT 1;", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := lintMigration(infrastructure.Migration{Name: "expand", Up: tt.sql})
+			if tt.ok {
+				assert.Empty(t, problems)
+			} else {
+				assert.NotEmpty(t, problems)
+			}
+		})
+	}
+
+	assert.Empty(t, lintMigration(infrastructure.Migration{
+		Name: "contract_drop_payload_json",
+		Up:   "ALTER TABLE outbox_events DROP COLUMN payload_json;",
+	}))
+}
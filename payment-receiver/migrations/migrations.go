@@ -0,0 +1,110 @@
+// Package migrations embeds the SQL migrations and runs them from the
+// binaries, replacing the separate golang-migrate step.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+
+	"payment-receiver/infrastructure"
+)
+
+// FS holds the migration files in golang-migrate layout.
+//
+//go:embed *.sql
+var FS embed.FS
+
+const usage = "usage: migrate up | down [N] | status | version"
+
+// NewMigrator returns a migrator for the embedded migrations.
+func NewMigrator(db *sql.DB) (*infrastructure.Migrator, error) {
+	return infrastructure.NewMigrator(db, FS)
+}
+
+// Command runs the migrate subcommand described by args and reports to w.
+// down reverts one migration unless N is given.
+func Command(ctx context.Context, db *sql.DB, args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+	m, err := NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := m.Up(ctx)
+		for _, mig := range applied {
+			fmt.Fprintf(w, "applied %d_%s\n", mig.Version, mig.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Fprintln(w, "no change")
+		}
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps <= 0 {
+				return fmt.Errorf("invalid step count %q", args[1])
+			}
+		}
+		reverted, err := m.Down(ctx, steps)
+		for _, mig := range reverted {
+			fmt.Fprintf(w, "reverted %d_%s\n", mig.Version, mig.Name)
+		}
+		return err
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied"
+			}
+			fmt.Fprintf(w, "%-8s %d_%s\n", state, s.Version, s.Name)
+		}
+		return nil
+	case "version":
+		version, dirty, err := m.Version(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%d", version)
+		if dirty {
+			fmt.Fprint(w, " (dirty)")
+		}
+		fmt.Fprintf(w, "\nlatest embedded: %d\n", m.Latest())
+		return nil
+	}
+	return fmt.Errorf("unknown migrate command %q; %s", args[0], usage)
+}
+
+// EnsureCurrent is called on startup. With autoMigrate it applies pending
+// migrations under an advisory lock; either way it fails when the schema is
+// still older than the binary expects.
+func EnsureCurrent(ctx context.Context, db *sql.DB, autoMigrate bool) error {
+	m, err := NewMigrator(db)
+	if err != nil {
+		return err
+	}
+	if autoMigrate {
+		applied, err := m.Up(ctx)
+		for _, mig := range applied {
+			log.Printf("applied migration %d_%s", mig.Version, mig.Name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return m.CheckCurrent(ctx)
+}
@@ -0,0 +1,29 @@
+package migrations_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"payment-receiver/infrastructure"
+	"payment-receiver/migrations"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFS_EveryMigrationIsReversible(t *testing.T) {
+	all, err := infrastructure.LoadMigrations(migrations.FS)
+	require.NoError(t, err)
+	require.NotEmpty(t, all)
+	for _, m := range all {
+		assert.NotEmpty(t, m.Down, "%d_%s has no down migration", m.Version, m.Name)
+	}
+}
+
+func TestCommand_RejectsUnknownSubcommand(t *testing.T) {
+	var out bytes.Buffer
+	assert.Error(t, migrations.Command(context.Background(), nil, nil, &out))
+	assert.ErrorContains(t, migrations.Command(context.Background(), nil, []string{"sideways"}, &out), "sideways")
+	assert.ErrorContains(t, migrations.Command(context.Background(), nil, []string{"down", "0"}, &out), "invalid step count")
+}
@@ -0,0 +1,116 @@
+-- name: InsertOutboxEvent :exec
+INSERT INTO outbox_events (
+    id, aggregate_id, event_type, payload, codec, status, created_at, event_at
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
+
+-- name: InsertOutboxEvents :many
+-- InsertOutboxEvents inserts a batch from parallel arrays, so the statement
+-- size does not depend on the batch size. Conflicting aggregate IDs are
+-- skipped; only the IDs of inserted rows are returned.
+INSERT INTO outbox_events (
+    id, aggregate_id, event_type, payload, codec, status, created_at, event_at
+)
+SELECT
+    unnest(@ids::uuid[]),
+    unnest(@aggregate_ids::text[]),
+    unnest(@event_types::text[]),
+    unnest(@payloads::bytea[]),
+    unnest(@codecs::text[]),
+    unnest(@statuses::text[]),
+    unnest(@created_ats::timestamp[]),
+    unnest(@event_ats::timestamp[])
+ON CONFLICT (aggregate_id) DO NOTHING
+RETURNING id;
+
+-- name: FetchPendingOutboxEvents :many
+SELECT id, aggregate_id, event_type, event_at, payload, status, created_at, sent_at, codec, attempts
+FROM outbox_events
+WHERE status = 'pending'
+ORDER BY event_at ASC
+LIMIT $1;
+
+-- name: MarkOutboxEventSent :exec
+UPDATE outbox_events
+SET status = 'sent', sent_at = $1
+WHERE id = $2;
+
+-- name: RecordOutboxEventFailure :one
+-- RecordOutboxEventFailure counts a failed delivery of a pending event and
+-- marks it failed once it has been attempted max_attempts times.
+UPDATE outbox_events
+SET attempts = attempts + 1,
+    status = CASE WHEN attempts + 1 >= @max_attempts::int THEN 'failed' ELSE status END
+WHERE id = @id AND status = 'pending'
+RETURNING status;
+
+-- name: OutboxEventExistsByAggregateID :one
+SELECT EXISTS (
+    SELECT 1 FROM outbox_events WHERE aggregate_id = $1
+);
+
+-- name: GetOutboxEvent :one
+SELECT id, aggregate_id, event_type, event_at, payload, status, created_at, sent_at, codec, attempts
+FROM outbox_events
+WHERE id = $1;
+
+-- name: ListOutboxEventsByAggregateID :many
+SELECT id, aggregate_id, event_type, event_at, payload, status, created_at, sent_at, codec, attempts
+FROM outbox_events
+WHERE aggregate_id = $1
+ORDER BY event_at ASC, created_at ASC;
+
+-- name: ListOutboxEvents :many
+-- ListOutboxEvents pages through events newest first. NULL filters match
+-- every row; the cursor applies when after_created_at is set.
+SELECT id, aggregate_id, event_type, event_at, payload, status, created_at, sent_at, codec, attempts
+FROM outbox_events
+WHERE (sqlc.narg('status')::text IS NULL OR status = sqlc.narg('status'))
+  AND (sqlc.narg('event_type')::text IS NULL OR event_type = sqlc.narg('event_type'))
+  AND (sqlc.narg('aggregate_id')::text IS NULL OR aggregate_id = sqlc.narg('aggregate_id'))
+  AND (sqlc.narg('created_from')::timestamp IS NULL OR created_at >= sqlc.narg('created_from'))
+  AND (sqlc.narg('created_to')::timestamp IS NULL OR created_at < sqlc.narg('created_to'))
+  AND (sqlc.narg('after_created_at')::timestamp IS NULL
+       OR (created_at, id) < (sqlc.narg('after_created_at'), sqlc.narg('after_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: RequeueOutboxEvent :execrows
+UPDATE outbox_events
+SET status = 'pending', sent_at = NULL, attempts = 0
+WHERE id = $1 AND status = $2;
+
+-- name: RequeueOutboxEventsCreatedBetween :execrows
+UPDATE outbox_events
+SET status = 'pending', sent_at = NULL, attempts = 0
+WHERE status = @status AND created_at >= @created_from AND created_at < @created_to;
+
+-- name: OutboxStats :one
+SELECT
+    count(*) FILTER (WHERE status = 'pending') AS pending,
+    count(*) FILTER (WHERE status = 'failed') AS failed,
+    min(created_at) FILTER (WHERE status = 'pending') AS oldest_pending_at,
+    max(sent_at) AS last_sent_at
+FROM outbox_events;
+
+-- name: ListLegacyJSONPayloads :many
+-- ListLegacyJSONPayloads pages by id through payment events tagged protobuf
+-- whose payload is the JSON text left by the JSONB to bytea conversion. A
+-- protobuf PaymentEvent never starts with '{' (field 15, start group).
+SELECT id, aggregate_id, event_type, event_at, payload, status, created_at, sent_at, codec, attempts
+FROM outbox_events
+WHERE codec = 'protobuf'
+  AND event_type = 'payment_event'
+  AND substring(payload FROM 1 FOR 1) = '\x7b'::bytea
+  AND id > @after_id
+ORDER BY id
+LIMIT @batch_size;
+
+-- name: ReplaceLegacyJSONPayloads :execrows
+-- ReplaceLegacyJSONPayloads rewrites payloads by id, skipping rows that no
+-- longer hold JSON so overlapping runs never convert a row twice.
+UPDATE outbox_events AS o
+SET payload = v.payload
+FROM unnest(@ids::uuid[], @payloads::bytea[]) AS v (id, payload)
+WHERE o.id = v.id
+  AND o.codec = 'protobuf'
+  AND substring(o.payload FROM 1 FOR 1) = '\x7b'::bytea;
@@ -0,0 +1,13 @@
+// Package repository defines interfaces for data access.
+package repository
+
+import (
+	"context"
+
+	"payment-receiver/domain"
+)
+
+// AuditLogRepository persists operator actions.
+type AuditLogRepository interface {
+	Record(ctx context.Context, entry *domain.AuditEntry) error
+}
@@ -0,0 +1,18 @@
+// Package repository defines interfaces for data access.
+package repository
+
+import (
+	"context"
+
+	"payment-receiver/domain"
+
+	"github.com/google/uuid"
+)
+
+// DeliveryAttemptRepository stores HTTP delivery attempts per subscriber.
+type DeliveryAttemptRepository interface {
+	Record(ctx context.Context, attempt *domain.DeliveryAttempt) error
+	HasSucceeded(ctx context.Context, eventID uuid.UUID, subscriber string) (bool, error)
+	ListByEvent(ctx context.Context, eventID uuid.UUID) ([]*domain.DeliveryAttempt, error)
+	SubscriberStatuses(ctx context.Context) ([]*domain.SubscriberStatus, error)
+}
@@ -0,0 +1,15 @@
+// Package repository defines interfaces for data access.
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// DestinationDeliveryRepository tracks which routed destinations have received an outbox event.
+type DestinationDeliveryRepository interface {
+	Delivered(ctx context.Context, eventID uuid.UUID) ([]string, error)
+	MarkDelivered(ctx context.Context, eventID uuid.UUID, destination string) error
+	MarkFailed(ctx context.Context, eventID uuid.UUID, destination, reason string) error
+}
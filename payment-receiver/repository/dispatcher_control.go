@@ -0,0 +1,14 @@
+// Package repository defines interfaces for data access.
+package repository
+
+import (
+	"context"
+
+	"payment-receiver/domain"
+)
+
+// DispatcherControl stores the pause switch checked by every dispatcher run.
+type DispatcherControl interface {
+	State(ctx context.Context) (*domain.DispatcherState, error)
+	SetPaused(ctx context.Context, paused bool, actor, reason string) error
+}
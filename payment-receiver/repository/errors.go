@@ -0,0 +1,7 @@
+// Package repository defines interfaces for data access.
+package repository
+
+import "errors"
+
+// ErrNotFound is returned by read methods when no matching record exists.
+var ErrNotFound = errors.New("record not found")
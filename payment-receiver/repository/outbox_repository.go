@@ -3,6 +3,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"payment-receiver/domain"
 
@@ -12,7 +13,68 @@ import (
 // OutboxRepository defines DB operations for the outbox pattern.
 type OutboxRepository interface {
 	Insert(ctx context.Context, event *domain.OutboxEvent) error
+	// InsertBatch inserts events in one statement, skipping those whose
+	// aggregate ID already exists (or repeats earlier in the batch). It returns
+	// the indexes of the skipped events.
+	InsertBatch(ctx context.Context, events []*domain.OutboxEvent) (duplicates []int, err error)
 	FetchPending(ctx context.Context, limit int) ([]*domain.OutboxEvent, error)
 	MarkAsSent(ctx context.Context, id uuid.UUID) error
+	// MarkAttemptFailed records a failed delivery of a pending event and
+	// marks it failed once it has been attempted maxAttempts times. It
+	// returns the resulting status, or ErrNotFound if the event is no longer
+	// pending.
+	MarkAttemptFailed(ctx context.Context, id uuid.UUID, maxAttempts int) (domain.OutboxStatus, error)
 	ExistsByAggregateID(ctx context.Context, aggregateID string) (bool, error)
 }
+
+// OutboxReader provides read-only access to outbox events.
+type OutboxReader interface {
+	FindByID(ctx context.Context, id uuid.UUID) (*domain.OutboxEvent, error)
+	// ListByAggregateID returns the events of an aggregate, oldest first.
+	ListByAggregateID(ctx context.Context, aggregateID string) ([]*domain.OutboxEvent, error)
+}
+
+// OutboxCursor is the keyset position after which an outbox listing resumes.
+type OutboxCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// OutboxFilter narrows an outbox listing. Zero fields do not filter. From is
+// inclusive and To is exclusive, both on created_at.
+type OutboxFilter struct {
+	Status      domain.OutboxStatus
+	EventType   string
+	AggregateID string
+	From        time.Time
+	To          time.Time
+	After       *OutboxCursor
+	Limit       int
+}
+
+// OutboxAdminRepository extends the outbox with operator queries and fixes.
+type OutboxAdminRepository interface {
+	OutboxReader
+	// List returns events newest first, ordered by created_at and id.
+	List(ctx context.Context, filter OutboxFilter) ([]*domain.OutboxEvent, error)
+	// RequeueByID moves the event back to pending if it is in status from,
+	// reporting whether it was changed.
+	RequeueByID(ctx context.Context, id uuid.UUID, from domain.OutboxStatus) (bool, error)
+	// RequeueRange moves events in status from, created in [start, end), back
+	// to pending and returns how many were changed.
+	RequeueRange(ctx context.Context, from domain.OutboxStatus, start, end time.Time) (int64, error)
+	Stats(ctx context.Context) (*domain.OutboxStats, error)
+}
+
+// OutboxPayloadBackfillRepository finds and rewrites payment event payloads
+// that are tagged protobuf but still hold the JSON text of the original JSONB
+// column.
+type OutboxPayloadBackfillRepository interface {
+	// ListLegacyJSONPayloads returns up to limit such events with an ID
+	// greater than after, ordered by ID.
+	ListLegacyJSONPayloads(ctx context.Context, after uuid.UUID, limit int) ([]*domain.OutboxEvent, error)
+	// ReplaceLegacyJSONPayloads stores the payload of each event, skipping
+	// events whose stored payload is no longer JSON, and returns how many
+	// were rewritten.
+	ReplaceLegacyJSONPayloads(ctx context.Context, events []*domain.OutboxEvent) (int64, error)
+}
@@ -0,0 +1,40 @@
+// Package repository defines interfaces for data access.
+package repository
+
+import (
+	"context"
+	"time"
+
+	"payment-receiver/domain"
+)
+
+// PaymentRepository stores the current state of each payment.
+type PaymentRepository interface {
+	// Upsert creates the payment or updates it when the stored state is not
+	// newer than payment.OccurredAt, so late webhooks never roll state back.
+	Upsert(ctx context.Context, payment *domain.Payment) error
+}
+
+// PaymentCursor is the keyset position after which a payment listing resumes.
+type PaymentCursor struct {
+	OccurredAt time.Time
+	ID         string
+}
+
+// PaymentFilter narrows a payment listing. Zero fields do not filter. From is
+// inclusive and To is exclusive.
+type PaymentFilter struct {
+	Status   string
+	Currency string
+	From     time.Time
+	To       time.Time
+	After    *PaymentCursor
+	Limit    int
+}
+
+// PaymentReader provides read-only access to payment state.
+type PaymentReader interface {
+	FindByID(ctx context.Context, id string) (*domain.Payment, error)
+	// List returns payments newest first, ordered by occurred_at and id.
+	List(ctx context.Context, filter PaymentFilter) ([]*domain.Payment, error)
+}
@@ -0,0 +1,13 @@
+// Package repository defines interfaces for data access.
+package repository
+
+import "context"
+
+// TxManager runs a unit of work in a single database transaction.
+type TxManager interface {
+	// WithinTx calls fn with a context bound to a transaction. Repositories
+	// called with that context join the transaction, which is committed when
+	// fn returns nil and rolled back otherwise. Nested calls join the outer
+	// transaction.
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -0,0 +1,32 @@
+// Package repository defines interfaces for data access.
+package repository
+
+import (
+	"context"
+	"time"
+
+	"payment-receiver/domain"
+
+	"github.com/google/uuid"
+)
+
+// WebhookDeliveryFilter narrows a webhook delivery listing. Zero fields do
+// not filter.
+type WebhookDeliveryFilter struct {
+	Since         time.Time
+	OutboxEventID *uuid.UUID
+	StatusCode    int
+	Limit         int
+}
+
+// WebhookDeliveryRepository stores raw incoming webhook requests. Records are
+// append-only; they are only ever removed by retention.
+type WebhookDeliveryRepository interface {
+	Record(ctx context.Context, delivery *domain.WebhookDelivery) error
+	FindByID(ctx context.Context, id uuid.UUID) (*domain.WebhookDelivery, error)
+	// List returns deliveries newest first.
+	List(ctx context.Context, filter WebhookDeliveryFilter) ([]*domain.WebhookDelivery, error)
+	// DeleteReceivedBefore removes deliveries older than t and returns how
+	// many were removed.
+	DeleteReceivedBefore(ctx context.Context, t time.Time) (int64, error)
+}
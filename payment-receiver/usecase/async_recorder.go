@@ -0,0 +1,127 @@
+// Package usecase contains application logic and orchestrators.
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"payment-receiver/domain"
+)
+
+// DefaultAsyncBatchSize is the number of WAL entries recorded per drain step
+// when AsyncPaymentRecorder.BatchSize is not set.
+const DefaultAsyncBatchSize = 100
+
+// PaymentWAL is a durable local log of accepted webhooks. Entries are read
+// in append order and stay in the log, surviving restarts, until committed.
+type PaymentWAL interface {
+	// Append durably stores entry before returning.
+	Append(entry PaymentEntry) error
+	// ReadBatch returns up to max uncommitted entries and the position to
+	// commit once they are recorded.
+	ReadBatch(max int) (entries []PaymentEntry, next int64, err error)
+	// Commit discards every entry before next.
+	Commit(next int64) error
+}
+
+// AsyncPaymentRecorder acknowledges webhooks once they are in the WAL and
+// records them through Recorder in the background. Delivery into the outbox
+// is at least once; entries replayed after a crash are deduplicated by
+// aggregate ID.
+type AsyncPaymentRecorder struct {
+	WAL       PaymentWAL
+	Recorder  PaymentBatchRecorder
+	BatchSize int
+
+	wake chan struct{}
+}
+
+var _ PaymentEventRecorder = (*AsyncPaymentRecorder)(nil)
+
+func NewAsyncPaymentRecorder(
+	wal PaymentWAL,
+	recorder PaymentBatchRecorder,
+	batchSize int,
+) *AsyncPaymentRecorder {
+	if batchSize <= 0 {
+		batchSize = DefaultAsyncBatchSize
+	}
+	return &AsyncPaymentRecorder{
+		WAL:       wal,
+		Recorder:  recorder,
+		BatchSize: batchSize,
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+// RecordPayment appends the payment and its event to the WAL and wakes the
+// background writer. Duplicates are only detected when the entry is drained.
+func (r *AsyncPaymentRecorder) RecordPayment(
+	_ context.Context,
+	payment *domain.Payment,
+	event *domain.OutboxEvent,
+) error {
+	if err := r.WAL.Append(PaymentEntry{Payment: payment, Event: event}); err != nil {
+		return fmt.Errorf("failed to append to WAL: %w", err)
+	}
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Drain records WAL entries batch by batch, one transaction per batch, until
+// the WAL is empty or a write fails. Entries already accepted, including
+// those replayed after a crash, are skipped. A batch is committed to the WAL
+// only once it has been recorded.
+func (r *AsyncPaymentRecorder) Drain(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		entries, next, err := r.WAL.ReadBatch(r.BatchSize)
+		if err != nil {
+			return total, fmt.Errorf("failed to read WAL: %w", err)
+		}
+		if len(entries) == 0 {
+			return total, nil
+		}
+
+		duplicates, err := r.Recorder.RecordPayments(ctx, entries)
+		if err != nil {
+			return total, fmt.Errorf("failed to record %d events from WAL: %w", len(entries), err)
+		}
+		if len(duplicates) > 0 {
+			log.Printf("skipped %d already accepted events from WAL", len(duplicates))
+		}
+		if err := r.WAL.Commit(next); err != nil {
+			return total, fmt.Errorf("failed to commit WAL: %w", err)
+		}
+		total += len(entries)
+	}
+}
+
+// Run drains the WAL on start, which replays entries left by a crash, then
+// whenever RecordPayment appends and at least once per interval, until ctx
+// is done. After a failed drain it waits for the next interval.
+func (r *AsyncPaymentRecorder) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		wake := r.wake
+		if n, err := r.Drain(ctx); err != nil {
+			log.Println(err)
+			wake = nil
+		} else if n > 0 {
+			log.Printf("recorded %d events from WAL", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-wake:
+		case <-ticker.C:
+		}
+	}
+}
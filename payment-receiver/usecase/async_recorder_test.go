@@ -0,0 +1,170 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"payment-receiver/domain"
+	"payment-receiver/usecase"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryWAL is a PaymentWAL whose positions are entry indexes.
+type memoryWAL struct {
+	mu        sync.Mutex
+	entries   []usecase.PaymentEntry
+	committed int64
+	appendErr error
+}
+
+func (w *memoryWAL) Append(entry usecase.PaymentEntry) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.appendErr != nil {
+		return w.appendErr
+	}
+	w.entries = append(w.entries, entry)
+	return nil
+}
+
+func (w *memoryWAL) ReadBatch(max int) ([]usecase.PaymentEntry, int64, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	end := min(int(w.committed)+max, len(w.entries))
+	batch := append([]usecase.PaymentEntry(nil), w.entries[w.committed:end]...)
+	return batch, int64(end), nil
+}
+
+func (w *memoryWAL) Commit(next int64) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.committed = next
+	return nil
+}
+
+func (w *memoryWAL) pending() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.entries) - int(w.committed)
+}
+
+// scriptedRecorder records batches in one step: an event whose errs entry
+// is ErrDuplicateEvent is reported as a duplicate, any other error fails the
+// whole batch and records nothing.
+type scriptedRecorder struct {
+	mu       sync.Mutex
+	errs     map[string]error
+	recorded []string
+	batches  int
+}
+
+func (r *scriptedRecorder) RecordPayments(_ context.Context, entries []usecase.PaymentEntry) ([]int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var duplicates []int
+	var recorded []string
+	for i, e := range entries {
+		switch err := r.errs[e.Event.AggregateID]; {
+		case errors.Is(err, usecase.ErrDuplicateEvent):
+			duplicates = append(duplicates, i)
+		case err != nil:
+			return nil, err
+		default:
+			recorded = append(recorded, e.Event.AggregateID)
+		}
+	}
+	r.recorded = append(r.recorded, recorded...)
+	r.batches++
+	return duplicates, nil
+}
+
+func (r *scriptedRecorder) recordedIDs() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.recorded...)
+}
+
+func walEntry(id string) usecase.PaymentEntry {
+	return usecase.PaymentEntry{
+		Payment: &domain.Payment{ID: id},
+		Event:   &domain.OutboxEvent{AggregateID: id, EventType: "payment_event"},
+	}
+}
+
+func TestAsyncPaymentRecorder_DrainsInBatches(t *testing.T) {
+	wal := &memoryWAL{}
+	inner := &scriptedRecorder{errs: map[string]error{"evt_002": usecase.ErrDuplicateEvent}}
+	async := usecase.NewAsyncPaymentRecorder(wal, inner, 2)
+
+	for _, id := range []string{"evt_001", "evt_002", "evt_003"} {
+		e := walEntry(id)
+		require.NoError(t, async.RecordPayment(context.Background(), e.Payment, e.Event))
+	}
+	assert.Empty(t, inner.recordedIDs(), "nothing is recorded before draining")
+
+	n, err := async.Drain(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, []string{"evt_001", "evt_003"}, inner.recordedIDs())
+	assert.Equal(t, 2, inner.batches)
+	assert.Equal(t, 0, wal.pending())
+}
+
+func TestAsyncPaymentRecorder_FailedBatchIsNotCommitted(t *testing.T) {
+	wal := &memoryWAL{}
+	inner := &scriptedRecorder{errs: map[string]error{"evt_003": errors.New("db down")}}
+	async := usecase.NewAsyncPaymentRecorder(wal, inner, 2)
+	for _, id := range []string{"evt_001", "evt_002", "evt_003", "evt_004"} {
+		require.NoError(t, wal.Append(walEntry(id)))
+	}
+
+	n, err := async.Drain(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, 2, wal.pending(), "the failed batch stays in the WAL")
+
+	delete(inner.errs, "evt_003")
+	n, err = async.Drain(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []string{"evt_001", "evt_002", "evt_003", "evt_004"}, inner.recordedIDs())
+}
+
+func TestAsyncPaymentRecorder_AppendError(t *testing.T) {
+	wal := &memoryWAL{appendErr: errors.New("disk full")}
+	async := usecase.NewAsyncPaymentRecorder(wal, &scriptedRecorder{}, 0)
+
+	e := walEntry("evt_001")
+	err := async.RecordPayment(context.Background(), e.Payment, e.Event)
+	assert.ErrorIs(t, err, wal.appendErr)
+	assert.Equal(t, usecase.DefaultAsyncBatchSize, async.BatchSize)
+}
+
+func TestAsyncPaymentRecorder_RunReplaysAndWakesOnAppend(t *testing.T) {
+	wal := &memoryWAL{}
+	require.NoError(t, wal.Append(walEntry("evt_001")))
+	inner := &scriptedRecorder{}
+	async := usecase.NewAsyncPaymentRecorder(wal, inner, 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		async.Run(ctx, time.Hour)
+	}()
+
+	assert.Eventually(t, func() bool { return len(inner.recordedIDs()) == 1 }, time.Second, 5*time.Millisecond,
+		"entries left from a previous run are replayed on start")
+
+	e := walEntry("evt_002")
+	require.NoError(t, async.RecordPayment(context.Background(), e.Payment, e.Event))
+	assert.Eventually(t, func() bool { return len(inner.recordedIDs()) == 2 }, time.Second, 5*time.Millisecond)
+
+	cancel()
+	<-done
+}
@@ -18,6 +18,9 @@ type OutboxEnqueuer struct {
 // OutboxEventSaver defines the interface for saving events to outbox.
 type OutboxEventSaver interface {
 	EnqueueOutboxEvent(ctx context.Context, event *domain.OutboxEvent) error
+	// EnqueueOutboxEvents saves events in one batch and returns the indexes
+	// of those that were already accepted.
+	EnqueueOutboxEvents(ctx context.Context, events []*domain.OutboxEvent) (duplicates []int, err error)
 }
 
 func NewOutboxEnqueuer(repo repository.OutboxRepository) *OutboxEnqueuer {
@@ -42,3 +45,11 @@ func (e *OutboxEnqueuer) EnqueueOutboxEvent(ctx context.Context, event *domain.O
 
 	return nil
 }
+
+func (e *OutboxEnqueuer) EnqueueOutboxEvents(ctx context.Context, events []*domain.OutboxEvent) ([]int, error) {
+	duplicates, err := e.Repo.InsertBatch(ctx, events)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert outbox events: %w", err)
+	}
+	return duplicates, nil
+}
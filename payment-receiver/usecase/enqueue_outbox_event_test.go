@@ -26,6 +26,15 @@ func (m *mockOutboxEnqueuerRepo) Insert(ctx context.Context, event *domain.Outbo
 	return args.Error(0)
 }
 
+func (m *mockOutboxEnqueuerRepo) InsertBatch(
+	ctx context.Context,
+	events []*domain.OutboxEvent,
+) ([]int, error) {
+	args := m.Called(ctx, events)
+	duplicates, _ := args.Get(0).([]int)
+	return duplicates, args.Error(1)
+}
+
 func (m *mockOutboxEnqueuerRepo) FetchPending(
 	ctx context.Context,
 	limit int,
@@ -37,6 +46,14 @@ func (m *mockOutboxEnqueuerRepo) MarkAsSent(ctx context.Context, id uuid.UUID) e
 	panic("not implemented")
 }
 
+func (m *mockOutboxEnqueuerRepo) MarkAttemptFailed(
+	ctx context.Context,
+	id uuid.UUID,
+	maxAttempts int,
+) (domain.OutboxStatus, error) {
+	panic("not implemented")
+}
+
 func (m *mockOutboxEnqueuerRepo) ExistsByAggregateID(ctx context.Context, id string) (bool, error) {
 	return m.ShouldExist, nil
 }
@@ -73,3 +90,19 @@ func TestOutboxEnqueuer_EnqueueOutboxEvent(t *testing.T) {
 	assert.NoError(t, err)
 	mockRepo.AssertExpectations(t)
 }
+
+func TestOutboxEnqueuer_EnqueueOutboxEvents(t *testing.T) {
+	events := []*domain.OutboxEvent{
+		{ID: uuid.New(), AggregateID: "evt_001"},
+		{ID: uuid.New(), AggregateID: "evt_002"},
+	}
+	repo := new(mockOutboxEnqueuerRepo)
+	repo.On("InsertBatch", mock.Anything, events).Return([]int{1}, nil)
+	enqueuer := usecase.NewOutboxEnqueuer(repo)
+
+	duplicates, err := enqueuer.EnqueueOutboxEvents(context.Background(), events)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1}, duplicates)
+	repo.AssertExpectations(t)
+}
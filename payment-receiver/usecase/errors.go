@@ -4,3 +4,13 @@ package usecase
 import "errors"
 
 var ErrDuplicateEvent = errors.New("event already processed")
+
+// ErrEventNotRetryable is returned when retrying an event that has not failed.
+var ErrEventNotRetryable = errors.New("only failed events can be retried")
+
+// ErrNoDestination is returned by OutboxRouter when an event matches no
+// route and there are no default destinations.
+var ErrNoDestination = errors.New("no destination for event")
+
+// ErrInvalidRequeue is returned for a bulk requeue with an invalid status or range.
+var ErrInvalidRequeue = errors.New("invalid requeue request")
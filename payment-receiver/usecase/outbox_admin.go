@@ -0,0 +1,195 @@
+// Package usecase contains application logic and orchestrators.
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"payment-receiver/domain"
+	"payment-receiver/repository"
+
+	"github.com/google/uuid"
+)
+
+// Audit actions recorded by OutboxAdminService.
+const (
+	AuditActionRetry            = "outbox.retry"
+	AuditActionRequeue          = "outbox.requeue"
+	AuditActionPauseDispatcher  = "dispatcher.pause"
+	AuditActionResumeDispatcher = "dispatcher.resume"
+)
+
+const (
+	defaultOutboxPageSize = 50
+	maxOutboxPageSize     = 500
+)
+
+// OutboxEventPage is one page of an outbox listing. Next is nil on the last page.
+type OutboxEventPage struct {
+	Events []*domain.OutboxEvent
+	Next   *repository.OutboxCursor
+}
+
+// DispatcherStatus combines the pause switch with backlog statistics.
+type DispatcherStatus struct {
+	State *domain.DispatcherState
+	Stats *domain.OutboxStats
+	Lag   time.Duration
+}
+
+// OutboxAdmin defines the operator actions exposed by the admin API.
+type OutboxAdmin interface {
+	ListEvents(ctx context.Context, filter repository.OutboxFilter) (*OutboxEventPage, error)
+	RetryEvent(ctx context.Context, actor string, id uuid.UUID) error
+	RequeueRange(ctx context.Context, actor string, from domain.OutboxStatus, start, end time.Time) (int64, error)
+	SetPaused(ctx context.Context, actor string, paused bool, reason string) error
+	Status(ctx context.Context) (*DispatcherStatus, error)
+}
+
+// OutboxAdminService applies operator actions and records each one in the
+// audit log within the same transaction.
+type OutboxAdminService struct {
+	Outbox  repository.OutboxAdminRepository
+	Control repository.DispatcherControl
+	Audit   repository.AuditLogRepository
+	Tx      repository.TxManager
+}
+
+func NewOutboxAdminService(
+	outbox repository.OutboxAdminRepository,
+	control repository.DispatcherControl,
+	audit repository.AuditLogRepository,
+	tx repository.TxManager,
+) *OutboxAdminService {
+	return &OutboxAdminService{Outbox: outbox, Control: control, Audit: audit, Tx: tx}
+}
+
+// ListEvents returns one page of outbox events. The page size defaults to 50
+// and is capped at 500.
+func (s *OutboxAdminService) ListEvents(
+	ctx context.Context,
+	filter repository.OutboxFilter,
+) (*OutboxEventPage, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultOutboxPageSize
+	}
+	if limit > maxOutboxPageSize {
+		limit = maxOutboxPageSize
+	}
+
+	filter.Limit = limit + 1
+	events, err := s.Outbox.List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list outbox events: %w", err)
+	}
+
+	page := &OutboxEventPage{Events: events}
+	if len(events) > limit {
+		page.Events = events[:limit]
+		last := page.Events[limit-1]
+		page.Next = &repository.OutboxCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+	return page, nil
+}
+
+// RetryEvent moves a failed event back to pending. It returns
+// repository.ErrNotFound for unknown events and ErrEventNotRetryable for
+// events that have not failed.
+func (s *OutboxAdminService) RetryEvent(ctx context.Context, actor string, id uuid.UUID) error {
+	return s.Tx.WithinTx(ctx, func(ctx context.Context) error {
+		ev, err := s.Outbox.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if ev.Status != domain.StatusFailed {
+			return fmt.Errorf("%w: event is %s", ErrEventNotRetryable, ev.Status)
+		}
+
+		ok, err := s.Outbox.RequeueByID(ctx, id, domain.StatusFailed)
+		if err != nil {
+			return fmt.Errorf("failed to requeue event: %w", err)
+		}
+		if !ok {
+			return fmt.Errorf("%w: event changed concurrently", ErrEventNotRetryable)
+		}
+
+		return s.audit(ctx, actor, AuditActionRetry, id.String(), map[string]any{
+			"aggregate_id": ev.AggregateID,
+		})
+	})
+}
+
+// RequeueRange moves events in status from (failed or sent), created in
+// [start, end), back to pending and returns how many were requeued.
+func (s *OutboxAdminService) RequeueRange(
+	ctx context.Context,
+	actor string,
+	from domain.OutboxStatus,
+	start, end time.Time,
+) (int64, error) {
+	if from != domain.StatusFailed && from != domain.StatusSent {
+		return 0, fmt.Errorf("%w: status must be failed or sent", ErrInvalidRequeue)
+	}
+	if start.IsZero() || end.IsZero() || !start.Before(end) {
+		return 0, fmt.Errorf("%w: from must be before to", ErrInvalidRequeue)
+	}
+
+	var n int64
+	err := s.Tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		n, err = s.Outbox.RequeueRange(ctx, from, start, end)
+		if err != nil {
+			return fmt.Errorf("failed to requeue events: %w", err)
+		}
+		return s.audit(ctx, actor, AuditActionRequeue, "", map[string]any{
+			"status":   string(from),
+			"from":     start.UTC().Format(time.RFC3339Nano),
+			"to":       end.UTC().Format(time.RFC3339Nano),
+			"requeued": n,
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// SetPaused pauses or resumes every dispatcher.
+func (s *OutboxAdminService) SetPaused(ctx context.Context, actor string, paused bool, reason string) error {
+	action := AuditActionResumeDispatcher
+	if paused {
+		action = AuditActionPauseDispatcher
+	}
+	return s.Tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.Control.SetPaused(ctx, paused, actor, reason); err != nil {
+			return fmt.Errorf("failed to update dispatcher state: %w", err)
+		}
+		return s.audit(ctx, actor, action, "dispatcher", map[string]any{"reason": reason})
+	})
+}
+
+// Status reports whether dispatching is paused and how far it lags behind.
+func (s *OutboxAdminService) Status(ctx context.Context) (*DispatcherStatus, error) {
+	state, err := s.Control.State(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dispatcher state: %w", err)
+	}
+	stats, err := s.Outbox.Stats(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read outbox stats: %w", err)
+	}
+	return &DispatcherStatus{State: state, Stats: stats, Lag: stats.Lag(time.Now())}, nil
+}
+
+func (s *OutboxAdminService) audit(
+	ctx context.Context,
+	actor, action, target string,
+	details map[string]any,
+) error {
+	if err := s.Audit.Record(ctx, domain.NewAuditEntry(actor, action, target, details)); err != nil {
+		return fmt.Errorf("failed to record audit entry: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"payment-receiver/domain"
+	"payment-receiver/repository"
+	"payment-receiver/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeOutboxAdminRepo struct {
+	events   map[uuid.UUID]*domain.OutboxEvent
+	listed   repository.OutboxFilter
+	requeued int64
+	stats    domain.OutboxStats
+}
+
+func (f *fakeOutboxAdminRepo) FindByID(_ context.Context, id uuid.UUID) (*domain.OutboxEvent, error) {
+	ev, ok := f.events[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return ev, nil
+}
+
+func (f *fakeOutboxAdminRepo) ListByAggregateID(_ context.Context, _ string) ([]*domain.OutboxEvent, error) {
+	return nil, nil
+}
+
+func (f *fakeOutboxAdminRepo) List(_ context.Context, filter repository.OutboxFilter) ([]*domain.OutboxEvent, error) {
+	f.listed = filter
+	var out []*domain.OutboxEvent
+	for _, ev := range f.events {
+		out = append(out, ev)
+	}
+	return out, nil
+}
+
+func (f *fakeOutboxAdminRepo) RequeueByID(_ context.Context, id uuid.UUID, from domain.OutboxStatus) (bool, error) {
+	ev, ok := f.events[id]
+	if !ok || ev.Status != from {
+		return false, nil
+	}
+	ev.Status = domain.StatusPending
+	ev.Attempts = 0
+	return true, nil
+}
+
+func (f *fakeOutboxAdminRepo) RequeueRange(_ context.Context, _ domain.OutboxStatus, _, _ time.Time) (int64, error) {
+	return f.requeued, nil
+}
+
+func (f *fakeOutboxAdminRepo) Stats(_ context.Context) (*domain.OutboxStats, error) {
+	return &f.stats, nil
+}
+
+type fakeAuditLog struct {
+	entries []*domain.AuditEntry
+	inTx    []bool
+}
+
+func (f *fakeAuditLog) Record(ctx context.Context, entry *domain.AuditEntry) error {
+	f.entries = append(f.entries, entry)
+	f.inTx = append(f.inTx, inTx(ctx))
+	return nil
+}
+
+func newAdminFixture(events ...*domain.OutboxEvent) (*usecase.OutboxAdminService, *fakeOutboxAdminRepo, *stubDispatcherControl, *fakeAuditLog) {
+	repo := &fakeOutboxAdminRepo{events: map[uuid.UUID]*domain.OutboxEvent{}}
+	for _, ev := range events {
+		repo.events[ev.ID] = ev
+	}
+	control := &stubDispatcherControl{}
+	audit := &fakeAuditLog{}
+	return usecase.NewOutboxAdminService(repo, control, audit, &fakeTxManager{}), repo, control, audit
+}
+
+func TestOutboxAdminService_RetryEvent(t *testing.T) {
+	failed := &domain.OutboxEvent{ID: uuid.New(), AggregateID: "evt_001", Status: domain.StatusFailed}
+	sent := &domain.OutboxEvent{ID: uuid.New(), AggregateID: "evt_002", Status: domain.StatusSent}
+	svc, _, _, audit := newAdminFixture(failed, sent)
+
+	require.NoError(t, svc.RetryEvent(context.Background(), "alice", failed.ID))
+	assert.Equal(t, domain.StatusPending, failed.Status)
+	require.Len(t, audit.entries, 1)
+	assert.Equal(t, "alice", audit.entries[0].Actor)
+	assert.Equal(t, usecase.AuditActionRetry, audit.entries[0].Action)
+	assert.Equal(t, failed.ID.String(), audit.entries[0].Target)
+	assert.True(t, audit.inTx[0], "audit entry must be written in the same transaction")
+
+	err := svc.RetryEvent(context.Background(), "alice", sent.ID)
+	assert.ErrorIs(t, err, usecase.ErrEventNotRetryable)
+
+	err = svc.RetryEvent(context.Background(), "alice", uuid.New())
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	assert.Len(t, audit.entries, 1)
+}
+
+func TestOutboxAdminService_RequeueRange(t *testing.T) {
+	svc, repo, _, audit := newAdminFixture()
+	repo.requeued = 7
+	start := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+
+	n, err := svc.RequeueRange(context.Background(), "bob", domain.StatusFailed, start, end)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), n)
+	require.Len(t, audit.entries, 1)
+	assert.Equal(t, usecase.AuditActionRequeue, audit.entries[0].Action)
+	assert.Equal(t, int64(7), audit.entries[0].Details["requeued"])
+
+	_, err = svc.RequeueRange(context.Background(), "bob", domain.StatusPending, start, end)
+	assert.ErrorIs(t, err, usecase.ErrInvalidRequeue)
+	_, err = svc.RequeueRange(context.Background(), "bob", domain.StatusFailed, end, start)
+	assert.ErrorIs(t, err, usecase.ErrInvalidRequeue)
+}
+
+func TestOutboxAdminService_SetPausedAndStatus(t *testing.T) {
+	svc, repo, control, audit := newAdminFixture()
+	oldest := time.Now().Add(-time.Minute)
+	repo.stats = domain.OutboxStats{Pending: 3, OldestPendingAt: &oldest}
+
+	require.NoError(t, svc.SetPaused(context.Background(), "carol", true, "broker maintenance"))
+	assert.True(t, control.state.Paused)
+	assert.Equal(t, "carol", control.state.UpdatedBy)
+
+	status, err := svc.Status(context.Background())
+	require.NoError(t, err)
+	assert.True(t, status.State.Paused)
+	assert.Equal(t, int64(3), status.Stats.Pending)
+	assert.GreaterOrEqual(t, status.Lag, time.Minute)
+
+	require.NoError(t, svc.SetPaused(context.Background(), "carol", false, ""))
+	assert.False(t, control.state.Paused)
+
+	require.Len(t, audit.entries, 2)
+	assert.Equal(t, usecase.AuditActionPauseDispatcher, audit.entries[0].Action)
+	assert.Equal(t, usecase.AuditActionResumeDispatcher, audit.entries[1].Action)
+}
+
+func TestOutboxAdminService_ListEvents_Pagination(t *testing.T) {
+	now := time.Now()
+	svc, repo, _, _ := newAdminFixture(
+		&domain.OutboxEvent{ID: uuid.New(), CreatedAt: now},
+		&domain.OutboxEvent{ID: uuid.New(), CreatedAt: now.Add(-time.Second)},
+	)
+
+	page, err := svc.ListEvents(context.Background(), repository.OutboxFilter{Status: domain.StatusFailed, Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 2, repo.listed.Limit)
+	assert.Equal(t, domain.StatusFailed, repo.listed.Status)
+	require.Len(t, page.Events, 1)
+	require.NotNil(t, page.Next)
+	assert.Equal(t, page.Events[0].ID, page.Next.ID)
+}
@@ -6,22 +6,47 @@ import (
 	"fmt"
 	"time"
 
+	"payment-receiver/domain"
 	"payment-receiver/repository"
+
+	"github.com/google/uuid"
 )
 
 // OutboxDispatcher processes pending outbox events and dispatches them to a queue.
 type OutboxDispatcher struct {
-	repo  repository.OutboxRepository
-	queue OutboxQueue
+	repo        repository.OutboxRepository
+	queue       OutboxQueue
+	control     repository.DispatcherControl
+	maxAttempts int
 }
 
 // NewOutboxDispatcher returns a new instance of OutboxDispatcher.
-func NewOutboxDispatcher(repo repository.OutboxRepository, queue OutboxQueue) *OutboxDispatcher {
-	return &OutboxDispatcher{repo: repo, queue: queue}
+// control, when non-nil, is consulted before each run so operators can pause
+// dispatching. An event whose delivery fails maxAttempts times is marked
+// failed and left for an operator to retry; maxAttempts <= 0 retries forever.
+func NewOutboxDispatcher(
+	repo repository.OutboxRepository,
+	queue OutboxQueue,
+	control repository.DispatcherControl,
+	maxAttempts int,
+) *OutboxDispatcher {
+	return &OutboxDispatcher{repo: repo, queue: queue, control: control, maxAttempts: maxAttempts}
 }
 
 // Dispatch retrieves pending events and enqueues them, marking them as sent.
+// It does nothing while the dispatcher is paused.
 func (d *OutboxDispatcher) Dispatch(ctx context.Context, limit int) error {
+	if d.control != nil {
+		state, err := d.control.State(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to read dispatcher state: %w", err)
+		}
+		if state.Paused {
+			fmt.Printf("dispatcher paused by %s: %s\n", state.UpdatedBy, state.Reason)
+			return nil
+		}
+	}
+
 	events, err := d.repo.FetchPending(ctx, limit)
 	if err != nil {
 		return fmt.Errorf("failed to fetch events: %w", err)
@@ -31,6 +56,7 @@ func (d *OutboxDispatcher) Dispatch(ctx context.Context, limit int) error {
 		err := d.queue.Enqueue(ctx, ev)
 		if err != nil {
 			fmt.Printf("enqueue failed for event %s: %v\n", ev.ID, err)
+			d.recordFailure(ctx, ev.ID)
 			continue
 		}
 		if err := d.repo.MarkAsSent(ctx, ev.ID); err != nil {
@@ -41,3 +67,19 @@ func (d *OutboxDispatcher) Dispatch(ctx context.Context, limit int) error {
 
 	return nil
 }
+
+// recordFailure counts a failed delivery and reports events that ran out of
+// attempts.
+func (d *OutboxDispatcher) recordFailure(ctx context.Context, id uuid.UUID) {
+	if d.maxAttempts <= 0 {
+		return
+	}
+	status, err := d.repo.MarkAttemptFailed(ctx, id, d.maxAttempts)
+	if err != nil {
+		fmt.Printf("record failure failed for event %s: %v\n", id, err)
+		return
+	}
+	if status == domain.StatusFailed {
+		fmt.Printf("event %s failed after %d attempts\n", id, d.maxAttempts)
+	}
+}
@@ -4,14 +4,17 @@ package usecase_test
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"testing"
 	"time"
 
 	"payment-receiver/domain"
+	"payment-receiver/repository"
 	"payment-receiver/usecase"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type mockOutboxRepo struct {
@@ -24,6 +27,10 @@ func (m *mockOutboxRepo) Insert(_ context.Context, _ *domain.OutboxEvent) error
 	return nil
 }
 
+func (m *mockOutboxRepo) InsertBatch(_ context.Context, _ []*domain.OutboxEvent) ([]int, error) {
+	return nil, nil
+}
+
 func (m *mockOutboxRepo) FetchPending(_ context.Context, _ int) ([]*domain.OutboxEvent, error) {
 	m.Fetched = true
 	return []*domain.OutboxEvent{
@@ -43,6 +50,10 @@ func (m *mockOutboxRepo) MarkAsSent(_ context.Context, id uuid.UUID) error {
 	return nil
 }
 
+func (m *mockOutboxRepo) MarkAttemptFailed(_ context.Context, _ uuid.UUID, _ int) (domain.OutboxStatus, error) {
+	return domain.StatusPending, nil
+}
+
 func (m *mockOutboxRepo) ExistsByAggregateID(_ context.Context, _ string) (bool, error) {
 	return false, nil
 }
@@ -62,7 +73,7 @@ func TestOutboxDispatcher_Dispatch(t *testing.T) {
 	repo := &mockOutboxRepo{}
 	queue := &mockOutboxQueue{}
 
-	dispatcher := usecase.NewOutboxDispatcher(repo, queue)
+	dispatcher := usecase.NewOutboxDispatcher(repo, queue, nil, 0)
 	err := dispatcher.Dispatch(context.Background(), 10)
 
 	assert.NoError(t, err)
@@ -70,3 +81,89 @@ func TestOutboxDispatcher_Dispatch(t *testing.T) {
 	assert.True(t, queue.Called)
 	assert.Len(t, repo.Marked, 1)
 }
+
+type stubDispatcherControl struct {
+	state domain.DispatcherState
+}
+
+func (s *stubDispatcherControl) State(_ context.Context) (*domain.DispatcherState, error) {
+	return &s.state, nil
+}
+
+func (s *stubDispatcherControl) SetPaused(_ context.Context, paused bool, actor, reason string) error {
+	s.state = domain.DispatcherState{Paused: paused, UpdatedBy: actor, Reason: reason}
+	return nil
+}
+
+func TestOutboxDispatcher_Dispatch_Paused(t *testing.T) {
+	repo := &mockOutboxRepo{}
+	queue := &mockOutboxQueue{}
+	control := &stubDispatcherControl{state: domain.DispatcherState{Paused: true, UpdatedBy: "alice"}}
+
+	dispatcher := usecase.NewOutboxDispatcher(repo, queue, control, 0)
+	err := dispatcher.Dispatch(context.Background(), 10)
+
+	assert.NoError(t, err)
+	assert.False(t, repo.Fetched)
+	assert.False(t, queue.Called)
+}
+
+// memoryOutbox dispatches from a shared event map, so a test can follow an
+// event through the dispatcher and the admin service.
+type memoryOutbox struct {
+	mockOutboxRepo
+	events map[uuid.UUID]*domain.OutboxEvent
+}
+
+func (m *memoryOutbox) FetchPending(_ context.Context, _ int) ([]*domain.OutboxEvent, error) {
+	var pending []*domain.OutboxEvent
+	for _, ev := range m.events {
+		if ev.Status == domain.StatusPending {
+			pending = append(pending, ev)
+		}
+	}
+	return pending, nil
+}
+
+func (m *memoryOutbox) MarkAsSent(_ context.Context, id uuid.UUID) error {
+	m.events[id].Status = domain.StatusSent
+	return nil
+}
+
+func (m *memoryOutbox) MarkAttemptFailed(_ context.Context, id uuid.UUID, maxAttempts int) (domain.OutboxStatus, error) {
+	ev, ok := m.events[id]
+	if !ok || ev.Status != domain.StatusPending {
+		return "", repository.ErrNotFound
+	}
+	ev.Attempts++
+	if ev.Attempts >= maxAttempts {
+		ev.Status = domain.StatusFailed
+	}
+	return ev.Status, nil
+}
+
+func TestOutboxDispatcher_Dispatch_FailsAfterMaxAttemptsUntilRetried(t *testing.T) {
+	ctx := context.Background()
+	ev := &domain.OutboxEvent{ID: uuid.New(), AggregateID: "evt_001", Status: domain.StatusPending}
+	admin, adminRepo, _, _ := newAdminFixture(ev)
+	queue := &recordingQueue{err: errors.New("broker down")}
+	dispatcher := usecase.NewOutboxDispatcher(&memoryOutbox{events: adminRepo.events}, queue, nil, 2)
+
+	require.NoError(t, dispatcher.Dispatch(ctx, 10))
+	assert.Equal(t, domain.StatusPending, ev.Status)
+	assert.Equal(t, 1, ev.Attempts)
+
+	require.NoError(t, dispatcher.Dispatch(ctx, 10))
+	assert.Equal(t, domain.StatusFailed, ev.Status)
+
+	require.NoError(t, dispatcher.Dispatch(ctx, 10))
+	assert.Len(t, queue.events, 2, "failed events are not dispatched")
+
+	require.NoError(t, admin.RetryEvent(ctx, "alice", ev.ID))
+	assert.Equal(t, domain.StatusPending, ev.Status)
+	assert.Zero(t, ev.Attempts)
+
+	queue.err = nil
+	require.NoError(t, dispatcher.Dispatch(ctx, 10))
+	assert.Equal(t, domain.StatusSent, ev.Status)
+}
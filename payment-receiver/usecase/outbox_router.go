@@ -0,0 +1,217 @@
+// Package usecase contains application logic and orchestrators.
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+
+	"payment-receiver/codec"
+	"payment-receiver/domain"
+	pb "payment-receiver/gen/proto"
+	"payment-receiver/repository"
+)
+
+// Destination is a named queue that routed events can be delivered to.
+// Failures of optional destinations are recorded but do not keep the outbox
+// event pending.
+type Destination struct {
+	Name     string
+	Queue    OutboxQueue
+	Optional bool
+}
+
+// RouteMatch selects events by attributes. Empty lists and nil thresholds
+// match everything; all set criteria must match. Amount bounds are inclusive.
+type RouteMatch struct {
+	EventTypes []string
+	Statuses   []string
+	Currencies []string
+	MinAmount  *int64
+	MaxAmount  *int64
+}
+
+// Route sends events matching Match to Destinations.
+type Route struct {
+	Match        RouteMatch
+	Destinations []string
+}
+
+// OutboxRouter is an OutboxQueue that fans events out to the destinations of
+// every matching route. Delivery state is tracked per destination, so a retry
+// only targets destinations that have not received the event yet.
+type OutboxRouter struct {
+	destinations map[string]Destination
+	routes       []Route
+	defaults     []string
+	deliveries   repository.DestinationDeliveryRepository
+}
+
+var _ OutboxQueue = (*OutboxRouter)(nil)
+
+// NewOutboxRouter validates that every route refers to a known destination.
+// defaults receive events that match no route.
+func NewOutboxRouter(
+	destinations []Destination,
+	routes []Route,
+	defaults []string,
+	deliveries repository.DestinationDeliveryRepository,
+) (*OutboxRouter, error) {
+	r := &OutboxRouter{
+		destinations: make(map[string]Destination, len(destinations)),
+		routes:       routes,
+		defaults:     defaults,
+		deliveries:   deliveries,
+	}
+	for _, d := range destinations {
+		if d.Name == "" || d.Queue == nil {
+			return nil, errors.New("destination name and queue are required")
+		}
+		if _, dup := r.destinations[d.Name]; dup {
+			return nil, fmt.Errorf("duplicate destination %q", d.Name)
+		}
+		r.destinations[d.Name] = d
+	}
+
+	check := func(names []string) error {
+		for _, name := range names {
+			if _, ok := r.destinations[name]; !ok {
+				return fmt.Errorf("unknown destination %q", name)
+			}
+		}
+		return nil
+	}
+	for i, route := range routes {
+		if len(route.Destinations) == 0 {
+			return nil, fmt.Errorf("route %d has no destinations", i)
+		}
+		if err := check(route.Destinations); err != nil {
+			return nil, fmt.Errorf("route %d: %w", i, err)
+		}
+	}
+	if err := check(defaults); err != nil {
+		return nil, fmt.Errorf("default destinations: %w", err)
+	}
+	return r, nil
+}
+
+// Enqueue delivers the event to each resolved destination that has not
+// received it yet. It returns an error when a required destination failed,
+// and ErrNoDestination when nothing is routed, so the event stays pending.
+func (r *OutboxRouter) Enqueue(ctx context.Context, event *domain.OutboxEvent) error {
+	targets, err := r.Resolve(event)
+	if err != nil {
+		return err
+	}
+	if len(targets) == 0 {
+		return fmt.Errorf("%w %s (%s)", ErrNoDestination, event.ID, event.EventType)
+	}
+
+	delivered, err := r.deliveries.Delivered(ctx, event.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load delivery state: %w", err)
+	}
+
+	var errs []error
+	for _, d := range targets {
+		if slices.Contains(delivered, d.Name) {
+			continue
+		}
+
+		if err := d.Queue.Enqueue(ctx, event); err != nil {
+			if markErr := r.deliveries.MarkFailed(ctx, event.ID, d.Name, err.Error()); markErr != nil {
+				log.Printf("failed to record failure for event %s to %s: %v", event.ID, d.Name, markErr)
+			}
+			if !d.Optional {
+				errs = append(errs, fmt.Errorf("destination %s: %w", d.Name, err))
+			}
+			continue
+		}
+
+		if err := r.deliveries.MarkDelivered(ctx, event.ID, d.Name); err != nil {
+			errs = append(errs, fmt.Errorf("destination %s: failed to record delivery: %w", d.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Resolve returns the destinations the event should be delivered to, in
+// declaration order and without duplicates.
+func (r *OutboxRouter) Resolve(event *domain.OutboxEvent) ([]Destination, error) {
+	attrs, err := routeAttributesOf(event)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, route := range r.routes {
+		if route.Match.matches(attrs) {
+			names = append(names, route.Destinations...)
+		}
+	}
+	if len(names) == 0 {
+		names = r.defaults
+	}
+
+	var targets []Destination
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		targets = append(targets, r.destinations[name])
+	}
+	return targets, nil
+}
+
+// routeAttributes are the event fields routing rules can match on.
+type routeAttributes struct {
+	eventType string
+	status    string
+	currency  string
+	amount    int64
+	hasAmount bool
+}
+
+func routeAttributesOf(event *domain.OutboxEvent) (routeAttributes, error) {
+	attrs := routeAttributes{eventType: event.EventType}
+	if event.EventType != "payment_event" {
+		return attrs, nil
+	}
+
+	c, err := codec.Lookup(event.Codec)
+	if err != nil {
+		return attrs, err
+	}
+	var payment pb.PaymentEvent
+	if err := c.Unmarshal(event.Payload, &payment); err != nil {
+		return attrs, fmt.Errorf("failed to decode payment for routing: %w", err)
+	}
+	attrs.status = payment.Status
+	attrs.currency = payment.Currency
+	attrs.amount = int64(payment.Amount)
+	attrs.hasAmount = true
+	return attrs, nil
+}
+
+func (m RouteMatch) matches(a routeAttributes) bool {
+	if len(m.EventTypes) > 0 && !slices.Contains(m.EventTypes, a.eventType) {
+		return false
+	}
+	if len(m.Statuses) > 0 && !slices.Contains(m.Statuses, a.status) {
+		return false
+	}
+	if len(m.Currencies) > 0 && !slices.Contains(m.Currencies, a.currency) {
+		return false
+	}
+	if m.MinAmount != nil && (!a.hasAmount || a.amount < *m.MinAmount) {
+		return false
+	}
+	if m.MaxAmount != nil && (!a.hasAmount || a.amount > *m.MaxAmount) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,199 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"payment-receiver/codec"
+	"payment-receiver/domain"
+	pb "payment-receiver/gen/proto"
+	"payment-receiver/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingQueue struct {
+	err    error
+	events []*domain.OutboxEvent
+}
+
+func (q *recordingQueue) Enqueue(_ context.Context, event *domain.OutboxEvent) error {
+	q.events = append(q.events, event)
+	return q.err
+}
+
+type memoryDestinationDeliveries struct {
+	delivered map[uuid.UUID][]string
+	failed    map[uuid.UUID][]string
+}
+
+func newMemoryDestinationDeliveries() *memoryDestinationDeliveries {
+	return &memoryDestinationDeliveries{
+		delivered: map[uuid.UUID][]string{},
+		failed:    map[uuid.UUID][]string{},
+	}
+}
+
+func (m *memoryDestinationDeliveries) Delivered(_ context.Context, id uuid.UUID) ([]string, error) {
+	return m.delivered[id], nil
+}
+
+func (m *memoryDestinationDeliveries) MarkDelivered(_ context.Context, id uuid.UUID, d string) error {
+	m.delivered[id] = append(m.delivered[id], d)
+	return nil
+}
+
+func (m *memoryDestinationDeliveries) MarkFailed(_ context.Context, id uuid.UUID, d, _ string) error {
+	m.failed[id] = append(m.failed[id], d)
+	return nil
+}
+
+func newPaymentOutboxEvent(t *testing.T, status, currency string, amount int32) *domain.OutboxEvent {
+	payment := &pb.PaymentEvent{Id: "evt_001", Status: status, Currency: currency, Amount: amount}
+	ev, err := domain.NewOutboxEvent("evt_001", "payment_event", time.Now(), payment, codec.Protobuf)
+	require.NoError(t, err)
+	return ev
+}
+
+func int64Ptr(v int64) *int64 { return &v }
+
+func TestOutboxRouter_Resolve(t *testing.T) {
+	dests := []usecase.Destination{
+		{Name: "redis", Queue: &recordingQueue{}},
+		{Name: "kafka", Queue: &recordingQueue{}},
+		{Name: "partners", Queue: &recordingQueue{}},
+	}
+	routes := []usecase.Route{
+		{
+			Match:        usecase.RouteMatch{Statuses: []string{"refunded"}},
+			Destinations: []string{"kafka"},
+		},
+		{
+			Match: usecase.RouteMatch{
+				Currencies: []string{"USD"},
+				MinAmount:  int64Ptr(10_000),
+			},
+			Destinations: []string{"partners", "kafka"},
+		},
+	}
+	router, err := usecase.NewOutboxRouter(dests, routes, []string{"redis"}, newMemoryDestinationDeliveries())
+	require.NoError(t, err)
+
+	names := func(ev *domain.OutboxEvent) []string {
+		targets, err := router.Resolve(ev)
+		require.NoError(t, err)
+		var out []string
+		for _, d := range targets {
+			out = append(out, d.Name)
+		}
+		return out
+	}
+
+	assert.Equal(t, []string{"redis"}, names(newPaymentOutboxEvent(t, "paid", "USD", 500)))
+	assert.Equal(t, []string{"kafka"}, names(newPaymentOutboxEvent(t, "refunded", "JPY", 500)))
+	assert.Equal(t, []string{"partners", "kafka"}, names(newPaymentOutboxEvent(t, "paid", "USD", 10_000)))
+	assert.Equal(t,
+		[]string{"kafka", "partners"},
+		names(newPaymentOutboxEvent(t, "refunded", "USD", 20_000)),
+		"destinations are deduplicated",
+	)
+}
+
+func TestNewOutboxRouter_UnknownDestination(t *testing.T) {
+	dests := []usecase.Destination{{Name: "redis", Queue: &recordingQueue{}}}
+
+	_, err := usecase.NewOutboxRouter(dests, []usecase.Route{{Destinations: []string{"kafka"}}}, nil, nil)
+	assert.ErrorContains(t, err, `unknown destination "kafka"`)
+
+	_, err = usecase.NewOutboxRouter(dests, nil, []string{"kafka"}, nil)
+	assert.ErrorContains(t, err, `unknown destination "kafka"`)
+}
+
+func TestOutboxRouter_Enqueue_FailingSinkDoesNotBlockOthers(t *testing.T) {
+	redis := &recordingQueue{}
+	kafka := &recordingQueue{err: errors.New("broker down")}
+	deliveries := newMemoryDestinationDeliveries()
+	router, err := usecase.NewOutboxRouter(
+		[]usecase.Destination{{Name: "redis", Queue: redis}, {Name: "kafka", Queue: kafka}},
+		[]usecase.Route{{Destinations: []string{"redis", "kafka"}}},
+		nil,
+		deliveries,
+	)
+	require.NoError(t, err)
+
+	ev := newPaymentOutboxEvent(t, "paid", "USD", 500)
+	err = router.Enqueue(context.Background(), ev)
+	assert.ErrorContains(t, err, "destination kafka")
+	assert.Equal(t, []string{"redis"}, deliveries.delivered[ev.ID])
+	assert.Equal(t, []string{"kafka"}, deliveries.failed[ev.ID])
+
+	// On retry only the failed destination is attempted again.
+	kafka.err = nil
+	require.NoError(t, router.Enqueue(context.Background(), ev))
+	assert.Len(t, redis.events, 1)
+	assert.Len(t, kafka.events, 2)
+}
+
+func TestOutboxRouter_Enqueue_OptionalFailureDoesNotFail(t *testing.T) {
+	deliveries := newMemoryDestinationDeliveries()
+	router, err := usecase.NewOutboxRouter(
+		[]usecase.Destination{
+			{Name: "redis", Queue: &recordingQueue{}},
+			{Name: "audit", Queue: &recordingQueue{err: errors.New("down")}, Optional: true},
+		},
+		nil,
+		[]string{"redis", "audit"},
+		deliveries,
+	)
+	require.NoError(t, err)
+
+	ev := newPaymentOutboxEvent(t, "paid", "USD", 500)
+	assert.NoError(t, router.Enqueue(context.Background(), ev))
+	assert.Equal(t, []string{"audit"}, deliveries.failed[ev.ID])
+}
+
+func TestOutboxDispatcher_WithRouter_MarksSentOnlyWhenRequiredSucceed(t *testing.T) {
+	repo := &mockOutboxRepo{}
+	router, err := usecase.NewOutboxRouter(
+		[]usecase.Destination{
+			{Name: "redis", Queue: &recordingQueue{}},
+			{Name: "kafka", Queue: &recordingQueue{err: errors.New("broker down")}},
+		},
+		nil,
+		[]string{"redis", "kafka"},
+		newMemoryDestinationDeliveries(),
+	)
+	require.NoError(t, err)
+
+	dispatcher := usecase.NewOutboxDispatcher(repo, router, nil, 0)
+	require.NoError(t, dispatcher.Dispatch(context.Background(), 10))
+
+	assert.True(t, repo.Fetched)
+	assert.Empty(t, repo.Marked)
+}
+
+func TestOutboxDispatcher_WithRouter_UnroutedEventIsNotMarkedSent(t *testing.T) {
+	repo := &mockOutboxRepo{}
+	redis := &recordingQueue{}
+	router, err := usecase.NewOutboxRouter(
+		[]usecase.Destination{{Name: "redis", Queue: redis}},
+		[]usecase.Route{{Match: usecase.RouteMatch{EventTypes: []string{"payment_event"}}, Destinations: []string{"redis"}}},
+		nil,
+		newMemoryDestinationDeliveries(),
+	)
+	require.NoError(t, err)
+
+	ev := &domain.OutboxEvent{ID: uuid.New(), EventType: "PaymentCompleted"}
+	assert.ErrorIs(t, router.Enqueue(context.Background(), ev), usecase.ErrNoDestination)
+
+	dispatcher := usecase.NewOutboxDispatcher(repo, router, nil, 0)
+	require.NoError(t, dispatcher.Dispatch(context.Background(), 10))
+
+	assert.True(t, repo.Fetched)
+	assert.Empty(t, repo.Marked, "an event no destination received stays pending")
+	assert.Empty(t, redis.events)
+}
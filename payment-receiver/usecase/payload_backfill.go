@@ -0,0 +1,164 @@
+// Package usecase contains application logic and orchestrators.
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"payment-receiver/domain"
+	pr "payment-receiver/gen/proto"
+	"payment-receiver/repository"
+
+	"github.com/google/uuid"
+)
+
+// DefaultBackfillBatchSize is the number of rows read and rewritten per batch.
+const DefaultBackfillBatchSize = 500
+
+// BackfillFailure is a legacy payload that could not be converted.
+type BackfillFailure struct {
+	ID  uuid.UUID
+	Err error
+}
+
+// BackfillReport summarizes a payload backfill run.
+type BackfillReport struct {
+	Scanned int
+	// Converted counts rewritten rows, or rows that would be rewritten in a
+	// dry run.
+	Converted int64
+	Failed    []BackfillFailure
+}
+
+// PayloadBackfill re-encodes payment event payloads that the JSONB to bytea
+// migration left as JSON text into the protobuf their codec column claims.
+// It walks the table by ID in batches, so it can run next to the webhook and
+// dispatcher and be stopped and restarted at any point.
+type PayloadBackfill struct {
+	Repo      repository.OutboxPayloadBackfillRepository
+	BatchSize int
+	// Pause is slept between batches to bound the write load.
+	Pause time.Duration
+}
+
+func NewPayloadBackfill(repo repository.OutboxPayloadBackfillRepository, batchSize int, pause time.Duration) *PayloadBackfill {
+	if batchSize <= 0 {
+		batchSize = DefaultBackfillBatchSize
+	}
+	return &PayloadBackfill{Repo: repo, BatchSize: batchSize, Pause: pause}
+}
+
+// Run converts every legacy payload. With dryRun nothing is written. Rows
+// that fail to convert are reported and left untouched.
+func (b *PayloadBackfill) Run(ctx context.Context, dryRun bool) (*BackfillReport, error) {
+	report := &BackfillReport{}
+	var after uuid.UUID
+	for {
+		events, err := b.Repo.ListLegacyJSONPayloads(ctx, after, b.BatchSize)
+		if err != nil {
+			return report, fmt.Errorf("failed to list legacy payloads: %w", err)
+		}
+		if len(events) == 0 {
+			return report, nil
+		}
+		after = events[len(events)-1].ID
+
+		converted := make([]*domain.OutboxEvent, 0, len(events))
+		for _, ev := range events {
+			report.Scanned++
+			payload, err := ReencodeLegacyPaymentPayload(ev.Payload)
+			if err != nil {
+				report.Failed = append(report.Failed, BackfillFailure{ID: ev.ID, Err: err})
+				continue
+			}
+			ev.Payload = payload
+			converted = append(converted, ev)
+		}
+
+		if dryRun {
+			report.Converted += int64(len(converted))
+		} else {
+			n, err := b.Repo.ReplaceLegacyJSONPayloads(ctx, converted)
+			if err != nil {
+				return report, fmt.Errorf("failed to rewrite payloads: %w", err)
+			}
+			report.Converted += n
+		}
+		log.Printf("payload backfill: scanned=%d converted=%d failed=%d", report.Scanned, report.Converted, len(report.Failed))
+
+		if len(events) < b.BatchSize {
+			return report, nil
+		}
+		if b.Pause > 0 {
+			select {
+			case <-ctx.Done():
+				return report, ctx.Err()
+			case <-time.After(b.Pause):
+			}
+		}
+	}
+}
+
+// legacyPaymentPayload is the JSON stored in outbox_events.payload while the
+// column was JSONB; occurred_at is an RFC3339 string or epoch seconds.
+type legacyPaymentPayload struct {
+	ID         string          `json:"id"`
+	Amount     json.Number     `json:"amount"`
+	Currency   string          `json:"currency"`
+	Method     string          `json:"method"`
+	Status     string          `json:"status"`
+	OccurredAt json.RawMessage `json:"occurred_at"`
+}
+
+// ReencodeLegacyPaymentPayload converts a legacy JSON payment payload into
+// the protobuf payload written for new events, applying the same validation.
+func ReencodeLegacyPaymentPayload(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var legacy legacyPaymentPayload
+	if err := dec.Decode(&legacy); err != nil {
+		return nil, fmt.Errorf("invalid legacy JSON payload: %w", err)
+	}
+
+	amount, err := legacy.Amount.Int64()
+	if err != nil || amount > math.MaxInt32 || amount < math.MinInt32 {
+		return nil, fmt.Errorf("invalid amount %q", legacy.Amount)
+	}
+	occurredAt, err := legacyOccurredAt(legacy.OccurredAt)
+	if err != nil {
+		return nil, err
+	}
+
+	event, err := domain.NewOutboxEventFromProtoPayment(&pr.PaymentEvent{
+		Id:                legacy.ID,
+		Amount:            int32(amount),
+		Currency:          legacy.Currency,
+		Method:            legacy.Method,
+		Status:            legacy.Status,
+		OccurredAtRfc3339: occurredAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return event.Payload, nil
+}
+
+// legacyOccurredAt returns occurred_at in a form domain.ParseOccurredAt
+// accepts.
+func legacyOccurredAt(raw json.RawMessage) (string, error) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s, nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(raw, &n); err == nil {
+		return n.String(), nil
+	}
+	return "", errors.New("occurred_at is required")
+}
@@ -0,0 +1,128 @@
+package usecase_test
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"payment-receiver/codec"
+	"payment-receiver/domain"
+	pr "payment-receiver/gen/proto"
+	"payment-receiver/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLegacyPayloads keeps events ordered by ID and treats payloads starting
+// with '{' as legacy JSON, like the Postgres queries.
+type fakeLegacyPayloads struct {
+	events []*domain.OutboxEvent
+	lists  int
+}
+
+func newFakeLegacyPayloads(payloads ...string) *fakeLegacyPayloads {
+	f := &fakeLegacyPayloads{}
+	for _, p := range payloads {
+		f.events = append(f.events, &domain.OutboxEvent{ID: uuid.New(), Payload: []byte(p), Codec: codec.NameProtobuf})
+	}
+	slices.SortFunc(f.events, func(a, b *domain.OutboxEvent) int { return bytes.Compare(a.ID[:], b.ID[:]) })
+	return f
+}
+
+func (f *fakeLegacyPayloads) ListLegacyJSONPayloads(
+	_ context.Context,
+	after uuid.UUID,
+	limit int,
+) ([]*domain.OutboxEvent, error) {
+	f.lists++
+	var out []*domain.OutboxEvent
+	for _, ev := range f.events {
+		if bytes.Compare(ev.ID[:], after[:]) > 0 && bytes.HasPrefix(ev.Payload, []byte("{")) && len(out) < limit {
+			cp := *ev
+			out = append(out, &cp)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeLegacyPayloads) ReplaceLegacyJSONPayloads(_ context.Context, events []*domain.OutboxEvent) (int64, error) {
+	var n int64
+	for _, ev := range events {
+		for _, stored := range f.events {
+			if stored.ID == ev.ID && bytes.HasPrefix(stored.Payload, []byte("{")) {
+				stored.Payload = ev.Payload
+				n++
+			}
+		}
+	}
+	return n, nil
+}
+
+const legacyPayload = `{"id":"pay_1","amount":1200,"currency":"JPY","method":"card","status":"paid","occurred_at":"2025-04-01T09:00:00+09:00"}`
+
+func TestReencodeLegacyPaymentPayload(t *testing.T) {
+	data, err := usecase.ReencodeLegacyPaymentPayload([]byte(legacyPayload))
+	require.NoError(t, err)
+
+	var ev pr.PaymentEvent
+	require.NoError(t, codec.Protobuf.Unmarshal(data, &ev))
+	assert.Equal(t, "pay_1", ev.Id)
+	assert.Equal(t, int32(1200), ev.Amount)
+	assert.Equal(t, "2025-04-01T00:00:00Z", ev.OccurredAtRfc3339)
+	assert.Equal(t, time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC), ev.OccurredAt.AsTime())
+}
+
+func TestReencodeLegacyPaymentPayload_EpochOccurredAt(t *testing.T) {
+	data, err := usecase.ReencodeLegacyPaymentPayload(
+		[]byte(`{"id":"pay_1","amount":5,"currency":"USD","method":"card","status":"refunded","occurred_at":1743465600}`))
+	require.NoError(t, err)
+
+	var ev pr.PaymentEvent
+	require.NoError(t, codec.Protobuf.Unmarshal(data, &ev))
+	assert.Equal(t, time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC), ev.OccurredAt.AsTime())
+}
+
+func TestReencodeLegacyPaymentPayload_RejectsInvalid(t *testing.T) {
+	for name, payload := range map[string]string{
+		"not json":        `{"id":`,
+		"no occurred_at":  `{"id":"pay_1","amount":5,"currency":"USD","method":"card","status":"paid"}`,
+		"amount overflow": `{"id":"pay_1","amount":3000000000,"currency":"USD","method":"card","status":"paid","occurred_at":"2025-04-01T00:00:00Z"}`,
+		"invalid status":  `{"id":"pay_1","amount":5,"currency":"USD","method":"card","status":"lost","occurred_at":"2025-04-01T00:00:00Z"}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := usecase.ReencodeLegacyPaymentPayload([]byte(payload))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestPayloadBackfill_ConvertsInBatches(t *testing.T) {
+	repo := newFakeLegacyPayloads(legacyPayload, legacyPayload, legacyPayload, `{"broken"`, "\x0a\x05pay_2")
+
+	report, err := usecase.NewPayloadBackfill(repo, 2, 0).Run(context.Background(), false)
+	require.NoError(t, err)
+	assert.Equal(t, 4, report.Scanned)
+	assert.Equal(t, int64(3), report.Converted)
+	require.Len(t, report.Failed, 1)
+	assert.Equal(t, 3, repo.lists, "two full batches and an empty one")
+
+	remaining, err := repo.ListLegacyJSONPayloads(context.Background(), uuid.Nil, 10)
+	require.NoError(t, err)
+	require.Len(t, remaining, 1)
+	assert.Equal(t, report.Failed[0].ID, remaining[0].ID)
+}
+
+func TestPayloadBackfill_DryRunWritesNothing(t *testing.T) {
+	repo := newFakeLegacyPayloads(legacyPayload, legacyPayload)
+
+	report, err := usecase.NewPayloadBackfill(repo, 10, 0).Run(context.Background(), true)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), report.Converted)
+	for _, ev := range repo.events {
+		assert.Equal(t, legacyPayload, string(ev.Payload))
+	}
+}
@@ -0,0 +1,99 @@
+// Package usecase contains application logic and orchestrators.
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"payment-receiver/domain"
+	"payment-receiver/repository"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPaymentPageSize = 50
+	maxPaymentPageSize     = 200
+)
+
+// PaymentDetails is a payment's current state with the events that produced it.
+type PaymentDetails struct {
+	Payment *domain.Payment
+	Events  []*domain.OutboxEvent
+}
+
+// PaymentPage is one page of a payment listing. Next is nil on the last page.
+type PaymentPage struct {
+	Payments []*domain.Payment
+	Next     *repository.PaymentCursor
+}
+
+// PaymentQuerier defines the read operations exposed by the query API.
+type PaymentQuerier interface {
+	GetPayment(ctx context.Context, id string) (*PaymentDetails, error)
+	ListPayments(ctx context.Context, filter repository.PaymentFilter) (*PaymentPage, error)
+	GetOutboxEvent(ctx context.Context, id uuid.UUID) (*domain.OutboxEvent, error)
+}
+
+// PaymentQueryService answers read queries about received payments.
+type PaymentQueryService struct {
+	Payments repository.PaymentReader
+	Outbox   repository.OutboxReader
+}
+
+func NewPaymentQueryService(
+	payments repository.PaymentReader,
+	outbox repository.OutboxReader,
+) *PaymentQueryService {
+	return &PaymentQueryService{Payments: payments, Outbox: outbox}
+}
+
+// GetPayment returns the payment with its event history, or
+// repository.ErrNotFound.
+func (s *PaymentQueryService) GetPayment(ctx context.Context, id string) (*PaymentDetails, error) {
+	payment, err := s.Payments.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	events, err := s.Outbox.ListByAggregateID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list payment events: %w", err)
+	}
+	return &PaymentDetails{Payment: payment, Events: events}, nil
+}
+
+// ListPayments returns one page of payments. The page size defaults to 50 and
+// is capped at 200.
+func (s *PaymentQueryService) ListPayments(
+	ctx context.Context,
+	filter repository.PaymentFilter,
+) (*PaymentPage, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultPaymentPageSize
+	}
+	if limit > maxPaymentPageSize {
+		limit = maxPaymentPageSize
+	}
+
+	// Fetch one extra row to learn whether another page exists.
+	filter.Limit = limit + 1
+	payments, err := s.Payments.List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list payments: %w", err)
+	}
+
+	page := &PaymentPage{Payments: payments}
+	if len(payments) > limit {
+		page.Payments = payments[:limit]
+		last := page.Payments[limit-1]
+		page.Next = &repository.PaymentCursor{OccurredAt: last.OccurredAt, ID: last.ID}
+	}
+	return page, nil
+}
+
+// GetOutboxEvent returns an outbox event with its dispatch status, or
+// repository.ErrNotFound.
+func (s *PaymentQueryService) GetOutboxEvent(ctx context.Context, id uuid.UUID) (*domain.OutboxEvent, error) {
+	return s.Outbox.FindByID(ctx, id)
+}
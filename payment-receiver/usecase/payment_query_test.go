@@ -0,0 +1,109 @@
+package usecase_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"payment-receiver/domain"
+	"payment-receiver/repository"
+	"payment-receiver/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePaymentReader struct {
+	payments []*domain.Payment
+	filter   repository.PaymentFilter
+}
+
+func (f *fakePaymentReader) FindByID(ctx context.Context, id string) (*domain.Payment, error) {
+	for _, p := range f.payments {
+		if p.ID == id {
+			return p, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (f *fakePaymentReader) List(ctx context.Context, filter repository.PaymentFilter) ([]*domain.Payment, error) {
+	f.filter = filter
+	if len(f.payments) > filter.Limit {
+		return f.payments[:filter.Limit], nil
+	}
+	return f.payments, nil
+}
+
+type fakeOutboxReader struct {
+	events []*domain.OutboxEvent
+}
+
+func (f *fakeOutboxReader) FindByID(ctx context.Context, id uuid.UUID) (*domain.OutboxEvent, error) {
+	return nil, repository.ErrNotFound
+}
+
+func (f *fakeOutboxReader) ListByAggregateID(ctx context.Context, aggregateID string) ([]*domain.OutboxEvent, error) {
+	return f.events, nil
+}
+
+func newPayments(n int) []*domain.Payment {
+	base := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	payments := make([]*domain.Payment, n)
+	for i := range payments {
+		payments[i] = &domain.Payment{
+			ID:         fmt.Sprintf("evt_%03d", n-i),
+			OccurredAt: base.Add(time.Duration(n-i) * time.Minute),
+		}
+	}
+	return payments
+}
+
+func TestPaymentQueryService_ListPayments_Pagination(t *testing.T) {
+	reader := &fakePaymentReader{payments: newPayments(3)}
+	svc := usecase.NewPaymentQueryService(reader, &fakeOutboxReader{})
+
+	page, err := svc.ListPayments(context.Background(), repository.PaymentFilter{Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 3, reader.filter.Limit, "one extra row is fetched to detect the next page")
+	require.Len(t, page.Payments, 2)
+	require.NotNil(t, page.Next)
+	assert.Equal(t, page.Payments[1].ID, page.Next.ID)
+	assert.Equal(t, page.Payments[1].OccurredAt, page.Next.OccurredAt)
+
+	page, err = svc.ListPayments(context.Background(), repository.PaymentFilter{Limit: 3})
+	require.NoError(t, err)
+	assert.Len(t, page.Payments, 3)
+	assert.Nil(t, page.Next)
+}
+
+func TestPaymentQueryService_ListPayments_PageSizeBounds(t *testing.T) {
+	reader := &fakePaymentReader{}
+	svc := usecase.NewPaymentQueryService(reader, &fakeOutboxReader{})
+
+	_, err := svc.ListPayments(context.Background(), repository.PaymentFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, 51, reader.filter.Limit)
+
+	_, err = svc.ListPayments(context.Background(), repository.PaymentFilter{Limit: 10_000})
+	require.NoError(t, err)
+	assert.Equal(t, 201, reader.filter.Limit)
+}
+
+func TestPaymentQueryService_GetPayment(t *testing.T) {
+	events := []*domain.OutboxEvent{{AggregateID: "evt_001"}}
+	svc := usecase.NewPaymentQueryService(
+		&fakePaymentReader{payments: []*domain.Payment{{ID: "evt_001"}}},
+		&fakeOutboxReader{events: events},
+	)
+
+	details, err := svc.GetPayment(context.Background(), "evt_001")
+	require.NoError(t, err)
+	assert.Equal(t, "evt_001", details.Payment.ID)
+	assert.Equal(t, events, details.Events)
+
+	_, err = svc.GetPayment(context.Background(), "missing")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
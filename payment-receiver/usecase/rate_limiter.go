@@ -0,0 +1,23 @@
+package usecase
+
+import (
+	"context"
+	"time"
+)
+
+// RateLimit is a token bucket refilled at Rate tokens per second up to Burst.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// Enabled reports whether the limit restricts anything.
+func (l RateLimit) Enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// RateLimiter takes one token from the bucket identified by key. When the
+// bucket is empty it reports how long until a token is available.
+type RateLimiter interface {
+	Allow(ctx context.Context, key string, limit RateLimit) (allowed bool, retryAfter time.Duration, err error)
+}
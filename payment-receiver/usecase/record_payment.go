@@ -0,0 +1,100 @@
+// Package usecase contains application logic and orchestrators.
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"payment-receiver/domain"
+	"payment-receiver/repository"
+)
+
+// PaymentEventRecorder defines the interface for recording a payment webhook.
+type PaymentEventRecorder interface {
+	RecordPayment(ctx context.Context, payment *domain.Payment, event *domain.OutboxEvent) error
+}
+
+// PaymentEntry is a validated payment webhook: the payment state and the
+// outbox event announcing it.
+type PaymentEntry struct {
+	Payment *domain.Payment
+	Event   *domain.OutboxEvent
+}
+
+// PaymentBatchRecorder records many payment webhooks at once.
+type PaymentBatchRecorder interface {
+	RecordPayments(ctx context.Context, entries []PaymentEntry) (duplicates []int, err error)
+}
+
+// PaymentRecorder stores payment state and its outbox event in one transaction,
+// so an event is published if and only if the state change was committed.
+type PaymentRecorder struct {
+	Outbox   OutboxEventSaver
+	Payments repository.PaymentRepository
+	Tx       repository.TxManager
+}
+
+func NewPaymentRecorder(
+	outbox OutboxEventSaver,
+	payments repository.PaymentRepository,
+	tx repository.TxManager,
+) *PaymentRecorder {
+	return &PaymentRecorder{Outbox: outbox, Payments: payments, Tx: tx}
+}
+
+// RecordPayment enqueues event and upserts payment atomically. It returns
+// ErrDuplicateEvent, leaving payment state untouched, when the event was
+// already accepted.
+func (r *PaymentRecorder) RecordPayment(
+	ctx context.Context,
+	payment *domain.Payment,
+	event *domain.OutboxEvent,
+) error {
+	return r.Tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := r.Outbox.EnqueueOutboxEvent(ctx, event); err != nil {
+			return err
+		}
+		if err := r.Payments.Upsert(ctx, payment); err != nil {
+			return fmt.Errorf("failed to upsert payment: %w", err)
+		}
+		return nil
+	})
+}
+
+// RecordPayments enqueues every event in one batch and upserts the payments
+// whose events were new, all in one transaction. It returns the indexes of
+// entries whose event was already accepted; their payment state is left
+// untouched.
+func (r *PaymentRecorder) RecordPayments(ctx context.Context, entries []PaymentEntry) ([]int, error) {
+	events := make([]*domain.OutboxEvent, len(entries))
+	for i, e := range entries {
+		events[i] = e.Event
+	}
+
+	var duplicates []int
+	err := r.Tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		duplicates, err = r.Outbox.EnqueueOutboxEvents(ctx, events)
+		if err != nil {
+			return err
+		}
+
+		skip := make(map[int]struct{}, len(duplicates))
+		for _, i := range duplicates {
+			skip[i] = struct{}{}
+		}
+		for i, e := range entries {
+			if _, ok := skip[i]; ok {
+				continue
+			}
+			if err := r.Payments.Upsert(ctx, e.Payment); err != nil {
+				return fmt.Errorf("failed to upsert payment: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return duplicates, nil
+}
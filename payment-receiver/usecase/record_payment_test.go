@@ -0,0 +1,174 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"payment-receiver/domain"
+	"payment-receiver/usecase"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type txCtxKey struct{}
+
+// fakeTxManager marks the context passed to fn and records the outcome.
+type fakeTxManager struct {
+	committed  bool
+	rolledBack bool
+}
+
+func (m *fakeTxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := fn(context.WithValue(ctx, txCtxKey{}, true)); err != nil {
+		m.rolledBack = true
+		return err
+	}
+	m.committed = true
+	return nil
+}
+
+func inTx(ctx context.Context) bool {
+	v, _ := ctx.Value(txCtxKey{}).(bool)
+	return v
+}
+
+type fakeOutboxSaver struct {
+	err        error
+	saved      *domain.OutboxEvent
+	savedTx    bool
+	batch      []*domain.OutboxEvent
+	duplicates []int
+}
+
+func (f *fakeOutboxSaver) EnqueueOutboxEvent(ctx context.Context, event *domain.OutboxEvent) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.saved = event
+	f.savedTx = inTx(ctx)
+	return nil
+}
+
+func (f *fakeOutboxSaver) EnqueueOutboxEvents(
+	ctx context.Context,
+	events []*domain.OutboxEvent,
+) ([]int, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.batch = events
+	f.savedTx = inTx(ctx)
+	return f.duplicates, nil
+}
+
+type fakePayments struct {
+	err        error
+	upserted   *domain.Payment
+	upsertedTx bool
+	all        []string
+}
+
+func (f *fakePayments) Upsert(ctx context.Context, payment *domain.Payment) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.upserted = payment
+	f.upsertedTx = inTx(ctx)
+	f.all = append(f.all, payment.ID)
+	return nil
+}
+
+func newRecordPaymentFixture() (*domain.Payment, *domain.OutboxEvent) {
+	payment := &domain.Payment{
+		ID:         "evt_001",
+		Amount:     1200,
+		Currency:   "USD",
+		Method:     "card",
+		Status:     domain.StatusPaid,
+		OccurredAt: time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC),
+	}
+	event := &domain.OutboxEvent{AggregateID: payment.ID, EventType: "payment_event"}
+	return payment, event
+}
+
+func TestPaymentRecorder_RecordPayment(t *testing.T) {
+	tx := &fakeTxManager{}
+	outbox := &fakeOutboxSaver{}
+	payments := &fakePayments{}
+	recorder := usecase.NewPaymentRecorder(outbox, payments, tx)
+
+	payment, event := newRecordPaymentFixture()
+	require.NoError(t, recorder.RecordPayment(context.Background(), payment, event))
+
+	assert.True(t, tx.committed)
+	assert.Same(t, event, outbox.saved)
+	assert.True(t, outbox.savedTx, "outbox insert must run inside the transaction")
+	assert.Same(t, payment, payments.upserted)
+	assert.True(t, payments.upsertedTx, "payment upsert must run inside the transaction")
+}
+
+func TestPaymentRecorder_RecordPayment_Duplicate(t *testing.T) {
+	tx := &fakeTxManager{}
+	payments := &fakePayments{}
+	recorder := usecase.NewPaymentRecorder(&fakeOutboxSaver{err: usecase.ErrDuplicateEvent}, payments, tx)
+
+	payment, event := newRecordPaymentFixture()
+	err := recorder.RecordPayment(context.Background(), payment, event)
+
+	assert.ErrorIs(t, err, usecase.ErrDuplicateEvent)
+	assert.True(t, tx.rolledBack)
+	assert.Nil(t, payments.upserted)
+}
+
+func TestPaymentRecorder_RecordPayment_UpsertFailureRollsBack(t *testing.T) {
+	tx := &fakeTxManager{}
+	upsertErr := errors.New("connection reset")
+	recorder := usecase.NewPaymentRecorder(&fakeOutboxSaver{}, &fakePayments{err: upsertErr}, tx)
+
+	payment, event := newRecordPaymentFixture()
+	err := recorder.RecordPayment(context.Background(), payment, event)
+
+	assert.ErrorIs(t, err, upsertErr)
+	assert.True(t, tx.rolledBack)
+	assert.False(t, tx.committed)
+}
+
+func TestPaymentRecorder_RecordPayments(t *testing.T) {
+	tx := &fakeTxManager{}
+	outbox := &fakeOutboxSaver{duplicates: []int{1}}
+	payments := &fakePayments{}
+	recorder := usecase.NewPaymentRecorder(outbox, payments, tx)
+
+	var entries []usecase.PaymentEntry
+	for _, id := range []string{"evt_001", "evt_002", "evt_003"} {
+		entries = append(entries, usecase.PaymentEntry{
+			Payment: &domain.Payment{ID: id},
+			Event:   &domain.OutboxEvent{AggregateID: id},
+		})
+	}
+
+	duplicates, err := recorder.RecordPayments(context.Background(), entries)
+
+	require.NoError(t, err)
+	assert.Equal(t, []int{1}, duplicates)
+	assert.True(t, tx.committed)
+	assert.Len(t, outbox.batch, 3)
+	assert.True(t, outbox.savedTx, "batch insert must run inside the transaction")
+	assert.Equal(t, []string{"evt_001", "evt_003"}, payments.all, "duplicates leave payment state untouched")
+	assert.True(t, payments.upsertedTx)
+}
+
+func TestPaymentRecorder_RecordPayments_UpsertFailureRollsBack(t *testing.T) {
+	tx := &fakeTxManager{}
+	upsertErr := errors.New("connection reset")
+	recorder := usecase.NewPaymentRecorder(&fakeOutboxSaver{}, &fakePayments{err: upsertErr}, tx)
+
+	payment, event := newRecordPaymentFixture()
+	_, err := recorder.RecordPayments(context.Background(), []usecase.PaymentEntry{{Payment: payment, Event: event}})
+
+	assert.ErrorIs(t, err, upsertErr)
+	assert.True(t, tx.rolledBack)
+}
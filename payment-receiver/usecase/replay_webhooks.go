@@ -0,0 +1,109 @@
+// Package usecase contains application logic and orchestrators.
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"payment-receiver/domain"
+	"payment-receiver/repository"
+
+	"github.com/google/uuid"
+)
+
+// WebhookParser turns a raw webhook body into the payment state and outbox
+// event it describes, applying the same rules as the live endpoint.
+type WebhookParser func(body []byte) (*domain.Payment, *domain.OutboxEvent, error)
+
+// ReplayOutcome describes what replaying one stored delivery did, or would do.
+type ReplayOutcome string
+
+const (
+	ReplayCreated        ReplayOutcome = "created"
+	ReplayDuplicate      ReplayOutcome = "duplicate"
+	ReplayInvalid        ReplayOutcome = "invalid"
+	ReplayFailed         ReplayOutcome = "failed"
+	ReplayWouldCreate    ReplayOutcome = "would_create"
+	ReplayWouldDuplicate ReplayOutcome = "would_duplicate"
+)
+
+// ReplayResult is the outcome of replaying one delivery.
+type ReplayResult struct {
+	Delivery      *domain.WebhookDelivery
+	Outcome       ReplayOutcome
+	AggregateID   string
+	OutboxEventID *uuid.UUID
+	Err           error
+}
+
+// WebhookReplayer re-ingests stored raw deliveries through the current
+// parsing rules and recorder. Signatures are not re-verified: a stored
+// request would fail any timestamp tolerance check by the time it is replayed.
+type WebhookReplayer struct {
+	Parse    WebhookParser
+	Recorder PaymentEventRecorder
+	Outbox   repository.OutboxRepository
+}
+
+func NewWebhookReplayer(
+	parse WebhookParser,
+	recorder PaymentEventRecorder,
+	outbox repository.OutboxRepository,
+) *WebhookReplayer {
+	return &WebhookReplayer{Parse: parse, Recorder: recorder, Outbox: outbox}
+}
+
+// Replay processes deliveries in order. With dryRun nothing is written and
+// the outcomes report what would be created or deduplicated.
+func (r *WebhookReplayer) Replay(
+	ctx context.Context,
+	deliveries []*domain.WebhookDelivery,
+	dryRun bool,
+) []ReplayResult {
+	results := make([]ReplayResult, 0, len(deliveries))
+	for _, d := range deliveries {
+		results = append(results, r.replayOne(ctx, d, dryRun))
+	}
+	return results
+}
+
+func (r *WebhookReplayer) replayOne(
+	ctx context.Context,
+	d *domain.WebhookDelivery,
+	dryRun bool,
+) ReplayResult {
+	res := ReplayResult{Delivery: d}
+
+	payment, event, err := r.Parse(d.Body)
+	if err != nil {
+		res.Outcome, res.Err = ReplayInvalid, err
+		return res
+	}
+	res.AggregateID = event.AggregateID
+
+	if dryRun {
+		exists, err := r.Outbox.ExistsByAggregateID(ctx, event.AggregateID)
+		switch {
+		case err != nil:
+			res.Outcome, res.Err = ReplayFailed, fmt.Errorf("failed to check idempotency: %w", err)
+		case exists:
+			res.Outcome = ReplayWouldDuplicate
+		default:
+			res.Outcome = ReplayWouldCreate
+		}
+		return res
+	}
+
+	err = r.Recorder.RecordPayment(ctx, payment, event)
+	switch {
+	case errors.Is(err, ErrDuplicateEvent):
+		res.Outcome = ReplayDuplicate
+	case err != nil:
+		res.Outcome, res.Err = ReplayFailed, err
+	default:
+		res.Outcome = ReplayCreated
+		res.OutboxEventID = &event.ID
+	}
+	return res
+}
@@ -0,0 +1,91 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"payment-receiver/domain"
+	"payment-receiver/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubPaymentRecorder struct {
+	duplicates map[string]bool
+	err        error
+	recorded   []string
+}
+
+func (s *stubPaymentRecorder) RecordPayment(
+	_ context.Context,
+	payment *domain.Payment,
+	_ *domain.OutboxEvent,
+) error {
+	if s.err != nil {
+		return s.err
+	}
+	if s.duplicates[payment.ID] {
+		return usecase.ErrDuplicateEvent
+	}
+	s.recorded = append(s.recorded, payment.ID)
+	return nil
+}
+
+// parseID treats the body as the payment ID; "bad" is rejected.
+func parseID(body []byte) (*domain.Payment, *domain.OutboxEvent, error) {
+	id := string(body)
+	if id == "bad" {
+		return nil, nil, errors.New("invalid payload")
+	}
+	return &domain.Payment{ID: id}, &domain.OutboxEvent{ID: uuid.New(), AggregateID: id}, nil
+}
+
+func deliveriesWithBodies(bodies ...string) []*domain.WebhookDelivery {
+	out := make([]*domain.WebhookDelivery, len(bodies))
+	for i, b := range bodies {
+		out[i] = &domain.WebhookDelivery{ID: uuid.New(), Body: []byte(b), StatusCode: 400}
+	}
+	return out
+}
+
+func TestWebhookReplayer_Replay(t *testing.T) {
+	recorder := &stubPaymentRecorder{duplicates: map[string]bool{"evt_dup": true}}
+	replayer := usecase.NewWebhookReplayer(parseID, recorder, &mockOutboxEnqueuerRepo{})
+
+	results := replayer.Replay(context.Background(), deliveriesWithBodies("evt_new", "evt_dup", "bad"), false)
+	require.Len(t, results, 3)
+
+	assert.Equal(t, usecase.ReplayCreated, results[0].Outcome)
+	require.NotNil(t, results[0].OutboxEventID)
+	assert.Equal(t, usecase.ReplayDuplicate, results[1].Outcome)
+	assert.Equal(t, usecase.ReplayInvalid, results[2].Outcome)
+	assert.Error(t, results[2].Err)
+	assert.Equal(t, []string{"evt_new"}, recorder.recorded)
+}
+
+func TestWebhookReplayer_Replay_DryRunWritesNothing(t *testing.T) {
+	recorder := &stubPaymentRecorder{}
+
+	replayer := usecase.NewWebhookReplayer(parseID, recorder, &mockOutboxEnqueuerRepo{ShouldExist: false})
+	results := replayer.Replay(context.Background(), deliveriesWithBodies("evt_new", "bad"), true)
+	assert.Equal(t, usecase.ReplayWouldCreate, results[0].Outcome)
+	assert.Equal(t, usecase.ReplayInvalid, results[1].Outcome)
+
+	replayer = usecase.NewWebhookReplayer(parseID, recorder, &mockOutboxEnqueuerRepo{ShouldExist: true})
+	results = replayer.Replay(context.Background(), deliveriesWithBodies("evt_old"), true)
+	assert.Equal(t, usecase.ReplayWouldDuplicate, results[0].Outcome)
+
+	assert.Empty(t, recorder.recorded)
+}
+
+func TestWebhookReplayer_Replay_RecorderFailure(t *testing.T) {
+	recorder := &stubPaymentRecorder{err: errors.New("db down")}
+	replayer := usecase.NewWebhookReplayer(parseID, recorder, &mockOutboxEnqueuerRepo{})
+
+	results := replayer.Replay(context.Background(), deliveriesWithBodies("evt_1"), false)
+	assert.Equal(t, usecase.ReplayFailed, results[0].Outcome)
+	assert.EqualError(t, results[0].Err, "db down")
+}
@@ -0,0 +1,57 @@
+// Package usecase contains application logic and orchestrators.
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"payment-receiver/repository"
+)
+
+// WebhookDeliveryPruner enforces the retention period of the webhook
+// delivery audit log.
+type WebhookDeliveryPruner struct {
+	Repo      repository.WebhookDeliveryRepository
+	Retention time.Duration
+}
+
+func NewWebhookDeliveryPruner(
+	repo repository.WebhookDeliveryRepository,
+	retention time.Duration,
+) *WebhookDeliveryPruner {
+	return &WebhookDeliveryPruner{Repo: repo, Retention: retention}
+}
+
+// Prune deletes deliveries older than the retention period. A non-positive
+// retention keeps everything.
+func (p *WebhookDeliveryPruner) Prune(ctx context.Context, now time.Time) (int64, error) {
+	if p.Retention <= 0 {
+		return 0, nil
+	}
+	n, err := p.Repo.DeleteReceivedBefore(ctx, now.Add(-p.Retention))
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune webhook deliveries: %w", err)
+	}
+	return n, nil
+}
+
+// Run prunes once per interval until ctx is done.
+func (p *WebhookDeliveryPruner) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if n, err := p.Prune(ctx, time.Now()); err != nil {
+			log.Println(err)
+		} else if n > 0 {
+			log.Printf("pruned %d webhook deliveries", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
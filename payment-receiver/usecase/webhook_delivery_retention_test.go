@@ -0,0 +1,58 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"payment-receiver/domain"
+	"payment-receiver/repository"
+	"payment-receiver/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeWebhookDeliveries struct {
+	cutoff time.Time
+}
+
+func (f *fakeWebhookDeliveries) Record(_ context.Context, _ *domain.WebhookDelivery) error {
+	return nil
+}
+
+func (f *fakeWebhookDeliveries) FindByID(_ context.Context, _ uuid.UUID) (*domain.WebhookDelivery, error) {
+	return nil, repository.ErrNotFound
+}
+
+func (f *fakeWebhookDeliveries) List(
+	_ context.Context,
+	_ repository.WebhookDeliveryFilter,
+) ([]*domain.WebhookDelivery, error) {
+	return nil, nil
+}
+
+func (f *fakeWebhookDeliveries) DeleteReceivedBefore(_ context.Context, t time.Time) (int64, error) {
+	f.cutoff = t
+	return 3, nil
+}
+
+func TestWebhookDeliveryPruner_Prune(t *testing.T) {
+	repo := &fakeWebhookDeliveries{}
+	now := time.Date(2024, 4, 10, 0, 0, 0, 0, time.UTC)
+
+	n, err := usecase.NewWebhookDeliveryPruner(repo, 72*time.Hour).Prune(context.Background(), now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+	assert.Equal(t, time.Date(2024, 4, 7, 0, 0, 0, 0, time.UTC), repo.cutoff)
+}
+
+func TestWebhookDeliveryPruner_NoRetentionKeepsEverything(t *testing.T) {
+	repo := &fakeWebhookDeliveries{}
+
+	n, err := usecase.NewWebhookDeliveryPruner(repo, 0).Prune(context.Background(), time.Now())
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	assert.True(t, repo.cutoff.IsZero())
+}